@@ -0,0 +1,97 @@
+// +build !lambda
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/config"
+	"github.com/jimbo/blandmockapi/internal/graphql"
+	"github.com/jimbo/blandmockapi/internal/models"
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+// runCheck loads and validates the configuration at path without starting
+// the server, writing a summary to out on success
+func runCheck(path string, out io.Writer) error {
+	loader := config.New()
+	loader.SetTemplateCheck(router.UndefinedTokens)
+	if err := loader.LoadFromPath(path); err != nil {
+		return err
+	}
+
+	cfg := loader.GetConfig()
+
+	// Build the GraphQL schema to catch schema errors before deploying
+	if cfg.GraphQL != nil && cfg.GraphQL.Enabled {
+		if _, err := graphql.New(cfg.GraphQL); err != nil {
+			return fmt.Errorf("invalid GraphQL configuration in %s: %w", path, err)
+		}
+	}
+
+	fmt.Fprintf(out, "Configuration OK: %s\n", path)
+	writeSummary(out, cfg)
+
+	// Templates that only break once rendered are reported without failing
+	for _, endpoint := range cfg.Endpoints {
+		for _, err := range router.CheckTemplates(endpoint) {
+			fmt.Fprintf(out, "Warning: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// writeSummary prints the server address, endpoints and GraphQL schema size
+func writeSummary(out io.Writer, cfg models.Config) {
+	if cfg.Server.SocketPath != "" {
+		fmt.Fprintf(out, "Server: unix:%s\n", cfg.Server.SocketPath)
+	} else if len(cfg.Server.Listen) > 0 {
+		fmt.Fprintf(out, "Server: %s\n", strings.Join(cfg.Server.Listen, ", "))
+	} else {
+		fmt.Fprintf(out, "Server: %s:%d\n", cfg.Server.GetHost(), cfg.Server.GetPort())
+	}
+
+	fmt.Fprintf(out, "Endpoints (%d):\n", len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		status := endpoint.Status
+		if status == 0 {
+			status = 200
+		}
+		if !endpoint.IsEnabled() {
+			fmt.Fprintf(out, "  %-7s %s -> disabled\n", endpointMethod(endpoint), endpoint.Path)
+			continue
+		}
+		fmt.Fprintf(out, "  %-7s %s -> %d\n", endpointMethod(endpoint), endpoint.Path, status)
+	}
+
+	if cfg.GraphQL != nil && cfg.GraphQL.Enabled {
+		path := cfg.GraphQL.Path
+		if path == "" {
+			path = "/graphql"
+		}
+		fmt.Fprintf(out, "GraphQL: %s (%d types, %d queries, %d mutations)\n",
+			path, len(cfg.GraphQL.Types), len(cfg.GraphQL.Queries), len(cfg.GraphQL.Mutations))
+	} else {
+		fmt.Fprintln(out, "GraphQL: disabled")
+	}
+}
+
+// endpointMethod returns the endpoint method with the GET default applied
+func endpointMethod(endpoint models.EndpointConfig) string {
+	if len(endpoint.MethodResponses) > 0 {
+		methods := make([]string, 0, len(endpoint.MethodResponses))
+		for _, expanded := range endpoint.ExpandMethods() {
+			methods = append(methods, expanded.Method)
+		}
+		return strings.Join(methods, ",")
+	}
+	if endpoint.Method == "" {
+		return "GET"
+	}
+	if endpoint.IsAnyMethod() {
+		return models.AnyMethod
+	}
+	return endpoint.Method
+}
@@ -0,0 +1,158 @@
+// +build !lambda
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCheck_Valid(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "valid.toml")
+
+	configContent := `
+[server]
+port = 9000
+
+[[endpoints]]
+path = "/api/users"
+method = "GET"
+response = '{"users": []}'
+
+[[endpoints]]
+path = "/api/users"
+method = "POST"
+status = 201
+response = '{"id": 1}'
+
+[graphql]
+enabled = true
+
+[[graphql.queries]]
+name = "ping"
+return_type = "String"
+response = '"pong"'
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runCheck(configPath, &out); err != nil {
+		t.Fatalf("Expected valid config, got error: %v", err)
+	}
+
+	summary := out.String()
+	for _, want := range []string{
+		"Configuration OK",
+		"Server: 0.0.0.0:9000",
+		"Endpoints (2):",
+		"GET     /api/users -> 200",
+		"POST    /api/users -> 201",
+		"GraphQL: /graphql (0 types, 1 queries, 0 mutations)",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, summary)
+		}
+	}
+}
+
+func TestRunCheck_DisabledEndpoint(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "disabled.toml")
+	configContent := `
+[[endpoints]]
+path = "/api/beta"
+enabled = false
+response = '{"beta": true}'
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runCheck(configPath, &out); err != nil {
+		t.Fatalf("Expected valid config, got error: %v", err)
+	}
+	if want := "GET     /api/beta -> disabled"; !strings.Contains(out.String(), want) {
+		t.Errorf("Expected summary to contain %q, got:\n%s", want, out.String())
+	}
+}
+
+func TestRunCheck_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "invalid.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/users"
+status = 700
+response = '{}'
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	err := runCheck(configPath, &out)
+	if err == nil {
+		t.Fatal("Expected error for invalid config, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "status 700") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no summary for invalid config, got %s", out.String())
+	}
+}
+
+func TestRunCheck_InvalidGraphQL(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "graphql.toml")
+
+	// A schema without any queries cannot be built
+	configContent := `
+[graphql]
+enabled = true
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runCheck(configPath, &out); err == nil {
+		t.Fatal("Expected GraphQL schema error, got nil")
+	}
+}
+
+func TestRunCheck_TemplateWarnings(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "templates.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/orders"
+method = "POST"
+response = '{"id": {{body.id}}}'
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runCheck(configPath, &out); err != nil {
+		t.Fatalf("Expected template problems to be warnings, got error: %v", err)
+	}
+	want := "Warning: endpoint POST /api/orders: response: renders invalid JSON"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jimbo/blandmockapi/internal/config"
+)
+
+// envFileName is the .env file loaded from the configuration directory when
+// -env-file is not given
+const envFileName = ".env"
+
+// loadEnvFile loads envFile into the process environment, or the .env file
+// next to the configuration at configPath when envFile is empty. A missing
+// auto-detected file is not an error; a missing -env-file is.
+func loadEnvFile(configPath, envFile string) error {
+	explicit := envFile != ""
+	if !explicit {
+		dir := configPath
+		if info, err := os.Stat(configPath); err != nil || !info.IsDir() {
+			dir = filepath.Dir(configPath)
+		}
+		envFile = filepath.Join(dir, envFileName)
+	}
+
+	names, err := config.LoadEnvFile(envFile)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	log.Printf("Loaded %d environment variables from %s", len(names), envFile)
+	return nil
+}
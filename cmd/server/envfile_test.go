@@ -0,0 +1,44 @@
+// +build !lambda
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEnvFile_ConfigDir(t *testing.T) {
+	t.Setenv("MOCK_API_KEY", "")
+	os.Unsetenv("MOCK_API_KEY")
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(configFile, []byte("[[endpoints]]\npath = \"/a\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// No .env next to the configuration is fine
+	if err := loadEnvFile(configFile, ""); err != nil {
+		t.Fatalf("Expected no error without a .env file, got %v", err)
+	}
+
+	// .env is found next to a config file or inside a config directory
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("MOCK_API_KEY=from-dotenv\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+	for _, configPath := range []string{configFile, dir} {
+		os.Unsetenv("MOCK_API_KEY")
+		if err := loadEnvFile(configPath, ""); err != nil {
+			t.Fatalf("%s: loadEnvFile failed: %v", configPath, err)
+		}
+		if got := os.Getenv("MOCK_API_KEY"); got != "from-dotenv" {
+			t.Errorf("%s: expected MOCK_API_KEY from .env, got %q", configPath, got)
+		}
+	}
+
+	// An explicit -env-file must exist
+	if err := loadEnvFile(configFile, filepath.Join(dir, "missing.env")); err == nil {
+		t.Error("Expected an error for a missing -env-file")
+	}
+}
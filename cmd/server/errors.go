@@ -0,0 +1,34 @@
+package main
+
+import "errors"
+
+// Exit codes reported by main. Flag parsing errors exit with 2.
+const (
+	exitFailure = 1 // the server failed while running
+	exitConfig  = 3 // the configuration could not be loaded or served, or failed -check
+	exitListen  = 4 // the listen address could not be bound
+)
+
+// startupError is an error that ends the process with a specific exit code
+type startupError struct {
+	code int
+	err  error
+}
+
+func (e *startupError) Error() string {
+	return e.err.Error()
+}
+
+func (e *startupError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code for err: the code of a startupError in its
+// chain, or exitFailure for any other error
+func exitCode(err error) int {
+	var startup *startupError
+	if errors.As(err, &startup) {
+		return startup.code
+	}
+	return exitFailure
+}
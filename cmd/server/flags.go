@@ -0,0 +1,30 @@
+// +build !lambda
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jimbo/blandmockapi/server"
+)
+
+// applyAddressFlags overrides the configured listen address with the -host
+// and -port flags. A nil flag was not given. Either flag replaces listen and
+// socket_path, so the server always binds the address asked for.
+func applyAddressFlags(cfg *server.ServerConfig, host *string, port *int) error {
+	if host == nil && port == nil {
+		return nil
+	}
+	if port != nil {
+		if *port < 0 || *port > 65535 {
+			return fmt.Errorf("-port %d outside range 0-65535", *port)
+		}
+		cfg.Port = *port
+	}
+	if host != nil {
+		cfg.Host = *host
+	}
+	cfg.Listen = nil
+	cfg.SocketPath = ""
+	return nil
+}
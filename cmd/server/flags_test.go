@@ -0,0 +1,45 @@
+// +build !lambda
+
+package main
+
+import (
+	"testing"
+
+	"github.com/jimbo/blandmockapi/server"
+)
+
+func TestApplyAddressFlags(t *testing.T) {
+	host, port := "127.0.0.1", 9100
+
+	tests := []struct {
+		name     string
+		host     *string
+		port     *int
+		expected server.ServerConfig
+	}{
+		{"no flags", nil, nil, server.ServerConfig{Host: "0.0.0.0", Port: 8080, Listen: []string{":8443"}}},
+		{"port flag beats config", nil, &port, server.ServerConfig{Host: "0.0.0.0", Port: 9100}},
+		{"host flag beats config", &host, nil, server.ServerConfig{Host: "127.0.0.1", Port: 8080}},
+		{"both flags", &host, &port, server.ServerConfig{Host: "127.0.0.1", Port: 9100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := server.ServerConfig{Host: "0.0.0.0", Port: 8080, Listen: []string{":8443"}}
+			if err := applyAddressFlags(&cfg, tt.host, tt.port); err != nil {
+				t.Fatalf("applyAddressFlags failed: %v", err)
+			}
+
+			if cfg.Host != tt.expected.Host || cfg.Port != tt.expected.Port || len(cfg.Listen) != len(tt.expected.Listen) {
+				t.Errorf("Expected %s:%d listen %v, got %s:%d listen %v",
+					tt.expected.Host, tt.expected.Port, tt.expected.Listen, cfg.Host, cfg.Port, cfg.Listen)
+			}
+		})
+	}
+
+	invalid := 70000
+	cfg := server.ServerConfig{}
+	if err := applyAddressFlags(&cfg, nil, &invalid); err == nil {
+		t.Error("Expected error for an out-of-range port, got nil")
+	}
+}
@@ -0,0 +1,86 @@
+// +build !lambda
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// runInit implements the init subcommand: it writes a starter configuration
+// serving the JSON document named by -from at -path, to -o or out
+func runInit(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	from := fs.String("from", "", "JSON file holding a sample response (required)")
+	path := fs.String("path", "/api/example", "Path of the generated endpoint")
+	method := fs.String("method", "GET", "Method of the generated endpoint")
+	output := fs.String("o", "", "File to write the configuration to instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" {
+		return errors.New("init: -from is required")
+	}
+	if !strings.HasPrefix(*path, "/") {
+		return fmt.Errorf("init: -path %q must start with /", *path)
+	}
+
+	sample, err := os.ReadFile(*from)
+	if err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimSpace(sample), "", "  "); err != nil {
+		return fmt.Errorf("init: %s is not valid JSON: %w", *from, err)
+	}
+
+	config, err := scaffoldConfig(*path, strings.ToUpper(*method), indented.String(), *from)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = io.WriteString(out, config)
+		return err
+	}
+	if err := os.WriteFile(*output, []byte(config), 0644); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+	fmt.Fprintf(out, "Wrote %s; run it with: blandmockapi -config %s\n", *output, *output)
+	return nil
+}
+
+// scaffoldConfig renders a configuration with one endpoint answering with
+// response, which is written as a multi-line literal string when possible
+func scaffoldConfig(path, method, response, source string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by blandmockapi init from %s\n\n", source)
+	b.WriteString("[server]\nport = 8080\n\n")
+	b.WriteString("[[endpoints]]\n")
+	fmt.Fprintf(&b, "path = %q\n", path)
+	fmt.Fprintf(&b, "method = %q\n", method)
+	b.WriteString("status = 200\n")
+
+	if !strings.Contains(response, "'''") {
+		fmt.Fprintf(&b, "response = '''\n%s\n'''\n", response)
+		return b.String(), nil
+	}
+
+	// Literal strings cannot hold ''', so fall back to an escaped string
+	var encoded bytes.Buffer
+	if err := toml.NewEncoder(&encoded).Encode(struct {
+		Response string `toml:"response"`
+	}{response}); err != nil {
+		return "", fmt.Errorf("init: %w", err)
+	}
+	b.Write(encoded.Bytes())
+	return b.String(), nil
+}
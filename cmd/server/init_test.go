@@ -0,0 +1,83 @@
+// +build !lambda
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/server"
+)
+
+func TestRunInit(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+	}{
+		{"object", `{"users": [{"id": 1, "name": "Alice"}], "total": 1}`},
+		{"triple quotes", `{"note": "it'''s"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			samplePath := filepath.Join(tmpDir, "response.json")
+			if err := os.WriteFile(samplePath, []byte(tt.sample), 0644); err != nil {
+				t.Fatalf("Failed to write sample: %v", err)
+			}
+			configPath := filepath.Join(tmpDir, "config.toml")
+
+			var out bytes.Buffer
+			if err := runInit([]string{"-from", samplePath, "-path", "/api/users", "-o", configPath}, &out); err != nil {
+				t.Fatalf("runInit failed: %v", err)
+			}
+
+			cfg, err := server.LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("Generated config does not load: %v", err)
+			}
+			if len(cfg.Endpoints) != 1 || cfg.Endpoints[0].Path != "/api/users" || cfg.Endpoints[0].Method != "GET" {
+				t.Fatalf("Expected one GET /api/users endpoint, got %+v", cfg.Endpoints)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal([]byte(cfg.Endpoints[0].Response), &got); err != nil {
+				t.Fatalf("Generated response is not JSON: %v", err)
+			}
+			json.Unmarshal([]byte(tt.sample), &want)
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Expected response %s, got %s", wantJSON, gotJSON)
+			}
+		})
+	}
+}
+
+func TestRunInit_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	invalid := filepath.Join(tmpDir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"a":`), 0644); err != nil {
+		t.Fatalf("Failed to write sample: %v", err)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-path", "/api/foo"}, "-from is required"},
+		{[]string{"-from", invalid}, "is not valid JSON"},
+		{[]string{"-from", invalid, "-path", "api/foo"}, "must start with /"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := runInit(tt.args, &out)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error containing %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
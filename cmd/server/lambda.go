@@ -3,21 +3,25 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
-	"github.com/jimbo/blandmockapi/internal/config"
-	"github.com/jimbo/blandmockapi/internal/graphql"
-	"github.com/jimbo/blandmockapi/internal/router"
+	"github.com/jimbo/blandmockapi/server"
 )
 
 func main() {
-	runLambda()
+	if err := runLambda(); err != nil {
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
 }
 
-func runLambda() {
+// runLambda serves the configuration as a Lambda function. Startup failures
+// are returned as startupErrors carrying the exit code.
+func runLambda() error {
 	log.Println("Initializing Lambda handler...")
 
 	// Get config path from environment or use default
@@ -26,42 +30,26 @@ func runLambda() {
 		configPath = "./config"
 	}
 
-	// Load configuration
-	loader := config.New()
-	if err := loader.LoadFromPath(configPath); err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+	// Load the .env file next to the configuration, if present, as the
+	// server and -check do
+	if err := loadEnvFile(configPath, ""); err != nil {
+		return &startupError{exitConfig, err}
 	}
 
-	cfg := loader.GetConfig()
-	log.Printf("Loaded configuration with %d endpoints", len(cfg.Endpoints))
-
-	// Create router
-	rt := router.New()
-
-	// Register health check
-	rt.RegisterHealthCheck()
-
-	// Register REST endpoints
-	if err := rt.RegisterEndpoints(cfg.Endpoints); err != nil {
-		log.Fatalf("Failed to register endpoints: %v", err)
+	// Load configuration
+	cfg, err := server.LoadConfig(configPath)
+	if err != nil {
+		return &startupError{exitConfig, fmt.Errorf("failed to load configuration: %w", err)}
 	}
+	log.Printf("Loaded configuration with %d endpoints", len(cfg.Endpoints))
 
-	// Register GraphQL endpoint if enabled
-	if cfg.GraphQL != nil && cfg.GraphQL.Enabled {
-		gqlHandler, err := graphql.New(cfg.GraphQL)
-		if err != nil {
-			log.Fatalf("Failed to create GraphQL handler: %v", err)
-		}
-
-		path := cfg.GraphQL.Path
-		if path == "" {
-			path = "/graphql"
-		}
-		rt.RegisterGraphQL(path, gqlHandler.ServeHTTP)
-		log.Printf("GraphQL endpoint enabled")
+	handler, err := server.NewHandler(cfg, server.Options{Build: build})
+	if err != nil {
+		return &startupError{exitConfig, err}
 	}
 
 	// Create Lambda handler using httpadapter
 	log.Println("Starting Lambda handler...")
-	lambda.Start(httpadapter.New(rt.Handler()).ProxyWithContext)
+	lambda.Start(httpadapter.New(handler).ProxyWithContext)
+	return nil
 }
@@ -4,112 +4,161 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/jimbo/blandmockapi/internal/config"
-	"github.com/jimbo/blandmockapi/internal/graphql"
-	"github.com/jimbo/blandmockapi/internal/router"
+	"github.com/jimbo/blandmockapi/server"
 )
 
 var (
 	configPath = flag.String("config", "./examples", "Path to configuration file or directory")
 	lambda     = flag.Bool("lambda", false, "Run in AWS Lambda mode")
+	bench      = flag.Bool("bench", false, "Benchmark mode: disable request logging and serve static responses from precomputed bytes")
+	check      = flag.Bool("check", false, "Validate the configuration, print a summary and exit without starting the server")
+	host       = flag.String("host", "", "Host to listen on, overriding the configuration")
+	port       = flag.Int("port", 0, "Port to listen on, overriding the configuration")
+	envFile    = flag.String("env-file", "", "KEY=value file loaded into the environment; default .env next to the configuration, if present")
 )
 
 func main() {
+	// Subcommands come before any flags
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		if err := runInit(os.Args[2:], os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitFailure)
+		}
+		return
+	}
+
 	flag.Parse()
 
-	// Check if running in Lambda mode
-	if *lambda || os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
-		runLambda()
+	// Load the .env file before choosing a mode, so -check and Lambda see
+	// the same environment as the server. Variables already in the
+	// environment take precedence over the file.
+	if err := loadEnvFile(*configPath, *envFile); err != nil {
+		log.Printf("%v", err)
+		os.Exit(exitConfig)
+	}
+
+	// Validate configuration only
+	if *check {
+		if err := runCheck(*configPath, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration check failed:\n%v\n", err)
+			os.Exit(exitConfig)
+		}
 		return
 	}
 
-	// Standard server mode
-	runServer()
+	// Check if running in Lambda mode, otherwise run the standard server
+	run := runServer
+	if *lambda || os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+		run = runLambda
+	}
+	if err := run(); err != nil {
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
 }
 
-func runServer() {
+// runServer serves the configuration until SIGINT or SIGTERM. Startup
+// failures are returned as startupErrors carrying the exit code.
+func runServer() error {
 	log.Println("Starting Bland Mock API...")
 
 	// Load configuration
-	loader := config.New()
-	if err := loader.LoadFromPath(*configPath); err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+	cfg, err := server.LoadConfig(*configPath)
+	if err != nil {
+		return &startupError{exitConfig, fmt.Errorf("failed to load configuration: %w", err)}
 	}
-
-	cfg := loader.GetConfig()
 	log.Printf("Loaded configuration with %d endpoints", len(cfg.Endpoints))
 
-	// Create router
-	rt := router.New()
-
-	// Register health check
-	rt.RegisterHealthCheck()
-
-	// Register REST endpoints
-	if err := rt.RegisterEndpoints(cfg.Endpoints); err != nil {
-		log.Fatalf("Failed to register endpoints: %v", err)
+	// Command-line -host and -port take precedence over the configuration
+	var hostOverride *string
+	var portOverride *int
+	if flagGiven("host") {
+		hostOverride = host
+	}
+	if flagGiven("port") {
+		portOverride = port
+	}
+	if err := applyAddressFlags(&cfg.Server, hostOverride, portOverride); err != nil {
+		return &startupError{exitConfig, err}
 	}
 
-	// Register GraphQL endpoint if enabled
-	if cfg.GraphQL != nil && cfg.GraphQL.Enabled {
-		gqlHandler, err := graphql.New(cfg.GraphQL)
-		if err != nil {
-			log.Fatalf("Failed to create GraphQL handler: %v", err)
-		}
-
-		path := cfg.GraphQL.Path
-		if path == "" {
-			path = "/graphql"
-		}
-		rt.RegisterGraphQL(path, gqlHandler.ServeHTTP)
-		log.Printf("GraphQL endpoint enabled with %d types, %d queries, %d mutations",
-			len(cfg.GraphQL.Types), len(cfg.GraphQL.Queries), len(cfg.GraphQL.Mutations))
+	if *bench {
+		log.Println("Benchmark mode enabled: request logging disabled")
 	}
 
-	// Create HTTP server
-	addr := fmt.Sprintf("%s:%d", cfg.Server.GetHost(), cfg.Server.GetPort())
-	srv := &http.Server{
-		Addr:         addr,
-		Handler:      rt.Handler(),
-		ReadTimeout:  cfg.Server.GetReadTimeout(),
-		WriteTimeout: cfg.Server.GetWriteTimeout(),
+	srv, err := server.New(cfg, server.Options{BenchMode: *bench, Build: build})
+	if err != nil {
+		return &startupError{exitConfig, err}
 	}
 
-	// Start server in a goroutine
+	// Handle signals before listening, so one arriving while the server
+	// starts is not left to its default action of killing the process.
+	// Reload the configuration on SIGHUP. Server settings such as the
+	// listen address and timeouts only take effect after a restart.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
 	go func() {
-		log.Printf("Server listening on %s", addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+		for range reload {
+			reloadServer(*configPath, srv)
 		}
 	}()
 
-	// Wait for interrupt signal for graceful shutdown
+	// Shut down gracefully on SIGINT or SIGTERM
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
 
-	log.Println("Shutting down server...")
+	if err := srv.Start(); err != nil {
+		return &startupError{exitListen, fmt.Errorf("server failed to start: %w", err)}
+	}
+	failed := make(chan error, 1)
+	go func() {
+		if err := srv.Wait(); err != nil {
+			failed <- err
+		}
+	}()
+
+	// Wait for an interrupt signal or a server failure
+	select {
+	case <-quit:
+	case err := <-failed:
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	log.Printf("Shutting down server on %s...", strings.Join(srv.Addrs(), ", "))
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
 
 	log.Println("Server exited")
+	return nil
+}
+
+// flagGiven reports whether the named flag was set on the command line
+func flagGiven(name string) bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			given = true
+		}
+	})
+	return given
 }
 
-func runLambda() {
-	log.Fatal("Lambda mode requires building with -tags lambda. See README for details.")
+func runLambda() error {
+	return errors.New("lambda mode requires building with -tags lambda, see README for details")
 }
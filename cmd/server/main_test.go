@@ -0,0 +1,62 @@
+// +build !lambda
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunServer_ConfigError(t *testing.T) {
+	previous := *configPath
+	defer func() { *configPath = previous }()
+	*configPath = filepath.Join(t.TempDir(), "missing.toml")
+
+	err := runServer()
+	if err == nil {
+		t.Fatal("Expected an error for a missing configuration, got nil")
+	}
+	if code := exitCode(err); code != exitConfig {
+		t.Errorf("Expected exit code %d, got %d: %v", exitConfig, code, err)
+	}
+}
+
+func TestRunServer_ListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := fmt.Sprintf("[server]\nhost = \"127.0.0.1\"\nport = %d\n", listener.Addr().(*net.TCPAddr).Port)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	previous := *configPath
+	defer func() { *configPath = previous }()
+	*configPath = path
+
+	err = runServer()
+	if err == nil {
+		t.Fatal("Expected an error for a port in use, got nil")
+	}
+	if code := exitCode(err); code != exitListen {
+		t.Errorf("Expected exit code %d, got %d: %v", exitListen, code, err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	wrapped := fmt.Errorf("context: %w", &startupError{exitConfig, errors.New("bad config")})
+	if code := exitCode(wrapped); code != exitConfig {
+		t.Errorf("Expected exit code %d for a wrapped startup error, got %d", exitConfig, code)
+	}
+	if code := exitCode(errors.New("crashed")); code != exitFailure {
+		t.Errorf("Expected exit code %d for other errors, got %d", exitFailure, code)
+	}
+}
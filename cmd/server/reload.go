@@ -0,0 +1,33 @@
+// +build !lambda
+
+package main
+
+import (
+	"log"
+
+	"github.com/jimbo/blandmockapi/server"
+)
+
+// reloadServer reloads the configuration at path into srv. On failure the
+// error is logged and the previous configuration keeps serving.
+func reloadServer(path string, srv *server.Server) {
+	log.Printf("Reloading configuration from %s...", path)
+
+	// Fail the readiness probe while the files are read, not just while the
+	// new handler is built
+	srv.SetReady(false)
+	defer srv.SetReady(true)
+
+	cfg, err := server.LoadConfig(path)
+	if err != nil {
+		log.Printf("Reload failed, keeping previous configuration: %v", err)
+		return
+	}
+
+	if err := srv.Reload(cfg); err != nil {
+		log.Printf("Reload failed, keeping previous configuration: %v", err)
+		return
+	}
+
+	log.Printf("Reloaded configuration with %d endpoints", len(cfg.Endpoints))
+}
@@ -0,0 +1,112 @@
+// +build !lambda
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess runs the server when invoked as a subprocess by
+// TestSIGHUP_ReloadsConfig; it is a no-op in a normal test run
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("BLANDMOCKAPI_HELPER_PROCESS") != "1" {
+		return
+	}
+	os.Args = []string{"server", "-config", os.Getenv("BLANDMOCKAPI_CONFIG")}
+	main()
+	os.Exit(0)
+}
+
+func TestSIGHUP_ReloadsConfig(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	tmpDir := t.TempDir()
+	baseConfig := fmt.Sprintf(`
+[server]
+host = "127.0.0.1"
+port = %d
+
+[[endpoints]]
+path = "/api/original"
+method = "GET"
+response = '{"version": 1}'
+`, port)
+	if err := os.WriteFile(filepath.Join(tmpDir, "base.toml"), []byte(baseConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "BLANDMOCKAPI_HELPER_PROCESS=1", "BLANDMOCKAPI_CONFIG="+tmpDir)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Signal(syscall.SIGTERM)
+		_ = cmd.Wait()
+	}()
+
+	baseURL := fmt.Sprintf("http://127.0.0.1:%d", port)
+	if status := waitForStatus(baseURL+"/api/original", http.StatusOK); status != http.StatusOK {
+		t.Fatalf("Server did not start serving, last status %d", status)
+	}
+	if status := getStatus(baseURL + "/api/added"); status != http.StatusNotFound {
+		t.Fatalf("Expected 404 before reload, got %d", status)
+	}
+
+	addedConfig := `
+[[endpoints]]
+path = "/api/added"
+method = "GET"
+response = '{"added": true}'
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "added.toml"), []byte(addedConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := cmd.Process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+
+	if status := waitForStatus(baseURL+"/api/added", http.StatusOK); status != http.StatusOK {
+		t.Errorf("Expected new endpoint to respond after SIGHUP, last status %d", status)
+	}
+	if status := getStatus(baseURL + "/api/original"); status != http.StatusOK {
+		t.Errorf("Expected original endpoint to keep responding, got %d", status)
+	}
+}
+
+// waitForStatus polls url for up to five seconds until it returns want,
+// returning the last status seen (0 if the server never answered)
+func waitForStatus(url string, want int) int {
+	status := 0
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if status = getStatus(url); status == want {
+			break
+		}
+	}
+	return status
+}
+
+// getStatus returns the status code of a GET request, or 0 on error
+func getStatus(url string) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode
+}
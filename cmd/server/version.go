@@ -0,0 +1,14 @@
+package main
+
+import "github.com/jimbo/blandmockapi/server"
+
+// Build information, set at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// build identifies this binary at /__version
+var build = server.BuildInfo{Version: version, Commit: commit, Date: buildDate}
@@ -3,8 +3,14 @@ module github.com/jimbo/blandmockapi
 go 1.24
 
 require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
-	github.com/aws/aws-lambda-go v1.49.0 // indirect
-	github.com/awslabs/aws-lambda-go-api-proxy v0.16.2 // indirect
-	github.com/graphql-go/graphql v0.8.1 // indirect
+	github.com/BurntSushi/toml v1.5.0
+	github.com/PaesslerAG/gval v1.2.4
+	github.com/PaesslerAG/jsonpath v0.1.1
+	github.com/aws/aws-lambda-go v1.49.0
+	github.com/awslabs/aws-lambda-go-api-proxy v0.16.2
+	github.com/graphql-go/graphql v0.8.1
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/shopspring/decimal v1.3.1 // indirect
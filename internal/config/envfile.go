@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envNamePattern matches the variable names a .env file may set
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadEnvFile sets the process environment variables defined in the .env
+// file at path, one KEY=value pair per line, returning the names it set.
+// Variables already in the environment keep their value, even when empty.
+// Blank lines and lines starting with # are skipped, and an optional
+// "export " prefix is ignored. Values may be double-quoted, with \n, \t, \"
+// and \\ escapes, or single-quoted, taken literally; unquoted values end at
+// " #".
+func LoadEnvFile(path string) ([]string, error) {
+	values, err := parseEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set []string
+	for _, pair := range values {
+		if _, exists := os.LookupEnv(pair[0]); exists {
+			continue
+		}
+		if err := os.Setenv(pair[0], pair[1]); err != nil {
+			return set, fmt.Errorf("env file %s: %w", path, err)
+		}
+		set = append(set, pair[0])
+	}
+	return set, nil
+}
+
+// parseEnvFile reads the name/value pairs of the .env file at path in order,
+// later lines overriding earlier ones when set
+func parseEnvFile(path string) ([][2]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var values [][2]string
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("env file %s:%d: expected KEY=value", path, number)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("env file %s:%d: %w", path, number, err)
+		}
+
+		if i, seen := index[name]; seen {
+			values[i][1] = value
+			continue
+		}
+		index[name] = len(values)
+		values = append(values, [2]string{name, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseEnvValue unquotes one .env value
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated double-quoted value")
+		}
+		replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+		return replacer.Replace(value[1:end]), nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return value[1 : end+1], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// closingQuote returns the index of the double quote ending the value that
+// starts with one, skipping escaped quotes, or -1
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
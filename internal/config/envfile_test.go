@@ -0,0 +1,105 @@
+package config
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+// unsetEnv clears names for the test, restoring them afterwards
+func unsetEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	unsetEnv(t, "MOCK_GREETING", "MOCK_QUOTED", "MOCK_LITERAL", "MOCK_EXPORTED", "MOCK_COMMENTED", "MOCK_REGION")
+	t.Setenv("MOCK_REGION", "from-environment")
+
+	envPath := filepath.Join(t.TempDir(), ".env")
+	content := `# Local development secrets
+MOCK_GREETING=hello from dotenv
+MOCK_QUOTED="line one\nsays \"hi\""
+MOCK_LITERAL='no $expansion \n here'
+export MOCK_EXPORTED=yes
+MOCK_COMMENTED=value # trailing comment
+
+MOCK_REGION=from-file
+`
+	if err := os.WriteFile(envPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	names, err := LoadEnvFile(envPath)
+	if err != nil {
+		t.Fatalf("LoadEnvFile failed: %v", err)
+	}
+	if len(names) != 5 {
+		t.Errorf("Expected 5 variables set, got %v", names)
+	}
+
+	expected := map[string]string{
+		"MOCK_GREETING":  "hello from dotenv",
+		"MOCK_QUOTED":    "line one\nsays \"hi\"",
+		"MOCK_LITERAL":   `no $expansion \n here`,
+		"MOCK_EXPORTED":  "yes",
+		"MOCK_COMMENTED": "value",
+		// Real environment variables take precedence over the file
+		"MOCK_REGION": "from-environment",
+	}
+	for name, value := range expected {
+		if got := os.Getenv(name); got != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, got)
+		}
+	}
+
+	// Responses read the loaded values through {{env.NAME}}
+	rt := router.New()
+	rt.SetTemplateEnv([]string{"MOCK_*"})
+	if err := rt.RegisterEndpoint(models.EndpointConfig{
+		Path:     "/api/greeting",
+		Method:   "GET",
+		Response: `{"greeting": "{{env.MOCK_GREETING}}", "region": "{{env.MOCK_REGION}}"}`,
+	}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/greeting", nil))
+	if body := `{"greeting": "hello from dotenv", "region": "from-environment"}`; w.Body.String() != body {
+		t.Errorf("Expected %s, got %s", body, w.Body.String())
+	}
+}
+
+func TestLoadEnvFile_Invalid(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"GOOD=1\nnot a pair\n", ".env:2: expected KEY=value"},
+		{"1BAD=x\n", ".env:1: expected KEY=value"},
+		{"QUOTED=\"open\n", ".env:1: unterminated double-quoted value"},
+		{"QUOTED='open\n", ".env:1: unterminated single-quoted value"},
+	}
+	for _, tt := range tests {
+		envPath := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(envPath, []byte(tt.content), 0644); err != nil {
+			t.Fatalf("Failed to write env file: %v", err)
+		}
+		_, err := LoadEnvFile(envPath)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+		}
+	}
+
+	if _, err := LoadEnvFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("Expected an error for a missing env file")
+	}
+}
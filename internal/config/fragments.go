@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// fragmentPattern matches fragment includes such as {{> meta}}
+var fragmentPattern = regexp.MustCompile(`\{\{>\s*([^{}\s]+)\s*\}\}`)
+
+// expandFragments replaces {{> name}} includes in every response body with
+// the named [fragments] text, reporting unknown and recursive includes.
+// Template tokens inside fragments are left for the request to fill in.
+func (l *Loader) expandFragments() error {
+	var errs []error
+	expand := func(label string, body *string) {
+		expanded, err := expandIncludes(*body, l.config.Fragments, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+			return
+		}
+		*body = expanded
+	}
+
+	for i := range l.config.Endpoints {
+		endpoint := &l.config.Endpoints[i]
+		label := "endpoint " + endpoint.RouteKey()
+
+		// Raw responses are served exactly as written, includes and all
+		if !endpoint.RawResponse {
+			expand(label+": response", &endpoint.Response)
+		}
+		for j := range endpoint.Responses {
+			expand(fmt.Sprintf("%s: responses[%d]", label, j), &endpoint.Responses[j].Response)
+		}
+		for j := range endpoint.Localized {
+			expand(fmt.Sprintf("%s: localized[%d]", label, j), &endpoint.Localized[j].Response)
+		}
+		expand(label+": anonymous_response", &endpoint.AnonymousResponse)
+		methods := make([]string, 0, len(endpoint.MethodResponses))
+		for method := range endpoint.MethodResponses {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			body := endpoint.MethodResponses[method]
+			expand(label+": method_responses."+method, &body)
+			endpoint.MethodResponses[method] = body
+		}
+	}
+	if l.config.DefaultEndpoint != nil {
+		expand("default_endpoint: response", &l.config.DefaultEndpoint.Response)
+	}
+	if l.config.Errors != nil {
+		expand("errors: template", &l.config.Errors.Template)
+	}
+	return errors.Join(errs...)
+}
+
+// expandIncludes expands the includes in body, and in the fragments it
+// includes. stack lists the fragments being expanded, to catch cycles.
+func expandIncludes(body string, fragments map[string]string, stack []string) (string, error) {
+	if !strings.Contains(body, "{{>") {
+		return body, nil
+	}
+
+	var err error
+	expanded := fragmentPattern.ReplaceAllStringFunc(body, func(token string) string {
+		if err != nil {
+			return token
+		}
+		name := fragmentPattern.FindStringSubmatch(token)[1]
+		fragment, ok := fragments[name]
+		if !ok {
+			err = fmt.Errorf("{{> %s}} does not match any [fragments] name", name)
+			return token
+		}
+		if slices.Contains(stack, name) {
+			err = fmt.Errorf("fragment %q includes itself", name)
+			return token
+		}
+		var inner string
+		inner, err = expandIncludes(fragment, fragments, append(stack, name))
+		return inner
+	})
+	return expanded, err
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+func TestLoadFromPath_Fragments(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fragments := `
+[fragments]
+meta = '{"version": "v1", "path": "{{path}}", "links": {{> links}}}'
+links = '{"docs": "/docs"}'
+`
+	endpoints := `
+[[endpoints]]
+path = "/api/users"
+method = "GET"
+response = '{"users": [], "meta": {{> meta}}}'
+
+[[endpoints]]
+path = "/api/orders"
+method = "GET"
+response = '{"orders": [], "meta": {{>meta}}}'
+`
+	// The includes load before the fragments to show expansion happens
+	// after every file is merged
+	if err := os.WriteFile(filepath.Join(tmpDir, "01-endpoints.toml"), []byte(endpoints), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "02-fragments.toml"), []byte(fragments), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFromPath(tmpDir); err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+
+	rt := router.New()
+	if err := rt.RegisterEndpoints(loader.GetConfig().Endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/users", `{"users": [], "meta": {"version": "v1", "path": "/api/users", "links": {"docs": "/docs"}}}`},
+		{"/api/orders", `{"orders": [], "meta": {"version": "v1", "path": "/api/orders", "links": {"docs": "/docs"}}}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		rt.Handler().ServeHTTP(w, req)
+
+		// Tokens inside the fragment are filled in per request
+		if w.Body.String() != tt.expected {
+			t.Errorf("Expected %s from %s, got %s", tt.expected, tt.path, w.Body.String())
+		}
+	}
+}
+
+func TestLoadFromPath_RawResponseKeepsIncludes(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "raw.toml")
+	config := `
+[fragments]
+meta = '{"version": "v1"}'
+
+[[endpoints]]
+path = "/api/broken"
+raw_response = true
+response = '{"meta": {{> meta}}'
+`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFromPath(configPath); err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+	if got := loader.GetConfig().Endpoints[0].Response; got != `{"meta": {{> meta}}` {
+		t.Errorf("Expected the raw response to be left as written, got %s", got)
+	}
+}
+
+func TestLoadFromPath_InvalidFragments(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			"unknown",
+			`
+[[endpoints]]
+path = "/api/users"
+response = '{"meta": {{> missing}}}'
+`,
+			`endpoint GET /api/users: response: {{> missing}} does not match any [fragments] name`,
+		},
+		{
+			"recursive",
+			`
+[fragments]
+a = '{"b": {{> b}}}'
+b = '{"a": {{> a}}}'
+
+[[endpoints]]
+path = "/api/users"
+response = '{{> a}}'
+`,
+			`fragment "a" includes itself`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "fragments.toml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to create test config: %v", err)
+			}
+
+			err := New().LoadFromPath(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -1,17 +1,26 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/jimbo/blandmockapi/internal/json5"
 	"github.com/jimbo/blandmockapi/internal/models"
 )
 
 // Loader handles loading and merging configuration files
 type Loader struct {
 	config models.Config
+	// Reports an endpoint's undefined template tokens for strict_templates
+	templateCheck func(models.EndpointConfig) []error
 }
 
 // New creates a new configuration loader
@@ -29,6 +38,14 @@ func New() *Loader {
 	}
 }
 
+// SetTemplateCheck sets the function reporting the template tokens of an
+// endpoint that no variable or function defines, such as
+// router.UndefinedTokens. It is only called for strict_templates, which fails
+// the load without one.
+func (l *Loader) SetTemplateCheck(check func(models.EndpointConfig) []error) {
+	l.templateCheck = check
+}
+
 // LoadFile loads a single TOML configuration file
 func (l *Loader) LoadFile(path string) error {
 	data, err := os.ReadFile(path)
@@ -37,39 +54,201 @@ func (l *Loader) LoadFile(path string) error {
 	}
 
 	var cfg models.Config
-	if err := toml.Unmarshal(data, &cfg); err != nil {
+	meta, err := toml.Decode(string(data), &cfg)
+	if err != nil {
 		return fmt.Errorf("failed to parse config file %s: %w", path, err)
 	}
 
-	// Merge the loaded config into the main config
+	// Reject keys that map to no config field, which are usually typos
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return fmt.Errorf("unknown field(s) in config file %s: %s", path, strings.Join(keys, ", "))
+	}
+
+	// Duplicates within one file are almost always a copy/paste mistake;
+	// duplicates across files are treated as deliberate overrides
+	if err := checkDuplicateEndpoints(cfg.Endpoints); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	// Static directories are relative to the file declaring them
+	for i := range cfg.Static {
+		static := &cfg.Static[i]
+		if static.Dir != "" && !filepath.IsAbs(static.Dir) {
+			static.Dir = filepath.Join(filepath.Dir(path), static.Dir)
+		}
+		if static.Dir != "" {
+			if info, err := os.Stat(static.Dir); err != nil || !info.IsDir() {
+				return fmt.Errorf("invalid config file %s: static dir %s is not a directory", path, static.Dir)
+			}
+		}
+	}
+
+	// Response files are relative to the file declaring them, and read once
+	// at load time
+	if err := loadResponseFiles(cfg.Endpoints, filepath.Dir(path)); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	// Endpoints generated from an OpenAPI spec are merged first so the
+	// file's own endpoints can override them
+	if cfg.OpenAPI != "" {
+		specPath := cfg.OpenAPI
+		if !filepath.IsAbs(specPath) {
+			specPath = filepath.Join(filepath.Dir(path), specPath)
+		}
+		endpoints, err := LoadOpenAPI(specPath)
+		if err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		log.Printf("Generated %d endpoints from OpenAPI spec %s", len(endpoints), specPath)
+		l.mergeConfig(models.Config{Endpoints: endpoints})
+	}
+
+	// Merge the loaded config into the main config, remembering which file
+	// each GraphQL definition came from for schema errors
+	annotateGraphQLSources(cfg.GraphQL, path)
 	l.mergeConfig(cfg)
+	l.config.Files = append(l.config.Files, path)
 	return nil
 }
 
-// LoadDirectory loads all .toml files from a directory
-func (l *Loader) LoadDirectory(dir string) error {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return fmt.Errorf("failed to read directory %s: %w", dir, err)
+// annotateGraphQLSources records path as the source of every type, scalar,
+// query, mutation and persisted query in graphql
+func annotateGraphQLSources(graphql *models.GraphQLConfig, path string) {
+	if graphql == nil {
+		return
+	}
+	for i := range graphql.Types {
+		graphql.Types[i].Source = path
+	}
+	for i := range graphql.Scalars {
+		graphql.Scalars[i].Source = path
+	}
+	for i := range graphql.Queries {
+		graphql.Queries[i].Source = path
 	}
+	for i := range graphql.Mutations {
+		graphql.Mutations[i].Source = path
+	}
+	for i := range graphql.Persisted {
+		graphql.Persisted[i].Source = path
+	}
+}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
+// checkDuplicateEndpoints reports endpoints within one file that share a
+// route. Disabled endpoints are never served, so a disabled copy may sit next
+// to the enabled definition it stands in for.
+func checkDuplicateEndpoints(endpoints []models.EndpointConfig) error {
+	seen := make(map[string]int)
+	for i := range endpoints {
+		if !endpoints[i].IsEnabled() {
 			continue
 		}
+		for _, key := range endpoints[i].RouteKeys() {
+			if first, exists := seen[key]; exists && first != i {
+				return fmt.Errorf("duplicate endpoint %s (endpoints %d and %d)", key, first, i)
+			}
+			seen[key] = i
+		}
+	}
+	return nil
+}
+
+// overrideEndpoints merges the endpoints of a later file into earlier ones.
+// Routes are compared per method, so an endpoint using method_responses
+// overrides, and is overridden by, the endpoints serving each of its
+// methods. An earlier endpoint losing only some methods keeps the others; one
+// losing all of them is replaced in place by the first later endpoint taking
+// them over. Endpoints of the same file never override one another, so a
+// disabled copy cannot replace its enabled twin.
+func overrideEndpoints(earlier, later []models.EndpointConfig) []models.EndpointConfig {
+	owners := make(map[string]int)
+	for i := range later {
+		for _, key := range later[i].RouteKeys() {
+			if _, exists := owners[key]; !exists {
+				owners[key] = i
+			}
+		}
+	}
+
+	merged := make([]models.EndpointConfig, 0, len(earlier)+len(later))
+	placed := make([]bool, len(later))
+	for _, endpoint := range earlier {
+		owner := -1
+		var kept []string
+		for _, expanded := range endpoint.ExpandMethods() {
+			key := expanded.RouteKey()
+			i, overridden := owners[key]
+			if !overridden {
+				kept = append(kept, expanded.Method)
+				continue
+			}
+			log.Printf("Overriding endpoint %s with later definition", key)
+			if owner < 0 {
+				owner = i
+			}
+		}
 
-		if filepath.Ext(entry.Name()) == ".toml" {
-			path := filepath.Join(dir, entry.Name())
-			if err := l.LoadFile(path); err != nil {
-				return err
+		switch {
+		case owner < 0:
+			merged = append(merged, endpoint)
+		case len(kept) > 0:
+			remaining := make(map[string]string, len(kept))
+			for method, response := range endpoint.MethodResponses {
+				if slices.Contains(kept, strings.ToUpper(method)) {
+					remaining[method] = response
+				}
 			}
+			endpoint.MethodResponses = remaining
+			merged = append(merged, endpoint)
+		}
+		if owner >= 0 && !placed[owner] {
+			merged = append(merged, later[owner])
+			placed[owner] = true
+		}
+	}
+
+	for i := range later {
+		if !placed[i] {
+			merged = append(merged, later[i])
+		}
+	}
+	return merged
+}
+
+// LoadDirectory loads all .toml files from a directory and its subdirectories.
+// Files are loaded in sorted path order, independent of the order the
+// filesystem returns them, so later files reliably override earlier ones.
+func (l *Loader) LoadDirectory(dir string) error {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".toml" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := l.LoadFile(path); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// LoadFromPath loads configuration from a file or directory
+// LoadFromPath loads configuration from a file or directory and validates the result
 func (l *Loader) LoadFromPath(path string) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -77,10 +256,126 @@ func (l *Loader) LoadFromPath(path string) error {
 	}
 
 	if info.IsDir() {
-		return l.LoadDirectory(path)
+		err = l.LoadDirectory(path)
+	} else {
+		err = l.LoadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Resolve shared response references now that every file is merged
+	if err := l.resolveResponseRefs(); err != nil {
+		return fmt.Errorf("invalid configuration in %s:\n%w", path, err)
 	}
 
-	return l.LoadFile(path)
+	// Expand fragment includes, including those in referenced bodies
+	if err := l.expandFragments(); err != nil {
+		return fmt.Errorf("invalid configuration in %s:\n%w", path, err)
+	}
+
+	// Convert JSON5 bodies, including referenced ones, before validating JSON
+	if err := l.convertResponseFormats(); err != nil {
+		return fmt.Errorf("invalid configuration in %s:\n%w", path, err)
+	}
+
+	// Validate the merged configuration so every problem is reported at once
+	if err := l.config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration in %s:\n%w", path, err)
+	}
+
+	// Misspelled template tokens would be served literally
+	if l.config.Server.StrictTemplates {
+		if err := l.checkTemplateTokens(); err != nil {
+			return fmt.Errorf("invalid configuration in %s:\n%w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// checkTemplateTokens reports every response template token that no
+// variable or function defines
+func (l *Loader) checkTemplateTokens() error {
+	if l.templateCheck == nil {
+		return errors.New("strict_templates is set but the loader has no template check")
+	}
+	var errs []error
+	for _, endpoint := range l.config.Endpoints {
+		errs = append(errs, l.templateCheck(endpoint)...)
+	}
+	return errors.Join(errs...)
+}
+
+// resolveResponseRefs copies named [[responses]] bodies into the endpoints
+// that reference them, reporting every dangling or conflicting reference
+func (l *Loader) resolveResponseRefs() error {
+	bodies := make(map[string]string, len(l.config.Responses))
+	for _, named := range l.config.Responses {
+		bodies[named.Name] = named.Response
+	}
+
+	var errs []error
+	for i := range l.config.Endpoints {
+		endpoint := &l.config.Endpoints[i]
+		if endpoint.ResponseRef == "" {
+			continue
+		}
+		if endpoint.Response != "" {
+			errs = append(errs, fmt.Errorf("endpoint %s: response and response_ref cannot both be set", endpoint.RouteKey()))
+			continue
+		}
+		body, ok := bodies[endpoint.ResponseRef]
+		if !ok {
+			errs = append(errs, fmt.Errorf("endpoint %s: response_ref %q does not match any [[responses]] name", endpoint.RouteKey(), endpoint.ResponseRef))
+			continue
+		}
+		endpoint.Response = body
+	}
+	return errors.Join(errs...)
+}
+
+// convertResponseFormats rewrites the bodies of endpoints with
+// response_format = "json5" as standard JSON, reporting every syntax error
+func (l *Loader) convertResponseFormats() error {
+	var errs []error
+	for i := range l.config.Endpoints {
+		endpoint := &l.config.Endpoints[i]
+		if endpoint.ResponseFormat != "json5" || endpoint.RawResponse {
+			continue
+		}
+
+		convert := func(field string, body *string) {
+			if *body == "" {
+				return
+			}
+			converted, err := json5.ToJSON(*body)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("endpoint %s: %s: %w", endpoint.RouteKey(), field, err))
+				return
+			}
+			*body = converted
+		}
+
+		convert("response", &endpoint.Response)
+		for j := range endpoint.Responses {
+			convert(fmt.Sprintf("responses[%d]", j), &endpoint.Responses[j].Response)
+		}
+		for j := range endpoint.Localized {
+			convert(fmt.Sprintf("localized[%d]", j), &endpoint.Localized[j].Response)
+		}
+		methods := make([]string, 0, len(endpoint.MethodResponses))
+		for method := range endpoint.MethodResponses {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			body := endpoint.MethodResponses[method]
+			convert("method_responses."+method, &body)
+			endpoint.MethodResponses[method] = body
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // mergeConfig merges a loaded config into the main config
@@ -98,9 +393,162 @@ func (l *Loader) mergeConfig(cfg models.Config) {
 	if cfg.Server.WriteTimeout > 0 {
 		l.config.Server.WriteTimeout = cfg.Server.WriteTimeout
 	}
+	if cfg.Server.IdleTimeout > 0 {
+		l.config.Server.IdleTimeout = cfg.Server.IdleTimeout
+	}
+	if cfg.Server.ReadHeaderTimeout > 0 {
+		l.config.Server.ReadHeaderTimeout = cfg.Server.ReadHeaderTimeout
+	}
+	if cfg.Server.MaxBodyBytes > 0 {
+		l.config.Server.MaxBodyBytes = cfg.Server.MaxBodyBytes
+	}
+	if cfg.Server.TLSCertFile != "" {
+		l.config.Server.TLSCertFile = cfg.Server.TLSCertFile
+	}
+	if cfg.Server.TLSKeyFile != "" {
+		l.config.Server.TLSKeyFile = cfg.Server.TLSKeyFile
+	}
+	if cfg.Server.RequestID {
+		l.config.Server.RequestID = true
+	}
+	if cfg.Server.H2C {
+		l.config.Server.H2C = true
+	}
+	if cfg.Server.SocketPath != "" {
+		l.config.Server.SocketPath = cfg.Server.SocketPath
+	}
+	if len(cfg.Server.Listen) > 0 {
+		l.config.Server.Listen = cfg.Server.Listen
+	}
+	if cfg.Server.AutoPort {
+		l.config.Server.AutoPort = true
+	}
+	if cfg.Server.AutoPortAttempts > 0 {
+		l.config.Server.AutoPortAttempts = cfg.Server.AutoPortAttempts
+	}
+	if cfg.Server.AccessLog != nil {
+		l.config.Server.AccessLog = cfg.Server.AccessLog
+	}
+	if len(cfg.Server.RedactQueryParams) > 0 {
+		l.config.Server.RedactQueryParams = cfg.Server.RedactQueryParams
+	}
+	if cfg.Server.NotFoundResponse != "" {
+		l.config.Server.NotFoundResponse = cfg.Server.NotFoundResponse
+	}
+	if cfg.Server.MethodNotAllowedResponse != "" {
+		l.config.Server.MethodNotAllowedResponse = cfg.Server.MethodNotAllowedResponse
+	}
+	if cfg.Server.CaptureRequests {
+		l.config.Server.CaptureRequests = true
+	}
+	if cfg.Server.CaptureLimit > 0 {
+		l.config.Server.CaptureLimit = cfg.Server.CaptureLimit
+	}
+	if len(cfg.Server.TemplateEnv) > 0 {
+		l.config.Server.TemplateEnv = cfg.Server.TemplateEnv
+	}
+	if cfg.Server.DisableKeepAlive {
+		l.config.Server.DisableKeepAlive = true
+	}
+	if cfg.Server.MaxConnections > 0 {
+		l.config.Server.MaxConnections = cfg.Server.MaxConnections
+	}
+	if cfg.Server.RandomSeed != nil {
+		l.config.Server.RandomSeed = cfg.Server.RandomSeed
+	}
+	if cfg.Server.MethodOverride {
+		l.config.Server.MethodOverride = true
+	}
+	if len(cfg.Server.MethodOverrideMethods) > 0 {
+		l.config.Server.MethodOverrideMethods = cfg.Server.MethodOverrideMethods
+	}
+	if cfg.Server.StrictTemplates {
+		l.config.Server.StrictTemplates = true
+	}
+
+	// Merge default headers, later files winning per header
+	for key, value := range cfg.DefaultHeaders {
+		if l.config.DefaultHeaders == nil {
+			l.config.DefaultHeaders = make(map[string]string)
+		}
+		l.config.DefaultHeaders[key] = value
+	}
+
+	// Merge method aliases, later files winning per alias
+	for alias, method := range cfg.MethodAliases {
+		if l.config.MethodAliases == nil {
+			l.config.MethodAliases = make(map[string]string)
+		}
+		l.config.MethodAliases[alias] = method
+	}
+
+	// Merge fragments, later files winning per name
+	for name, fragment := range cfg.Fragments {
+		if l.config.Fragments == nil {
+			l.config.Fragments = make(map[string]string)
+		}
+		l.config.Fragments[name] = fragment
+	}
+
+	// A later error envelope replaces an earlier one as a whole
+	if cfg.Errors != nil {
+		l.config.Errors = cfg.Errors
+	}
+
+	// A later throttle replaces an earlier one as a whole
+	if cfg.Throttle != nil {
+		l.config.Throttle = cfg.Throttle
+	}
 
-	// Append endpoints
-	l.config.Endpoints = append(l.config.Endpoints, cfg.Endpoints...)
+	// Static directories accumulate; a later file serving the same prefix
+	// replaces the earlier directory
+	for _, static := range cfg.Static {
+		replaced := false
+		for i := range l.config.Static {
+			if strings.TrimSuffix(l.config.Static[i].Prefix, "/") == strings.TrimSuffix(static.Prefix, "/") {
+				l.config.Static[i] = static
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			l.config.Static = append(l.config.Static, static)
+		}
+	}
+
+	// A later health block replaces an earlier one as a whole
+	if cfg.Health != nil {
+		l.config.Health = cfg.Health
+	}
+
+	// A later default endpoint replaces an earlier one as a whole
+	if cfg.DefaultEndpoint != nil {
+		l.config.DefaultEndpoint = cfg.DefaultEndpoint
+	}
+
+	// A later CORS policy replaces an earlier one as a whole
+	if cfg.CORS != nil {
+		l.config.CORS = cfg.CORS
+	}
+
+	// Append endpoints, letting later files override earlier definitions of
+	// the same route in place
+	l.config.Endpoints = overrideEndpoints(l.config.Endpoints, cfg.Endpoints)
+
+	// Accumulate named responses, later files replacing a name in place
+	for _, named := range cfg.Responses {
+		replaced := false
+		for i := range l.config.Responses {
+			if l.config.Responses[i].Name == named.Name {
+				l.config.Responses[i] = named
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			l.config.Responses = append(l.config.Responses, named)
+		}
+	}
 
 	// Override GraphQL config if provided
 	if cfg.GraphQL != nil {
@@ -114,9 +562,32 @@ func (l *Loader) mergeConfig(cfg models.Config) {
 			if cfg.GraphQL.Path != "" {
 				l.config.GraphQL.Path = cfg.GraphQL.Path
 			}
+			if cfg.GraphQL.CaseInsensitivePath {
+				l.config.GraphQL.CaseInsensitivePath = true
+			}
+			if cfg.GraphQL.MaxDepth > 0 {
+				l.config.GraphQL.MaxDepth = cfg.GraphQL.MaxDepth
+			}
+			if cfg.GraphQL.MaxComplexity > 0 {
+				l.config.GraphQL.MaxComplexity = cfg.GraphQL.MaxComplexity
+			}
+			if cfg.GraphQL.LimitStatus > 0 {
+				l.config.GraphQL.LimitStatus = cfg.GraphQL.LimitStatus
+			}
+			if cfg.GraphQL.StrictStatus {
+				l.config.GraphQL.StrictStatus = true
+			}
+			if cfg.GraphQL.QueryTypeName != "" {
+				l.config.GraphQL.QueryTypeName = cfg.GraphQL.QueryTypeName
+			}
+			if cfg.GraphQL.MutationTypeName != "" {
+				l.config.GraphQL.MutationTypeName = cfg.GraphQL.MutationTypeName
+			}
 			l.config.GraphQL.Types = append(l.config.GraphQL.Types, cfg.GraphQL.Types...)
+			l.config.GraphQL.Scalars = append(l.config.GraphQL.Scalars, cfg.GraphQL.Scalars...)
 			l.config.GraphQL.Queries = append(l.config.GraphQL.Queries, cfg.GraphQL.Queries...)
 			l.config.GraphQL.Mutations = append(l.config.GraphQL.Mutations, cfg.GraphQL.Mutations...)
+			l.config.GraphQL.Persisted = append(l.config.GraphQL.Persisted, cfg.GraphQL.Persisted...)
 		}
 	}
 }
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"fmt"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/graphql"
+	"github.com/jimbo/blandmockapi/internal/router"
 )
 
 func TestNew(t *testing.T) {
@@ -113,6 +119,100 @@ response = '{"id": 2}'
 	}
 }
 
+func TestLoadDirectory_Nested(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"server.toml": `
+[server]
+port = 9000
+`,
+		"users/users.toml": `
+[[endpoints]]
+path = "/api/users"
+response = '{"source": "users"}'
+`,
+		"orders/v1/orders.toml": `
+[[endpoints]]
+path = "/api/orders"
+response = '{"source": "orders"}'
+`,
+		"orders/notes.txt": "not a config file",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	loader := New()
+	if err := loader.LoadDirectory(tmpDir); err != nil {
+		t.Fatalf("LoadDirectory failed: %v", err)
+	}
+
+	cfg := loader.GetConfig()
+
+	if cfg.Server.Port != 9000 {
+		t.Errorf("Expected port 9000, got %d", cfg.Server.Port)
+	}
+
+	if len(cfg.Endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints from subdirectories, got %d", len(cfg.Endpoints))
+	}
+
+	// Sorted path order: orders/v1/orders.toml before users/users.toml
+	if cfg.Endpoints[0].Path != "/api/orders" || cfg.Endpoints[1].Path != "/api/users" {
+		t.Errorf("Expected endpoints in sorted path order, got %s then %s", cfg.Endpoints[0].Path, cfg.Endpoints[1].Path)
+	}
+}
+
+func TestLoadDirectory_SortedOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create the files out of order; load order must follow the names
+	files := []struct {
+		name string
+		port int
+	}{
+		{"02-override.toml", 9002},
+		{"10-final.toml", 9010},
+		{"01-base.toml", 9001},
+	}
+
+	for _, f := range files {
+		content := fmt.Sprintf("[server]\nport = %d\n", f.port)
+		if err := os.WriteFile(filepath.Join(tmpDir, f.name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", f.name, err)
+		}
+	}
+
+	loader := New()
+	if err := loader.LoadDirectory(tmpDir); err != nil {
+		t.Fatalf("LoadDirectory failed: %v", err)
+	}
+
+	cfg := loader.GetConfig()
+	if cfg.Server.Port != 9010 {
+		t.Errorf("Expected port 9010 from last sorted file, got %d", cfg.Server.Port)
+	}
+
+	// The loaded files are recorded in load order
+	expected := []string{"01-base.toml", "02-override.toml", "10-final.toml"}
+	if len(cfg.Files) != len(expected) {
+		t.Fatalf("Expected %d loaded files, got %v", len(expected), cfg.Files)
+	}
+	for i, name := range expected {
+		if cfg.Files[i] != filepath.Join(tmpDir, name) {
+			t.Errorf("Expected file %d to be %s, got %s", i, name, cfg.Files[i])
+		}
+	}
+}
+
 func TestLoadFromPath_File(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "test.toml")
@@ -216,6 +316,266 @@ response = '{}'
 	}
 }
 
+func TestMergeConfig_OverridesEndpoint(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config1 := `
+[[endpoints]]
+path = "/api/users"
+method = "GET"
+status = 200
+response = '{"version": 1}'
+
+[[endpoints]]
+path = "/api/products"
+response = '{}'
+`
+
+	config2 := `
+[[endpoints]]
+path = "/api/users"
+method = "get"
+status = 200
+response = '{"version": 2}'
+`
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "01-base.toml"), []byte(config1), 0644); err != nil {
+		t.Fatalf("Failed to create config1: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "02-override.toml"), []byte(config2), 0644); err != nil {
+		t.Fatalf("Failed to create config2: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadDirectory(tmpDir); err != nil {
+		t.Fatalf("LoadDirectory failed: %v", err)
+	}
+
+	cfg := loader.GetConfig()
+
+	if len(cfg.Endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints after override, got %d", len(cfg.Endpoints))
+	}
+
+	if cfg.Endpoints[0].Response != `{"version": 2}` {
+		t.Errorf("Expected later file to override endpoint in place, got %s", cfg.Endpoints[0].Response)
+	}
+}
+
+func TestMergeConfig_OverridesMethodResponses(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config1 := `
+[[endpoints]]
+path = "/api/users"
+method_responses = { GET = '{"list": 1}', POST = '{"created": 1}' }
+
+[[endpoints]]
+path = "/api/orders"
+method = "DELETE"
+response = '{"deleted": 1}'
+`
+
+	config2 := `
+[[endpoints]]
+path = "/api/users"
+method = "POST"
+response = '{"created": 2}'
+
+[[endpoints]]
+path = "/api/orders"
+method_responses = { DELETE = '{"deleted": 2}', PUT = '{"updated": 2}' }
+`
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "01-base.toml"), []byte(config1), 0644); err != nil {
+		t.Fatalf("Failed to create config1: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "02-override.toml"), []byte(config2), 0644); err != nil {
+		t.Fatalf("Failed to create config2: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadDirectory(tmpDir); err != nil {
+		t.Fatalf("LoadDirectory failed: %v", err)
+	}
+
+	rt := router.New()
+	if err := rt.RegisterEndpoints(loader.GetConfig().Endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/api/users", `{"list": 1}`},
+		{"POST", "/api/users", `{"created": 2}`},
+		{"DELETE", "/api/orders", `{"deleted": 2}`},
+		{"PUT", "/api/orders", `{"updated": 2}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		rt.Handler().ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Body.String() != tt.expected {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.expected, w.Body.String())
+		}
+	}
+}
+
+func TestLoadFile_DuplicateMethodResponses(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "dupes.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/users"
+method_responses = { GET = '{}', POST = '{}' }
+
+[[endpoints]]
+path = "/api/users"
+method = "POST"
+response = '{}'
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	err := New().LoadFile(configPath)
+	if err == nil || !strings.Contains(err.Error(), "duplicate endpoint POST /api/users") {
+		t.Errorf("Expected duplicate POST endpoint error, got %v", err)
+	}
+}
+
+func TestLoadFile_DuplicateEndpoints(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "dupes.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/users"
+response = '{}'
+
+[[endpoints]]
+path = "/api/users"
+method = "GET"
+response = '{}'
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	err := loader.LoadFile(configPath)
+	if err == nil {
+		t.Fatal("Expected error for duplicate endpoints in one file, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "duplicate endpoint GET /api/users") {
+		t.Errorf("Expected duplicate endpoint error, got %v", err)
+	}
+}
+
+func TestLoadFile_DisabledDuplicateEndpoint(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "toggle.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/users"
+response = '{"version": 1}'
+
+[[endpoints]]
+path = "/api/users"
+response = '{"version": 2}'
+enabled = false
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFile(configPath); err != nil {
+		t.Fatalf("Expected disabled copy to be allowed, got %v", err)
+	}
+
+	cfg := loader.GetConfig()
+	if len(cfg.Endpoints) != 2 {
+		t.Fatalf("Expected both definitions to be kept, got %d", len(cfg.Endpoints))
+	}
+
+	rt := router.New()
+	if err := rt.RegisterEndpoints(cfg.Endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+	if w.Body.String() != `{"version": 1}` {
+		t.Errorf("Expected the enabled definition to be served, got %s", w.Body.String())
+	}
+}
+
+func TestMergeConfig_DefaultHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config1 := `
+[default_headers]
+X-API-Version = "1"
+X-Service = "mock"
+`
+
+	config2 := `
+[default_headers]
+X-API-Version = "2"
+`
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "01-base.toml"), []byte(config1), 0644); err != nil {
+		t.Fatalf("Failed to create config1: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "02-override.toml"), []byte(config2), 0644); err != nil {
+		t.Fatalf("Failed to create config2: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadDirectory(tmpDir); err != nil {
+		t.Fatalf("LoadDirectory failed: %v", err)
+	}
+
+	cfg := loader.GetConfig()
+
+	if cfg.DefaultHeaders["X-API-Version"] != "2" {
+		t.Errorf("Expected later file to override X-API-Version, got %q", cfg.DefaultHeaders["X-API-Version"])
+	}
+	if cfg.DefaultHeaders["X-Service"] != "mock" {
+		t.Errorf("Expected X-Service to be kept, got %q", cfg.DefaultHeaders["X-Service"])
+	}
+}
+
+func TestMergeConfig_RandomSeed(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A zero seed is a seed like any other
+	if err := os.WriteFile(filepath.Join(tmpDir, "01-base.toml"), []byte("[server]\nrandom_seed = 0\n"), 0644); err != nil {
+		t.Fatalf("Failed to create config1: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "02-override.toml"), []byte("[server]\nport = 9090\n"), 0644); err != nil {
+		t.Fatalf("Failed to create config2: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadDirectory(tmpDir); err != nil {
+		t.Fatalf("LoadDirectory failed: %v", err)
+	}
+
+	seed := loader.GetConfig().Server.RandomSeed
+	if seed == nil || *seed != 0 {
+		t.Errorf("Expected random_seed 0 to be kept, got %v", seed)
+	}
+}
+
 func TestLoadInvalidPath(t *testing.T) {
 	loader := New()
 	err := loader.LoadFromPath("/nonexistent/path/config.toml")
@@ -245,3 +605,402 @@ port = "not a number"
 		t.Error("Expected error for invalid TOML, got nil")
 	}
 }
+
+func TestLoadFromPath_ValidationErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "invalid.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/bad-status"
+status = 999
+response = '{}'
+
+[[endpoints]]
+path = "/bad-json"
+response = '{"unterminated": '
+
+[endpoints.headers]
+Content-Type = "application/json"
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	err := loader.LoadFromPath(configPath)
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "status 999") {
+		t.Errorf("Expected status error in %q", err.Error())
+	}
+	if !strings.Contains(err.Error(), "not valid JSON") {
+		t.Errorf("Expected JSON error in %q", err.Error())
+	}
+}
+
+func TestLoadFile_UnknownField(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "typo.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/test"
+method = "GET"
+respones = '{"typo": true}'
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	err := loader.LoadFile(configPath)
+	if err == nil {
+		t.Fatal("Expected error for unknown field, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "endpoints.respones") {
+		t.Errorf("Expected error to name the offending key, got %v", err)
+	}
+	if !strings.Contains(err.Error(), configPath) {
+		t.Errorf("Expected error to name the file, got %v", err)
+	}
+}
+
+func TestLoadFile_Static(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "public"), 0755); err != nil {
+		t.Fatalf("Failed to create static dir: %v", err)
+	}
+	configPath := filepath.Join(tmpDir, "static.toml")
+	configContent := `
+[[static]]
+prefix = "/assets/"
+dir = "public"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFile(configPath); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	// Relative directories resolve against the config file
+	static := loader.GetConfig().Static
+	if len(static) != 1 || static[0].Dir != filepath.Join(tmpDir, "public") {
+		t.Errorf("Expected dir resolved against the config file, got %v", static)
+	}
+
+	missingPath := filepath.Join(tmpDir, "missing.toml")
+	if err := os.WriteFile(missingPath, []byte("[[static]]\nprefix = \"/files/\"\ndir = \"nope\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+	err := New().LoadFile(missingPath)
+	if err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("Expected a missing directory error, got %v", err)
+	}
+}
+
+func TestLoadDirectory_GraphQLSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"01-queries.toml": `
+[graphql]
+enabled = true
+
+[[graphql.queries]]
+name = "user"
+return_type = "User"
+response = '{"id": 1}'
+`,
+		"02-types.toml": `
+[[graphql.types]]
+name = "User"
+[graphql.types.fields]
+id = "Int!"
+first-name = "String"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test config: %v", err)
+		}
+	}
+
+	loader := New()
+	if err := loader.LoadDirectory(tmpDir); err != nil {
+		t.Fatalf("LoadDirectory failed: %v", err)
+	}
+	cfg := loader.GetConfig()
+	if got := cfg.GraphQL.Queries[0].Source; got != filepath.Join(tmpDir, "01-queries.toml") {
+		t.Errorf("Expected query source 01-queries.toml, got %q", got)
+	}
+
+	// The schema error points at the file defining the bad field
+	_, err := graphql.New(cfg.GraphQL)
+	if err == nil || !strings.Contains(err.Error(), "defined in "+filepath.Join(tmpDir, "02-types.toml")) {
+		t.Errorf("Expected schema error naming 02-types.toml, got %v", err)
+	}
+}
+
+func TestLoadFromPath_ResponseRefs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	shared := `
+[[responses]]
+name = "catalog"
+response = '{"items": [1, 2, 3]}'
+`
+	endpoints := `
+[[endpoints]]
+path = "/api/catalog"
+method = "GET"
+response_ref = "catalog"
+
+[[endpoints]]
+path = "/api/v2/catalog"
+method = "GET"
+response_ref = "catalog"
+`
+	// The references load before the named response to show resolution
+	// happens after every file is merged
+	if err := os.WriteFile(filepath.Join(tmpDir, "01-endpoints.toml"), []byte(endpoints), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "02-shared.toml"), []byte(shared), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFromPath(tmpDir); err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+
+	rt := router.New()
+	if err := rt.RegisterEndpoints(loader.GetConfig().Endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	for _, path := range []string{"/api/catalog", "/api/v2/catalog"} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		rt.Handler().ServeHTTP(w, req)
+
+		if w.Body.String() != `{"items": [1, 2, 3]}` {
+			t.Errorf("Expected shared body from %s, got %s", path, w.Body.String())
+		}
+	}
+}
+
+func TestLoadFromPath_DanglingResponseRef(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "dangling.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/catalog"
+response_ref = "missing"
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	err := loader.LoadFromPath(configPath)
+	if err == nil {
+		t.Fatal("Expected error for dangling response_ref, got nil")
+	}
+	if !strings.Contains(err.Error(), `response_ref "missing"`) {
+		t.Errorf("Expected error to name the reference, got %v", err)
+	}
+}
+
+func TestLoadFile_GraphQLFieldForms(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "graphql.toml")
+	configContent := `
+[graphql]
+enabled = true
+
+[[graphql.types]]
+name = "User"
+
+[graphql.types.fields]
+id = "Int!"
+posts = { type = "[Post]", response = '[{"title": "Hello"}]' }
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFile(configPath); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	fields := loader.GetConfig().GraphQL.Types[0].Fields
+	if fields["id"].Type != "Int!" || fields["id"].Response != "" {
+		t.Errorf("Expected string form to set only the type, got %+v", fields["id"])
+	}
+	if fields["posts"].Type != "[Post]" || fields["posts"].Response != `[{"title": "Hello"}]` {
+		t.Errorf("Expected table form to set type and response, got %+v", fields["posts"])
+	}
+}
+
+func TestLoadFromPath_JSON5Response(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "json5.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/user"
+method = "GET"
+response_format = "json5"
+response = '''
+{
+  // Commented payloads are served as standard JSON
+  id: 1,
+  name: 'Ada',
+  tags: ["admin", "ops",], /* trailing commas are dropped */
+}
+'''
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFromPath(configPath); err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+
+	rt := router.New()
+	if err := rt.RegisterEndpoints(loader.GetConfig().Endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/user", nil)
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, req)
+
+	expected := `{"id":1,"name":"Ada","tags":["admin","ops"]}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestLoadFromPath_InvalidJSON5Response(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "json5.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/broken"
+response_format = "json5"
+response = '{id: 1,, }'
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	err := loader.LoadFromPath(configPath)
+	if err == nil {
+		t.Fatal("Expected error for invalid JSON5, got nil")
+	}
+	if !strings.Contains(err.Error(), "endpoint GET /api/broken: response: json5: line 1 column 8") {
+		t.Errorf("Expected error to locate the JSON5 problem, got %v", err)
+	}
+}
+
+func TestLoadFile_RetryAfterForms(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "retry.toml")
+
+	configContent := `
+[[endpoints]]
+path = "/api/limited"
+status = 429
+retry_after = 30
+
+[[endpoints]]
+path = "/api/maintenance"
+status = 503
+retry_after = "Wed, 21 Oct 2026 07:28:00 GMT"
+`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFromPath(configPath); err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+
+	endpoints := loader.GetConfig().Endpoints
+	if endpoints[0].RetryAfter != "30" {
+		t.Errorf("Expected integer retry_after 30, got %q", endpoints[0].RetryAfter)
+	}
+	if endpoints[1].RetryAfter != "Wed, 21 Oct 2026 07:28:00 GMT" {
+		t.Errorf("Expected HTTP-date retry_after, got %q", endpoints[1].RetryAfter)
+	}
+}
+
+func TestLoadFromPath_StrictTemplates(t *testing.T) {
+	configContent := `
+[server]
+strict_templates = %t
+
+[[endpoints]]
+path = "/api/greet"
+response = '''
+{
+  "name": "{{quer.name}}",
+  "path": "{{path}}",
+  "unquoted": {{query.id}}
+}
+'''
+`
+
+	for _, strict := range []bool{false, true} {
+		tmpDir := t.TempDir()
+		configPath := filepath.Join(tmpDir, "templates.toml")
+		if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configContent, strict)), 0644); err != nil {
+			t.Fatalf("Failed to create test config: %v", err)
+		}
+
+		loader := New()
+		loader.SetTemplateCheck(router.UndefinedTokens)
+		err := loader.LoadFromPath(configPath)
+		if !strict {
+			// Without strict mode the token is only a startup warning
+			if err != nil {
+				t.Errorf("Expected the config to load without strict_templates, got %v", err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatal("Expected an undefined token error under strict_templates, got nil")
+		}
+		if !strings.Contains(err.Error(), "undefined template token {{quer.name}}") {
+			t.Errorf("Expected error to name the token, got %v", err)
+		}
+		// Rendering problems other than unknown tokens stay warnings
+		if strings.Contains(err.Error(), "invalid JSON") || strings.Contains(err.Error(), "{{query.id}}") {
+			t.Errorf("Expected only undefined tokens to fail the load, got %v", err)
+		}
+
+		// Strict mode cannot be enforced without a template check
+		if err := New().LoadFromPath(configPath); err == nil || !strings.Contains(err.Error(), "no template check") {
+			t.Errorf("Expected a missing template check error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISpec holds the parts of an OpenAPI 3 document used to generate
+// endpoints
+type openAPISpec struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// openAPIOperation is a single method on a path
+type openAPIOperation struct {
+	Summary   string                     `json:"summary"`
+	Responses map[string]openAPIResponse `json:"responses"`
+}
+
+// openAPIResponse is one documented response of an operation
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content"`
+}
+
+// openAPIMediaType holds the example and schema for one content type
+type openAPIMediaType struct {
+	Example  json.RawMessage `json:"example"`
+	Examples map[string]struct {
+		Value json.RawMessage `json:"value"`
+	} `json:"examples"`
+	Schema *openAPISchema `json:"schema"`
+}
+
+// openAPISchema is the subset of JSON Schema used to synthesize examples
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Format     string                    `json:"format"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Items      *openAPISchema            `json:"items"`
+	Example    json.RawMessage           `json:"example"`
+	Default    json.RawMessage           `json:"default"`
+	Enum       []json.RawMessage         `json:"enum"`
+}
+
+// openAPIMethods maps OpenAPI operation keys to HTTP methods
+var openAPIMethods = map[string]string{
+	"get":     http.MethodGet,
+	"put":     http.MethodPut,
+	"post":    http.MethodPost,
+	"delete":  http.MethodDelete,
+	"options": http.MethodOptions,
+	"head":    http.MethodHead,
+	"patch":   http.MethodPatch,
+	"trace":   http.MethodTrace,
+}
+
+// maxSchemaDepth stops example generation for deeply nested or recursive
+// schemas
+const maxSchemaDepth = 8
+
+// openAPIStatusParam is the query parameter selecting one of an operation's
+// other documented statuses, e.g. GET /api/users?__code=404
+const openAPIStatusParam = "__code"
+
+// LoadOpenAPI generates endpoints from an OpenAPI 3 document in JSON or YAML.
+// Each path and method serves the operation's lowest 2xx response (or the
+// lowest documented status when there is none) by default, and every other
+// documented status when the request carries ?__code=STATUS. Bodies are the
+// response's example or an example synthesized from its schema. Path
+// templates such as /users/{id} are served as the prefix /users/; two
+// operations collapsing onto the same route are an error.
+func LoadOpenAPI(path string) ([]models.EndpointConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI spec %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("failed to parse OpenAPI spec %s: %w", path, err)
+		}
+	}
+
+	var spec openAPISpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec %s: %w", path, err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("OpenAPI spec %s: unsupported version %q, expected 3.x", path, spec.OpenAPI)
+	}
+
+	var endpoints []models.EndpointConfig
+	seen := make(map[string]string)
+	for _, specPath := range sortedKeys(spec.Paths) {
+		item := spec.Paths[specPath]
+		routePath := openAPIRoutePath(specPath)
+
+		for _, key := range sortedKeys(item) {
+			method, ok := openAPIMethods[key]
+			if !ok {
+				continue
+			}
+
+			var op openAPIOperation
+			if err := json.Unmarshal(item[key], &op); err != nil {
+				return nil, fmt.Errorf("OpenAPI spec %s: invalid operation %s %s: %w", path, method, specPath, err)
+			}
+
+			// Templated paths that collapse onto the same prefix would serve
+			// one operation's body for the other's requests
+			routeKey := method + " " + routePath
+			if previous, exists := seen[routeKey]; exists {
+				return nil, fmt.Errorf("OpenAPI spec %s: %s %s and %s %s are both served at %s; routes don't support path parameters, so override one of them with an endpoint in the config file", path, method, previous, method, specPath, routePath)
+			}
+			seen[routeKey] = specPath
+
+			for _, endpoint := range spec.endpoints(op) {
+				endpoint.Path = routePath
+				endpoint.Method = method
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+
+	return endpoints, nil
+}
+
+// yamlToJSON converts a YAML document to JSON. YAML allows non-string
+// mapping keys, such as unquoted status codes, which become strings.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(stringKeys(doc))
+}
+
+// stringKeys converts every mapping in a decoded YAML value to one keyed by
+// strings, as JSON requires
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = stringKeys(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	}
+	return value
+}
+
+// endpoints builds one endpoint per documented status of an operation. The
+// primary response comes first and answers requests without ?__code; the
+// others are bound to their status code.
+func (spec *openAPISpec) endpoints(op openAPIOperation) []models.EndpointConfig {
+	status, response, ok := primaryResponse(op.Responses)
+	if !ok {
+		return []models.EndpointConfig{{Description: op.Summary}}
+	}
+	endpoints := []models.EndpointConfig{spec.endpoint(op, status, response)}
+
+	for _, code := range sortedKeys(op.Responses) {
+		other, err := strconv.Atoi(code)
+		if err != nil || other < 100 || other > 599 || other == status {
+			continue
+		}
+		endpoint := spec.endpoint(op, other, op.Responses[code])
+		endpoint.MatchQuery = map[string]string{openAPIStatusParam: code}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// endpoint builds the headers and response for one status of an operation
+func (spec *openAPISpec) endpoint(op openAPIOperation, status int, response openAPIResponse) models.EndpointConfig {
+	endpoint := models.EndpointConfig{Description: op.Summary, Status: status}
+	if endpoint.Description == "" {
+		endpoint.Description = response.Description
+	}
+
+	contentType, media, ok := preferredMedia(response.Content)
+	if !ok {
+		return endpoint
+	}
+	endpoint.Headers = map[string]string{"Content-Type": contentType}
+
+	example := exampleFor(media)
+	if example == nil && media.Schema != nil {
+		example = spec.synthesize(media.Schema, 0)
+	}
+	if example != nil {
+		if encoded, err := json.Marshal(example); err == nil {
+			endpoint.Response = string(encoded)
+		}
+	}
+	return endpoint
+}
+
+// primaryResponse picks the lowest 2xx response, falling back to the lowest
+// numeric status and then to "default" (served as 200)
+func primaryResponse(responses map[string]openAPIResponse) (int, openAPIResponse, bool) {
+	best := 0
+	for code := range responses {
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 100 || status > 599 {
+			continue
+		}
+		isSuccess := status >= 200 && status < 300
+		bestIsSuccess := best >= 200 && best < 300
+		if best == 0 || (isSuccess && !bestIsSuccess) || (isSuccess == bestIsSuccess && status < best) {
+			best = status
+		}
+	}
+	if best != 0 {
+		return best, responses[strconv.Itoa(best)], true
+	}
+	if response, ok := responses["default"]; ok {
+		return http.StatusOK, response, true
+	}
+	return 0, openAPIResponse{}, false
+}
+
+// preferredMedia picks the JSON content type when present, otherwise the
+// first content type in sorted order
+func preferredMedia(content map[string]openAPIMediaType) (string, openAPIMediaType, bool) {
+	if len(content) == 0 {
+		return "", openAPIMediaType{}, false
+	}
+	types := sortedKeys(content)
+	for _, contentType := range types {
+		if strings.Contains(contentType, "json") {
+			return contentType, content[contentType], true
+		}
+	}
+	return types[0], content[types[0]], true
+}
+
+// exampleFor returns the media type's example, or its first named example
+func exampleFor(media openAPIMediaType) interface{} {
+	if value := decodeRaw(media.Example); value != nil {
+		return value
+	}
+	for _, name := range sortedKeys(media.Examples) {
+		if value := decodeRaw(media.Examples[name].Value); value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// synthesize builds an example value from a schema, preferring the schema's
+// own example, default or first enum value
+func (spec *openAPISpec) synthesize(schema *openAPISchema, depth int) interface{} {
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		return spec.synthesize(spec.Components.Schemas[name], depth+1)
+	}
+	if value := decodeRaw(schema.Example); value != nil {
+		return value
+	}
+	if value := decodeRaw(schema.Default); value != nil {
+		return value
+	}
+	if len(schema.Enum) > 0 {
+		return decodeRaw(schema.Enum[0])
+	}
+
+	switch schema.Type {
+	case "object", "":
+		if schema.Properties == nil {
+			if schema.Type == "" {
+				return nil
+			}
+			return map[string]interface{}{}
+		}
+		obj := make(map[string]interface{}, len(schema.Properties))
+		for name, property := range schema.Properties {
+			obj[name] = spec.synthesize(property, depth+1)
+		}
+		return obj
+	case "array":
+		item := spec.synthesize(schema.Items, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		}
+		return "string"
+	case "integer":
+		return 0
+	case "number":
+		return 0.0
+	case "boolean":
+		return false
+	}
+	return nil
+}
+
+// decodeRaw decodes a raw JSON value, returning nil when it is absent
+func decodeRaw(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// openAPIRoutePath converts an OpenAPI path template to a router path.
+// Everything from the first templated segment on is replaced by a prefix
+// match, so /users/{id}/orders becomes /users/.
+func openAPIRoutePath(path string) string {
+	index := strings.Index(path, "{")
+	if index < 0 {
+		return path
+	}
+	prefix := path[:index]
+	return prefix[:strings.LastIndex(prefix, "/")+1]
+}
+
+// sortedKeys returns map keys in sorted order for deterministic output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+const minimalSpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Users", "version": "1.0.0"},
+  "paths": {
+    "/api/users": {
+      "get": {
+        "summary": "List users",
+        "responses": {
+          "500": {"description": "Server error"},
+          "200": {
+            "description": "OK",
+            "content": {
+              "application/json": {
+                "example": {"users": [{"id": 1, "name": "Alice"}]}
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestLoadFile_OpenAPI(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "spec.json"), []byte(minimalSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	configPath := filepath.Join(tmpDir, "api.toml")
+	if err := os.WriteFile(configPath, []byte(`openapi = "spec.json"`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFromPath(configPath); err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+
+	cfg := loader.GetConfig()
+	// One endpoint per documented status: 200 by default, 500 on ?__code=500
+	if len(cfg.Endpoints) != 2 {
+		t.Fatalf("Expected 2 generated endpoints, got %d", len(cfg.Endpoints))
+	}
+
+	rt := router.New()
+	if err := rt.RegisterEndpoints(cfg.Endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	expected := `{"users":[{"id":1,"name":"Alice"}]}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestLoadOpenAPI_SchemaAndTemplatedPath(t *testing.T) {
+	spec := `{
+  "openapi": "3.1.0",
+  "paths": {
+    "/api/users/{id}": {
+      "get": {
+        "responses": {
+          "200": {
+            "description": "A user",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}
+          }
+        }
+      },
+      "delete": {"responses": {"204": {"description": "Deleted"}}}
+    }
+  },
+  "components": {
+    "schemas": {
+      "User": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer", "example": 7},
+          "tags": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }
+  }
+}`
+	specPath := filepath.Join(t.TempDir(), "spec.json")
+	if err := os.WriteFile(specPath, []byte(spec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	endpoints, err := LoadOpenAPI(specPath)
+	if err != nil {
+		t.Fatalf("LoadOpenAPI failed: %v", err)
+	}
+	if len(endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(endpoints))
+	}
+
+	// Operations are generated in sorted method order
+	deleteEndpoint, getEndpoint := endpoints[0], endpoints[1]
+	if getEndpoint.Path != "/api/users/" {
+		t.Errorf("Expected templated path to become prefix /api/users/, got %s", getEndpoint.Path)
+	}
+	if getEndpoint.Response != `{"id":7,"tags":["string"]}` {
+		t.Errorf("Expected synthesized response, got %s", getEndpoint.Response)
+	}
+	if deleteEndpoint.Method != "DELETE" || deleteEndpoint.Status != 204 || deleteEndpoint.Response != "" {
+		t.Errorf("Expected empty DELETE 204, got %s %d %q", deleteEndpoint.Method, deleteEndpoint.Status, deleteEndpoint.Response)
+	}
+}
+
+func TestLoadOpenAPI_YAMLWithEveryStatus(t *testing.T) {
+	spec := `openapi: 3.0.3
+paths:
+  /api/users:
+    get:
+      responses:
+        200:
+          description: OK
+          content:
+            application/json:
+              example: {users: [{id: 1}]}
+        404:
+          description: Not found
+          content:
+            application/json:
+              example: {error: missing}
+`
+	specPath := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(specPath, []byte(spec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	endpoints, err := LoadOpenAPI(specPath)
+	if err != nil {
+		t.Fatalf("LoadOpenAPI failed: %v", err)
+	}
+	rt := router.New()
+	if err := rt.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	tests := []struct {
+		url      string
+		status   int
+		expected string
+	}{
+		{"/api/users", 200, `{"users":[{"id":1}]}`},
+		{"/api/users?__code=404", 404, `{"error":"missing"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		rt.Handler().ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.status || w.Body.String() != tt.expected {
+			t.Errorf("GET %s: expected %d %s, got %d %s", tt.url, tt.status, tt.expected, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestLoadOpenAPI_RejectsCollapsedPaths(t *testing.T) {
+	spec := `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/users/{id}": {"get": {"responses": {"200": {"description": "A user"}}}},
+    "/users/{id}/posts": {"get": {"responses": {"200": {"description": "Posts"}}}}
+  }
+}`
+	specPath := filepath.Join(t.TempDir(), "spec.json")
+	if err := os.WriteFile(specPath, []byte(spec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	_, err := LoadOpenAPI(specPath)
+	if err == nil || !strings.Contains(err.Error(), "/users/{id}/posts") {
+		t.Errorf("Expected error naming the colliding path, got %v", err)
+	}
+}
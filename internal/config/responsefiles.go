@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// loadResponseFiles reads the response_files of every endpoint, relative to
+// dir, into equally weighted response variants so each request is answered
+// by one file picked at random. Reading them at load time lets the bodies be
+// validated and checked like any other response.
+func loadResponseFiles(endpoints []models.EndpointConfig, dir string) error {
+	var errs []error
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		for j, file := range endpoint.ResponseFiles {
+			if !filepath.IsAbs(file) {
+				endpoint.ResponseFiles[j] = filepath.Join(dir, file)
+			}
+		}
+		if err := endpoint.LoadResponseFiles(); err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", endpoint.RouteKey(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+func TestLoadFile_ResponseFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "fixtures"), 0755); err != nil {
+		t.Fatalf("Failed to create fixtures dir: %v", err)
+	}
+	pool := map[string]bool{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		body := `{"user": "` + name + `"}`
+		pool[body] = true
+		if err := os.WriteFile(filepath.Join(tmpDir, "fixtures", name+".json"), []byte(body), 0644); err != nil {
+			t.Fatalf("Failed to write fixture: %v", err)
+		}
+	}
+	configPath := filepath.Join(tmpDir, "users.toml")
+	configContent := `
+[server]
+random_seed = 7
+
+[[endpoints]]
+path = "/api/user"
+method = "GET"
+response_files = ["fixtures/alice.json", "fixtures/bob.json", "fixtures/carol.json"]
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	loader := New()
+	if err := loader.LoadFromPath(configPath); err != nil {
+		t.Fatalf("LoadFromPath failed: %v", err)
+	}
+	cfg := loader.GetConfig()
+
+	rt := router.New()
+	rt.SetRandomSeed(cfg.Server.RandomSeed)
+	if err := rt.RegisterEndpoints(cfg.Endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+	handler := rt.Handler()
+
+	seen := map[string]bool{}
+	for i := 0; i < 60; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/user", nil))
+		if !pool[w.Body.String()] {
+			t.Fatalf("Expected a response from the pool, got %s", w.Body.String())
+		}
+		seen[w.Body.String()] = true
+	}
+	if len(seen) != len(pool) {
+		t.Errorf("Expected every file to be served, saw %v", seen)
+	}
+}
+
+func TestLoadFile_InvalidResponseFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"missing file", `
+[[endpoints]]
+path = "/api/user"
+response_files = ["nope.json"]
+`, "nope.json"},
+		{"combined with response", `
+[[endpoints]]
+path = "/api/user"
+response = '{}'
+response_files = ["nope.json"]
+`, "response_files cannot be combined with response"},
+		{"combined with raw_response", `
+[[endpoints]]
+path = "/api/user"
+raw_response = true
+response_files = ["nope.json"]
+`, "response_files cannot be combined"},
+		{"combined with localized", `
+[[endpoints]]
+path = "/api/user"
+response_files = ["nope.json"]
+
+[[endpoints.localized]]
+lang = "en"
+response = '{}'
+`, "response_files cannot be combined"},
+		{"combined with response_format", `
+[[endpoints]]
+path = "/api/user"
+response_format = "json5"
+response_files = ["nope.json"]
+`, "response_files cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test config: %v", err)
+			}
+			err := New().LoadFile(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
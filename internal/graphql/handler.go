@@ -1,19 +1,27 @@
 package graphql
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
 	"github.com/jimbo/blandmockapi/internal/models"
 )
 
 // Handler manages GraphQL requests based on TOML configuration
 type Handler struct {
-	schema graphql.Schema
-	config *models.GraphQLConfig
+	schema  graphql.Schema
+	config  *models.GraphQLConfig
+	scalars map[string]*graphql.Scalar
+	// Persisted query hash -> query text
+	persisted map[string]string
 }
 
 // New creates a new GraphQL handler from configuration
@@ -23,36 +31,52 @@ func New(config *models.GraphQLConfig) (*Handler, error) {
 	}
 
 	h := &Handler{
-		config: config,
+		config:    config,
+		persisted: newPersistedQueries(config.Persisted),
 	}
 
 	// Build the GraphQL schema from configuration
 	schema, err := h.buildSchema()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build GraphQL schema: %w", err)
+		return nil, fmt.Errorf("failed to build GraphQL schema: %w", withSchemaErrorSources(config, err))
 	}
 
 	h.schema = schema
+	if err := h.checkPersistedQueries(); err != nil {
+		return nil, err
+	}
 	return h, nil
 }
 
 // buildSchema constructs a GraphQL schema from TOML configuration
 func (h *Handler) buildSchema() (graphql.Schema, error) {
-	// Create custom types
+	// Create custom scalars first so type fields can reference them
+	h.scalars = make(map[string]*graphql.Scalar, len(h.config.Scalars))
+	for _, scalarDef := range h.config.Scalars {
+		h.scalars[scalarDef.Name] = newPassThroughScalar(scalarDef)
+	}
+
+	// Create custom types. Fields are built lazily so they can reference
+	// types declared later, and fields with their own response get a resolver.
 	types := make(map[string]*graphql.Object)
 	for _, typeDef := range h.config.Types {
-		fields := graphql.Fields{}
-		for fieldName, fieldType := range typeDef.Fields {
-			fields[fieldName] = &graphql.Field{
-				Type:        h.parseType(fieldType),
-				Description: fmt.Sprintf("Field %s of type %s", fieldName, fieldType),
-			}
-		}
-
 		types[typeDef.Name] = graphql.NewObject(graphql.ObjectConfig{
 			Name:        typeDef.Name,
 			Description: typeDef.Description,
-			Fields:      fields,
+			Fields: graphql.FieldsThunk(func() graphql.Fields {
+				fields := graphql.Fields{}
+				for fieldName, fieldDef := range typeDef.Fields {
+					field := &graphql.Field{
+						Type:        h.resolveType(fieldDef.Type, types),
+						Description: fmt.Sprintf("Field %s of type %s", fieldName, fieldDef.Type),
+					}
+					if fieldDef.Response != "" {
+						field.Resolve = h.createResolver(fieldDef.Response, nil)
+					}
+					fields[fieldName] = field
+				}
+				return fields
+			}),
 		})
 	}
 
@@ -76,12 +100,12 @@ func (h *Handler) buildSchema() (graphql.Schema, error) {
 			Type:        returnType,
 			Description: query.Description,
 			Args:        args,
-			Resolve:     h.createResolver(query.Response),
+			Resolve:     h.createResolver(query.Response, query.Cases),
 		}
 	}
 
 	rootQuery := graphql.NewObject(graphql.ObjectConfig{
-		Name:   "RootQuery",
+		Name:   h.config.GetQueryTypeName(),
 		Fields: queryFields,
 	})
 
@@ -107,12 +131,12 @@ func (h *Handler) buildSchema() (graphql.Schema, error) {
 				Type:        returnType,
 				Description: mutation.Description,
 				Args:        args,
-				Resolve:     h.createResolver(mutation.Response),
+				Resolve:     h.createResolver(mutation.Response, nil),
 			}
 		}
 
 		rootMutation = graphql.NewObject(graphql.ObjectConfig{
-			Name:   "RootMutation",
+			Name:   h.config.GetMutationTypeName(),
 			Fields: mutationFields,
 		})
 	}
@@ -159,8 +183,12 @@ func (h *Handler) parseType(typeStr string) graphql.Output {
 	case "ID":
 		baseType = graphql.ID
 	default:
-		// Assume it's a custom type (will be resolved later)
-		baseType = graphql.String
+		if scalar, ok := h.scalars[typeStr]; ok {
+			baseType = scalar
+		} else {
+			// Assume it's a custom type (will be resolved later)
+			baseType = graphql.String
+		}
 	}
 
 	if isList {
@@ -176,6 +204,13 @@ func (h *Handler) parseType(typeStr string) graphql.Output {
 
 // resolveType resolves a type name to a GraphQL type (including custom types)
 func (h *Handler) resolveType(typeName string, types map[string]*graphql.Object) graphql.Output {
+	// Check for non-null custom types such as "User!" or "[User]!"
+	if len(typeName) > 1 && typeName[len(typeName)-1] == '!' {
+		if innerType := h.resolveType(typeName[:len(typeName)-1], types); innerType != nil {
+			return graphql.NewNonNull(innerType)
+		}
+	}
+
 	// Check for custom types first
 	if customType, ok := types[typeName]; ok {
 		return customType
@@ -193,48 +228,171 @@ func (h *Handler) resolveType(typeName string, types map[string]*graphql.Object)
 	return h.parseType(typeName)
 }
 
-// createResolver creates a resolver function that returns the configured response
-func (h *Handler) createResolver(responseJSON string) graphql.FieldResolveFn {
+// createResolver creates a resolver function that returns the configured
+// response, or the response of the first case whose arguments all match
+func (h *Handler) createResolver(responseJSON string, cases []models.GraphQLCase) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (interface{}, error) {
+		response := responseJSON
+		for _, c := range cases {
+			if argsMatch(c.Args, p.Args) {
+				response = c.Response
+				break
+			}
+		}
+
 		// Parse the JSON response
 		var result interface{}
-		if err := json.Unmarshal([]byte(responseJSON), &result); err != nil {
+		if err := json.Unmarshal([]byte(response), &result); err != nil {
 			return nil, fmt.Errorf("invalid response JSON: %w", err)
 		}
 		return result, nil
 	}
 }
 
+// argsMatch reports whether every expected argument was passed with the same
+// value. Values are compared as text, so the TOML integer 1 matches an ID
+// argument of "1".
+func argsMatch(expected, args map[string]interface{}) bool {
+	for name, want := range expected {
+		got, ok := args[name]
+		if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestParams holds the standard GraphQL request parameters
+type requestParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    *requestExtensions     `json:"extensions"`
+}
+
 // ServeHTTP handles GraphQL HTTP requests
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Only accept POST requests
-	if r.Method != http.MethodPost {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		if err := json.NewEncoder(w).Encode(map[string]string{
-			"error": "GraphQL endpoint only accepts POST requests",
-		}); err != nil {
-			log.Printf("Failed to encode error response: %v", err)
+	var params requestParams
+
+	switch r.Method {
+	case http.MethodPost:
+		// Parse the request body, which is either one operation or a batch
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+			h.serveBatch(w, trimmed)
+			return
 		}
+		if err := json.Unmarshal(body, &params); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		if result := h.resolvePersisted(&params); result != nil {
+			writeResult(w, http.StatusOK, result)
+			return
+		}
+	case http.MethodGet:
+		// Parse the query string
+		query := r.URL.Query()
+		params.Query = query.Get("query")
+		params.OperationName = query.Get("operationName")
+		if extensions := query.Get("extensions"); extensions != "" {
+			if err := json.Unmarshal([]byte(extensions), &params.Extensions); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid extensions parameter: %v", err))
+				return
+			}
+			if result := h.resolvePersisted(&params); result != nil {
+				writeResult(w, http.StatusOK, result)
+				return
+			}
+		}
+		if params.Query == "" {
+			writeError(w, http.StatusBadRequest, "missing query parameter")
+			return
+		}
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &params.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid variables parameter: %v", err))
+				return
+			}
+		}
+
+		// Mutations change state and must not be sent over GET
+		if operationType(params.Query, params.OperationName) == "mutation" {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "mutations are only accepted over POST")
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "GraphQL endpoint only accepts GET and POST requests")
+		return
+	}
+
+	// Return the result
+	result, status := h.execute(params)
+	writeResult(w, status, result)
+}
+
+// serveBatch executes an array of operations in order and returns an array
+// of their results. Over-limit operations get an error result in their slot;
+// the others still run.
+func (h *Handler) serveBatch(w http.ResponseWriter, body []byte) {
+	var batch []requestParams
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if len(batch) == 0 {
+		writeError(w, http.StatusBadRequest, "empty batch")
 		return
 	}
 
-	// Parse the request body
-	var params struct {
-		Query         string                 `json:"query"`
-		OperationName string                 `json:"operationName"`
-		Variables     map[string]interface{} `json:"variables"`
+	results := make([]*graphql.Result, len(batch))
+	for i, params := range batch {
+		if results[i] = h.resolvePersisted(&params); results[i] == nil {
+			results[i], _ = h.execute(params)
+		}
 	}
+	writeResult(w, http.StatusOK, results)
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		if encErr := json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("invalid request body: %v", err),
-		}); encErr != nil {
-			log.Printf("Failed to encode error response: %v", encErr)
+// execute runs a single operation and returns its result with the HTTP
+// status to send. Queries over the configured limits are not executed.
+func (h *Handler) execute(params requestParams) (*graphql.Result, int) {
+	// Reject oversized queries before executing them
+	if err := h.checkQueryLimits(params.Query); err != nil {
+		log.Printf("GraphQL query rejected: %v", err)
+		status := http.StatusOK
+		if h.config.LimitStatus == http.StatusBadRequest {
+			status = http.StatusBadRequest
+		}
+		return &graphql.Result{
+			Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())},
+		}, status
+	}
+
+	// With strict_status, syntax and validation errors are request errors;
+	// errors raised while resolving fields still return 200
+	if h.config.StrictStatus {
+		if errs := h.validate(params.Query); len(errs) > 0 {
+			log.Printf("GraphQL errors: %v", errs)
+			return &graphql.Result{Errors: errs}, http.StatusBadRequest
 		}
-		return
+	}
+
+	// Report mistyped variables clearly instead of coercing or rejecting
+	// them with graphql-go's less specific messages
+	if errs := checkVariables(params.Query, params.OperationName, params.Variables); len(errs) > 0 {
+		log.Printf("GraphQL errors: %v", errs)
+		status := http.StatusOK
+		if h.config.StrictStatus {
+			status = http.StatusBadRequest
+		}
+		return &graphql.Result{Errors: errs}, status
 	}
 
 	// Execute the GraphQL query
@@ -249,10 +407,55 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if len(result.Errors) > 0 {
 		log.Printf("GraphQL errors: %v", result.Errors)
 	}
+	return result, http.StatusOK
+}
+
+// validate parses a query and validates it against the schema, returning
+// the errors graphql.Do would report before executing anything
+func (h *Handler) validate(query string) []gqlerrors.FormattedError {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return gqlerrors.FormatErrors(err)
+	}
+	return graphql.ValidateDocument(&h.schema, doc, nil).Errors
+}
 
-	// Return the result
+// writeResult encodes a result (or batch of results) as JSON
+func writeResult(w http.ResponseWriter, status int, result interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		log.Printf("Failed to encode GraphQL response: %v", err)
 	}
 }
+
+// writeError writes a plain JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"error": message,
+	}); err != nil {
+		log.Printf("Failed to encode error response: %v", err)
+	}
+}
+
+// operationType returns the type ("query", "mutation" or "subscription") of
+// the operation a request would execute, or "" if the query does not parse
+func operationType(query, operationName string) string {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return ""
+	}
+
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+			return operation.Operation
+		}
+	}
+	return ""
+}
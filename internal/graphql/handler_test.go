@@ -3,7 +3,10 @@ package graphql
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/graphql-go/graphql"
@@ -17,9 +20,9 @@ func TestNew(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id":   "Int!",
-					"name": "String!",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
 				},
 			},
 		},
@@ -103,9 +106,9 @@ func TestBuildSchema_SimpleType(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id":   "Int!",
-					"name": "String!",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
 				},
 			},
 		},
@@ -136,9 +139,9 @@ func TestBuildSchema_WithMutations(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id":   "Int!",
-					"name": "String!",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
 				},
 			},
 		},
@@ -173,6 +176,35 @@ func TestBuildSchema_WithMutations(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_RootTypeNames(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled:          true,
+		QueryTypeName:    "Query",
+		MutationTypeName: "Mutation",
+		Queries:          []models.GraphQLQuery{{Name: "ping", ReturnType: "String", Response: `"pong"`}},
+		Mutations:        []models.GraphQLMutation{{Name: "reset", ReturnType: "Boolean", Response: `true`}},
+	}
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	_, result := postQuery(t, handler, "{ __schema { queryType { name } mutationType { name } } }")
+	schema := result["data"].(map[string]interface{})["__schema"].(map[string]interface{})
+	if name := schema["queryType"].(map[string]interface{})["name"]; name != "Query" {
+		t.Errorf("Expected query type Query, got %v", name)
+	}
+	if name := schema["mutationType"].(map[string]interface{})["name"]; name != "Mutation" {
+		t.Errorf("Expected mutation type Mutation, got %v", name)
+	}
+
+	// Operations still resolve through the renamed roots
+	_, result = postQuery(t, handler, "{ ping }")
+	if ping := result["data"].(map[string]interface{})["ping"]; ping != "pong" {
+		t.Errorf("Expected pong, got %v", ping)
+	}
+}
+
 func TestServeHTTP_ValidQuery(t *testing.T) {
 	config := &models.GraphQLConfig{
 		Enabled: true,
@@ -180,9 +212,9 @@ func TestServeHTTP_ValidQuery(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id":   "Int!",
-					"name": "String!",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
 				},
 			},
 		},
@@ -231,8 +263,8 @@ func TestServeHTTP_MethodNotAllowed(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id": "Int!",
+				Fields: map[string]models.GraphQLField{
+					"id": {Type: "Int!"},
 				},
 			},
 		},
@@ -247,7 +279,7 @@ func TestServeHTTP_MethodNotAllowed(t *testing.T) {
 
 	handler, _ := New(config)
 
-	req := httptest.NewRequest("GET", "/graphql", nil)
+	req := httptest.NewRequest("PUT", "/graphql", nil)
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -263,8 +295,8 @@ func TestServeHTTP_InvalidJSON(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id": "Int!",
+				Fields: map[string]models.GraphQLField{
+					"id": {Type: "Int!"},
 				},
 			},
 		},
@@ -296,9 +328,9 @@ func TestServeHTTP_WithVariables(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id":   "Int!",
-					"name": "String!",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
 				},
 			},
 		},
@@ -379,7 +411,7 @@ func TestCreateResolver(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resolver := handler.createResolver(tt.responseJSON)
+			resolver := handler.createResolver(tt.responseJSON, nil)
 			result, err := resolver(graphql.ResolveParams{})
 
 			if tt.wantErr {
@@ -430,17 +462,17 @@ func TestMultipleTypes(t *testing.T) {
 		Types: []models.GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id":   "Int!",
-					"name": "String!",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
 				},
 			},
 			{
 				Name: "Post",
-				Fields: map[string]string{
-					"id":      "Int!",
-					"title":   "String!",
-					"content": "String",
+				Fields: map[string]models.GraphQLField{
+					"id":      {Type: "Int!"},
+					"title":   {Type: "String!"},
+					"content": {Type: "String"},
 				},
 			},
 		},
@@ -489,3 +521,423 @@ func TestMultipleTypes(t *testing.T) {
 		t.Errorf("Posts query failed with status %d", w.Code)
 	}
 }
+
+// newLimitedHandler builds a handler with a small schema and the given limits
+func newLimitedHandler(t *testing.T, maxDepth, maxComplexity, limitStatus int) *Handler {
+	t.Helper()
+
+	config := &models.GraphQLConfig{
+		Enabled:       true,
+		MaxDepth:      maxDepth,
+		MaxComplexity: maxComplexity,
+		LimitStatus:   limitStatus,
+		Types: []models.GraphQLType{
+			{
+				Name: "User",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
+				},
+			},
+		},
+		Queries: []models.GraphQLQuery{
+			{
+				Name:       "user",
+				ReturnType: "User",
+				Response:   `{"id": 1, "name": "Test"}`,
+			},
+		},
+	}
+
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	return handler
+}
+
+// postQuery sends a GraphQL query and decodes the response
+func postQuery(t *testing.T, handler *Handler, query string) (int, map[string]interface{}) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return w.Code, result
+}
+
+func TestServeHTTP_DepthLimit(t *testing.T) {
+	handler := newLimitedHandler(t, 2, 0, 0)
+
+	// Depth 2 is within the limit and executes normally
+	code, result := postQuery(t, handler, "{ user { id name } }")
+	if code != 200 {
+		t.Errorf("Expected status 200, got %d", code)
+	}
+	if result["errors"] != nil {
+		t.Errorf("Expected no errors, got %v", result["errors"])
+	}
+
+	// Depth 3 is rejected before execution, even through a fragment
+	code, result = postQuery(t, handler, "query { user { ...Deep } } fragment Deep on User { friends { id } }")
+	if code != 200 {
+		t.Errorf("Expected status 200 for rejected query, got %d", code)
+	}
+	errs, ok := result["errors"].([]interface{})
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Expected one error, got %v", result["errors"])
+	}
+	message := errs[0].(map[string]interface{})["message"].(string)
+	if message != "query depth 3 exceeds maximum allowed depth of 2" {
+		t.Errorf("Unexpected error message: %s", message)
+	}
+	if result["data"] != nil {
+		t.Errorf("Expected no data for rejected query, got %v", result["data"])
+	}
+}
+
+func TestServeHTTP_ComplexityLimit(t *testing.T) {
+	handler := newLimitedHandler(t, 0, 2, http.StatusBadRequest)
+
+	code, _ := postQuery(t, handler, "{ user { id } }")
+	if code != 200 {
+		t.Errorf("Expected status 200, got %d", code)
+	}
+
+	code, result := postQuery(t, handler, "{ user { id name } }")
+	if code != 400 {
+		t.Errorf("Expected configured status 400, got %d", code)
+	}
+	if result["errors"] == nil {
+		t.Error("Expected errors for over-complex query")
+	}
+}
+
+func TestServeHTTP_LimitsIgnoreIntrospection(t *testing.T) {
+	handler := newLimitedHandler(t, 1, 0, 0)
+
+	_, result := postQuery(t, handler, "{ __schema { queryType { name fields { name } } } }")
+	if result["errors"] != nil {
+		t.Errorf("Expected introspection to bypass limits, got %v", result["errors"])
+	}
+}
+
+func TestServeHTTP_GetQuery(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled: true,
+		Types: []models.GraphQLType{
+			{
+				Name: "User",
+				Fields: map[string]models.GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
+				},
+			},
+		},
+		Queries: []models.GraphQLQuery{
+			{
+				Name:       "user",
+				ReturnType: "User",
+				Args: map[string]string{
+					"id": "Int!",
+				},
+				Response: `{"id": 1, "name": "Test"}`,
+			},
+		},
+	}
+
+	handler, _ := New(config)
+
+	params := url.Values{}
+	params.Set("query", "query GetUser($id: Int!) { user(id: $id) { id name } }")
+	params.Set("operationName", "GetUser")
+	params.Set("variables", `{"id": 1}`)
+
+	req := httptest.NewRequest("GET", "/graphql?"+params.Encode(), nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if result["errors"] != nil {
+		t.Errorf("Expected no errors, got %v", result["errors"])
+	}
+	data, ok := result["data"].(map[string]interface{})
+	if !ok || data["user"] == nil {
+		t.Errorf("Expected user data, got %v", result["data"])
+	}
+}
+
+func TestServeHTTP_GetMutationRejected(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled: true,
+		Queries: []models.GraphQLQuery{
+			{Name: "ping", ReturnType: "String", Response: `"pong"`},
+		},
+		Mutations: []models.GraphQLMutation{
+			{Name: "reset", ReturnType: "Boolean", Response: `true`},
+		},
+	}
+
+	handler, _ := New(config)
+
+	params := url.Values{}
+	params.Set("query", "mutation { reset }")
+
+	req := httptest.NewRequest("GET", "/graphql?"+params.Encode(), nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != 405 {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+	if w.Header().Get("Allow") != "POST" {
+		t.Errorf("Expected Allow: POST, got %q", w.Header().Get("Allow"))
+	}
+}
+
+func TestServeHTTP_GetMissingQuery(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled: true,
+		Queries: []models.GraphQLQuery{
+			{Name: "ping", ReturnType: "String", Response: `"pong"`},
+		},
+	}
+
+	handler, _ := New(config)
+
+	req := httptest.NewRequest("GET", "/graphql", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != 400 {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestServeHTTP_FieldResolver(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled: true,
+		Path:    "/graphql",
+		Types: []models.GraphQLType{
+			{
+				Name: "User",
+				Fields: map[string]models.GraphQLField{
+					"id":    {Type: "Int!"},
+					"posts": {Type: "[Post]", Response: `[{"title": "First"}, {"title": "Second"}]`},
+				},
+			},
+			{
+				Name: "Post",
+				Fields: map[string]models.GraphQLField{
+					"title": {Type: "String!"},
+				},
+			},
+		},
+		Queries: []models.GraphQLQuery{
+			{
+				Name:       "user",
+				ReturnType: "User",
+				// The parent response has no posts; the field resolver supplies them
+				Response: `{"id": 1}`,
+			},
+		},
+	}
+
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"query": "{ user { id posts { title } } }"})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	expected := `{"data":{"user":{"id":1,"posts":[{"title":"First"},{"title":"Second"}]}}}`
+	if got := bytes.TrimSpace(w.Body.Bytes()); string(got) != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestServeHTTP_QueryCases(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled: true,
+		Path:    "/graphql",
+		Types: []models.GraphQLType{
+			{Name: "User", Fields: map[string]models.GraphQLField{
+				"id":   {Type: "ID!"},
+				"name": {Type: "String"},
+			}},
+		},
+		Queries: []models.GraphQLQuery{
+			{
+				Name:       "user",
+				ReturnType: "User",
+				Args:       map[string]string{"id": "ID!"},
+				Response:   `{"id": "0", "name": "Unknown"}`,
+				Cases: []models.GraphQLCase{
+					{Args: map[string]interface{}{"id": int64(1)}, Response: `{"id": "1", "name": "Alice"}`},
+					{Args: map[string]interface{}{"id": "2"}, Response: `{"id": "2", "name": "Bob"}`},
+				},
+			},
+		},
+	}
+
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`{ user(id: 1) { id name } }`, `{"data":{"user":{"id":"1","name":"Alice"}}}`},
+		{`{ user(id: "2") { id name } }`, `{"data":{"user":{"id":"2","name":"Bob"}}}`},
+		{`{ user(id: 3) { id name } }`, `{"data":{"user":{"id":"0","name":"Unknown"}}}`},
+	}
+
+	for _, tt := range tests {
+		body, _ := json.Marshal(map[string]string{"query": tt.query})
+		req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if got := bytes.TrimSpace(w.Body.Bytes()); string(got) != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.query, tt.expected, got)
+		}
+	}
+}
+
+func TestServeHTTP_Batch(t *testing.T) {
+	handler := newLimitedHandler(t, 0, 0, 0)
+
+	body := `[
+		{"query": "{ user { id } }"},
+		{"query": "query Named { user { name } }", "operationName": "Named"}
+	]`
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var results []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Expected a JSON array, got %s", w.Body.String())
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	// Results are returned in request order
+	first := results[0]["data"].(map[string]interface{})["user"].(map[string]interface{})
+	if first["id"] != float64(1) || first["name"] != nil {
+		t.Errorf("Expected first result to select only id, got %v", first)
+	}
+	second := results[1]["data"].(map[string]interface{})["user"].(map[string]interface{})
+	if second["name"] != "Test" || second["id"] != nil {
+		t.Errorf("Expected second result to select only name, got %v", second)
+	}
+}
+
+func TestServeHTTP_EmptyBatch(t *testing.T) {
+	handler := newLimitedHandler(t, 0, 0, 0)
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader("[]"))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != 400 {
+		t.Errorf("Expected status 400 for empty batch, got %d", w.Code)
+	}
+}
+
+// newStrictHandler creates a handler with strict_status set and a query whose
+// resolver always fails
+func newStrictHandler(t *testing.T, strict bool) *Handler {
+	t.Helper()
+
+	config := &models.GraphQLConfig{
+		Enabled:      true,
+		StrictStatus: strict,
+		Types: []models.GraphQLType{
+			{
+				Name: "User",
+				Fields: map[string]models.GraphQLField{
+					"id": {Type: "Int!"},
+				},
+			},
+		},
+		Queries: []models.GraphQLQuery{
+			{Name: "user", ReturnType: "User", Response: `{"id": 1}`},
+			{Name: "broken", ReturnType: "User", Response: `{not json`},
+		},
+	}
+
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	return handler
+}
+
+func TestServeHTTP_StrictStatusSyntaxError(t *testing.T) {
+	code, result := postQuery(t, newStrictHandler(t, true), "{ user { id ")
+	if code != 400 {
+		t.Errorf("Expected status 400 for syntax error, got %d", code)
+	}
+	if result["errors"] == nil {
+		t.Error("Expected errors for syntax error")
+	}
+
+	code, _ = postQuery(t, newStrictHandler(t, true), "{ user { missing } }")
+	if code != 400 {
+		t.Errorf("Expected status 400 for validation error, got %d", code)
+	}
+
+	// Without strict_status the spec default of 200 is kept
+	code, _ = postQuery(t, newStrictHandler(t, false), "{ user { id ")
+	if code != 200 {
+		t.Errorf("Expected status 200 without strict_status, got %d", code)
+	}
+}
+
+func TestServeHTTP_StrictStatusResolverError(t *testing.T) {
+	code, result := postQuery(t, newStrictHandler(t, true), "{ broken { id } }")
+	if code != 200 {
+		t.Errorf("Expected status 200 for resolver error, got %d", code)
+	}
+	if result["errors"] == nil {
+		t.Error("Expected errors in body for resolver error")
+	}
+	if _, ok := result["data"]; !ok {
+		t.Error("Expected data in body for resolver error")
+	}
+}
@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// queryCost holds the measured size of a single GraphQL operation
+type queryCost struct {
+	depth      int
+	complexity int
+}
+
+// checkQueryLimits parses a query and rejects it when any operation exceeds
+// the configured depth or complexity. Parse errors are left for graphql.Do
+// to report in the usual way.
+func (h *Handler) checkQueryLimits(query string) error {
+	if h.config.MaxDepth <= 0 && h.config.MaxComplexity <= 0 {
+		return nil
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		cost := measureSelectionSet(operation.SelectionSet, fragments, map[string]bool{})
+		if h.config.MaxDepth > 0 && cost.depth > h.config.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds maximum allowed depth of %d", cost.depth, h.config.MaxDepth)
+		}
+		if h.config.MaxComplexity > 0 && cost.complexity > h.config.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds maximum allowed complexity of %d", cost.complexity, h.config.MaxComplexity)
+		}
+	}
+
+	return nil
+}
+
+// measureSelectionSet walks a selection set, expanding fragments. Every field
+// adds one to complexity and each nested selection adds one to depth.
+// Introspection fields (prefixed with "__") are not counted so tooling keeps
+// working under tight limits.
+func measureSelectionSet(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, visiting map[string]bool) queryCost {
+	var cost queryCost
+	if set == nil {
+		return cost
+	}
+
+	for _, selection := range set.Selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if sel.Name != nil && strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			child := measureSelectionSet(sel.SelectionSet, fragments, visiting)
+			cost.complexity += 1 + child.complexity
+			if child.depth+1 > cost.depth {
+				cost.depth = child.depth + 1
+			}
+		case *ast.InlineFragment:
+			child := measureSelectionSet(sel.SelectionSet, fragments, visiting)
+			cost.complexity += child.complexity
+			if child.depth > cost.depth {
+				cost.depth = child.depth
+			}
+		case *ast.FragmentSpread:
+			if sel.Name == nil {
+				continue
+			}
+			name := sel.Name.Value
+			fragment, ok := fragments[name]
+			// Cyclic fragments are rejected by validation; just stop walking
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			child := measureSelectionSet(fragment.SelectionSet, fragments, visiting)
+			delete(visiting, name)
+			cost.complexity += child.complexity
+			if child.depth > cost.depth {
+				cost.depth = child.depth
+			}
+		}
+	}
+
+	return cost
+}
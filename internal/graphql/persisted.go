@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// requestExtensions holds the request extensions the handler understands
+type requestExtensions struct {
+	PersistedQuery *persistedQueryExtension `json:"persistedQuery"`
+}
+
+// persistedQueryExtension identifies a persisted query by the SHA-256 hash
+// of its text, as sent by Apollo clients
+type persistedQueryExtension struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// newPersistedQueries indexes the configured persisted queries by hash. A
+// later query with the same hash replaces an earlier one.
+func newPersistedQueries(persisted []models.GraphQLPersistedQuery) map[string]string {
+	queries := make(map[string]string, len(persisted))
+	for i := range persisted {
+		queries[persisted[i].GetHash()] = persisted[i].Query
+	}
+	return queries
+}
+
+// checkPersistedQueries validates every persisted query against the schema,
+// so a stale query is reported at startup rather than when a client sends it
+func (h *Handler) checkPersistedQueries() error {
+	for _, persisted := range h.config.Persisted {
+		if errs := h.validate(persisted.Query); len(errs) > 0 {
+			label := "persisted query " + persisted.GetHash()
+			if persisted.Source != "" {
+				label += " (defined in " + persisted.Source + ")"
+			}
+			return fmt.Errorf("%s: %s", label, errs[0].Message)
+		}
+	}
+	return nil
+}
+
+// resolvePersisted fills in the query of a request that sends only a
+// persisted query hash. It returns a PersistedQueryNotFound result for an
+// unknown hash, and nil once the request has a query to execute. Requests
+// that send their query text are executed as sent.
+func (h *Handler) resolvePersisted(params *requestParams) *graphql.Result {
+	if params.Query != "" || params.Extensions == nil || params.Extensions.PersistedQuery == nil {
+		return nil
+	}
+
+	hash := strings.ToLower(params.Extensions.PersistedQuery.SHA256Hash)
+	query, ok := h.persisted[hash]
+	if !ok {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{{
+			Message:    "PersistedQueryNotFound",
+			Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"},
+		}}}
+	}
+	params.Query = query
+	return nil
+}
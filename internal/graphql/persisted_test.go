@@ -0,0 +1,119 @@
+package graphql
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// persistedQueryHash returns the hash an Apollo client sends for query
+func persistedQueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func newPersistedHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	config := &models.GraphQLConfig{
+		Enabled: true,
+		Queries: []models.GraphQLQuery{
+			{Name: "ping", ReturnType: "String", Response: `"pong"`},
+			{Name: "version", ReturnType: "Int", Response: `2`},
+		},
+		Persisted: []models.GraphQLPersistedQuery{
+			{Query: "{ ping }"},
+			{Hash: strings.ToUpper(persistedQueryHash("query Version { version }")), Query: "query Version { version }"},
+		},
+	}
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	return handler
+}
+
+// postPersisted sends a request carrying only a persisted query hash
+func postPersisted(t *testing.T, handler *Handler, hash string) (int, string) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"extensions": map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+		},
+	})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code, strings.TrimSpace(w.Body.String())
+}
+
+func TestServeHTTP_PersistedQueryHit(t *testing.T) {
+	handler := newPersistedHandler(t)
+
+	tests := []struct {
+		hash     string
+		expected string
+	}{
+		{persistedQueryHash("{ ping }"), `{"data":{"ping":"pong"}}`},
+		// Configured hashes match regardless of case
+		{persistedQueryHash("query Version { version }"), `{"data":{"version":2}}`},
+	}
+	for _, tt := range tests {
+		code, body := postPersisted(t, handler, tt.hash)
+		if code != 200 || body != tt.expected {
+			t.Errorf("Expected 200 %s, got %d %s", tt.expected, code, body)
+		}
+	}
+
+	// GET requests send the extension as a JSON query parameter
+	extensions := `{"persistedQuery":{"version":1,"sha256Hash":"` + persistedQueryHash("{ ping }") + `"}}`
+	req := httptest.NewRequest("GET", "/graphql?extensions="+url.QueryEscape(extensions), nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if body := strings.TrimSpace(w.Body.String()); w.Code != 200 || body != `{"data":{"ping":"pong"}}` {
+		t.Errorf("Expected GET persisted query to execute, got %d %s", w.Code, body)
+	}
+}
+
+func TestServeHTTP_PersistedQueryMiss(t *testing.T) {
+	handler := newPersistedHandler(t)
+
+	code, body := postPersisted(t, handler, persistedQueryHash("{ unknown }"))
+	if code != 200 {
+		t.Errorf("Expected status 200, got %d", code)
+	}
+
+	var result struct {
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Message != "PersistedQueryNotFound" || result.Errors[0].Extensions["code"] != "PERSISTED_QUERY_NOT_FOUND" {
+		t.Errorf("Expected PersistedQueryNotFound, got %s", body)
+	}
+}
+
+func TestNew_InvalidPersistedQuery(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled:   true,
+		Queries:   []models.GraphQLQuery{{Name: "ping", ReturnType: "String", Response: `"pong"`}},
+		Persisted: []models.GraphQLPersistedQuery{{Query: "{ pong }", Source: "persisted.toml"}},
+	}
+	_, err := New(config)
+	if err == nil || !strings.Contains(err.Error(), "(defined in persisted.toml)") || !strings.Contains(err.Error(), `Cannot query field "pong"`) {
+		t.Errorf("Expected the stale persisted query to be reported, got %v", err)
+	}
+}
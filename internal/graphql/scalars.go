@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// newPassThroughScalar builds a custom scalar that serializes and parses
+// values unchanged, so configured responses are returned exactly as written
+func newPassThroughScalar(def models.GraphQLScalar) *graphql.Scalar {
+	return graphql.NewScalar(graphql.ScalarConfig{
+		Name:        def.Name,
+		Description: def.Description,
+		Serialize: func(value interface{}) interface{} {
+			return value
+		},
+		ParseValue: func(value interface{}) interface{} {
+			return value
+		},
+		ParseLiteral: literalValue,
+	})
+}
+
+// literalValue converts an inline query literal to its Go value
+func literalValue(value ast.Value) interface{} {
+	switch v := value.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			return n
+		}
+		return v.Value
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+		return v.Value
+	case *ast.ListValue:
+		items := make([]interface{}, 0, len(v.Values))
+		for _, item := range v.Values {
+			items = append(items, literalValue(item))
+		}
+		return items
+	case *ast.ObjectValue:
+		fields := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			fields[field.Name.Value] = literalValue(field.Value)
+		}
+		return fields
+	}
+	return nil
+}
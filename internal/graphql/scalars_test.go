@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestServeHTTP_CustomScalars(t *testing.T) {
+	config := &models.GraphQLConfig{
+		Enabled: true,
+		Path:    "/graphql",
+		Scalars: []models.GraphQLScalar{
+			{Name: "DateTime", Description: "RFC 3339 timestamp"},
+			{Name: "JSON"},
+		},
+		Types: []models.GraphQLType{
+			{
+				Name: "Event",
+				Fields: map[string]models.GraphQLField{
+					"id":       {Type: "Int!"},
+					"startsAt": {Type: "DateTime!"},
+					"metadata": {Type: "JSON"},
+				},
+			},
+		},
+		Queries: []models.GraphQLQuery{
+			{
+				Name:       "event",
+				ReturnType: "Event",
+				Response:   `{"id": 1, "startsAt": "2024-03-01T09:30:00Z", "metadata": {"tags": ["a", "b"], "capacity": 40}}`,
+			},
+		},
+	}
+
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"query": "{ event { id startsAt metadata } }"})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	expected := `{"data":{"event":{"id":1,"metadata":{"capacity":40,"tags":["a","b"]},"startsAt":"2024-03-01T09:30:00Z"}}}`
+	if got := bytes.TrimSpace(w.Body.Bytes()); string(got) != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// The scalar is exposed under its own name rather than as String
+	scalar, ok := handler.schema.Type("DateTime").(interface{ Name() string })
+	if !ok || scalar.Name() != "DateTime" {
+		t.Errorf("Expected DateTime scalar in schema, got %v", handler.schema.Type("DateTime"))
+	}
+}
@@ -0,0 +1,76 @@
+package graphql
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// schemaErrorSources returns the config files defining the types, scalars,
+// queries and mutations a schema build error names, in sorted order. The
+// schema builder names the offending definition either quoted ("User") or at
+// the start of the message (User fields must be ...), and a field or argument
+// is attributed to the definition it belongs to.
+func schemaErrorSources(config *models.GraphQLConfig, err error) []string {
+	message := err.Error()
+	mentions := func(name string) bool {
+		return name != "" && (strings.Contains(message, `"`+name+`"`) || strings.HasPrefix(message, name+" "))
+	}
+	mentionsAny := func(names map[string]string) bool {
+		for name := range names {
+			if mentions(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var sources []string
+	add := func(source string) {
+		if source != "" && !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	for _, typeDef := range config.Types {
+		fieldMentioned := false
+		for fieldName := range typeDef.Fields {
+			if mentions(fieldName) {
+				fieldMentioned = true
+				break
+			}
+		}
+		if mentions(typeDef.Name) || fieldMentioned {
+			add(typeDef.Source)
+		}
+	}
+	for _, scalar := range config.Scalars {
+		if mentions(scalar.Name) {
+			add(scalar.Source)
+		}
+	}
+	for _, query := range config.Queries {
+		if mentions(query.Name) || mentionsAny(query.Args) {
+			add(query.Source)
+		}
+	}
+	for _, mutation := range config.Mutations {
+		if mentions(mutation.Name) || mentionsAny(mutation.Args) {
+			add(mutation.Source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// withSchemaErrorSources adds the config files behind a schema build error to
+// its message, when they are known
+func withSchemaErrorSources(config *models.GraphQLConfig, err error) error {
+	sources := schemaErrorSources(config, err)
+	if len(sources) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w (defined in %s)", err, strings.Join(sources, ", "))
+}
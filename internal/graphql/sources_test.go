@@ -0,0 +1,70 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestNew_SchemaErrorSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *models.GraphQLConfig
+		expected string
+	}{
+		{
+			"invalid field name",
+			&models.GraphQLConfig{
+				Enabled: true,
+				Types:   []models.GraphQLType{{Name: "User", Fields: map[string]models.GraphQLField{"first-name": {Type: "String"}}, Source: "users.toml"}},
+				Queries: []models.GraphQLQuery{{Name: "user", ReturnType: "User", Source: "queries.toml"}},
+			},
+			`"first-name" does not. (defined in users.toml)`,
+		},
+		{
+			"type without fields",
+			&models.GraphQLConfig{
+				Enabled: true,
+				Types:   []models.GraphQLType{{Name: "User", Source: "users.toml"}},
+				Queries: []models.GraphQLQuery{{Name: "user", ReturnType: "User", Source: "queries.toml"}},
+			},
+			"(defined in users.toml)",
+		},
+		{
+			"invalid argument name",
+			&models.GraphQLConfig{
+				Enabled:   true,
+				Queries:   []models.GraphQLQuery{{Name: "user", ReturnType: "String", Source: "queries.toml"}},
+				Mutations: []models.GraphQLMutation{{Name: "rename", ReturnType: "String", Args: map[string]string{"new-name": "String"}, Source: "mutations.toml"}},
+			},
+			"(defined in mutations.toml)",
+		},
+		{
+			"clash across files",
+			&models.GraphQLConfig{
+				Enabled: true,
+				Types:   []models.GraphQLType{{Name: "String", Fields: map[string]models.GraphQLField{"id": {Type: "ID"}}, Source: "b.toml"}},
+				Queries: []models.GraphQLQuery{{Name: "name", ReturnType: "String", Source: "a.toml"}},
+			},
+			`multiple types named "String". (defined in b.toml)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestNew_SchemaErrorWithoutSources(t *testing.T) {
+	// Configs built in code have no sources, leaving the message unchanged
+	_, err := New(&models.GraphQLConfig{Enabled: true, Queries: []models.GraphQLQuery{{Name: "bad-name", ReturnType: "String"}}})
+	if err == nil || strings.Contains(err.Error(), "defined in") {
+		t.Errorf("Expected an error without sources, got %v", err)
+	}
+}
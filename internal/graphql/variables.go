@@ -0,0 +1,161 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// checkVariables checks the request's variables against the types the
+// operation declares for them, returning one readable error per bad
+// variable. Values of built-in scalars must already have the right JSON
+// type: integral numbers for Int, numbers for Float, and so on, so "5" or
+// 5.5 are rejected for Int instead of being silently coerced. Parse errors
+// and unknown types are left for graphql.Do to report in the usual way.
+func checkVariables(query, operationName string, variables map[string]interface{}) []gqlerrors.FormattedError {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+	operation := findOperation(doc, operationName)
+	if operation == nil {
+		return nil
+	}
+
+	var errs []gqlerrors.FormattedError
+	for _, def := range operation.VariableDefinitions {
+		if def.Variable == nil || def.Variable.Name == nil {
+			continue
+		}
+		name := def.Variable.Name.Value
+		value, provided := variables[name]
+
+		var message string
+		switch {
+		case !provided:
+			if _, nonNull := def.Type.(*ast.NonNull); nonNull && def.DefaultValue == nil {
+				message = fmt.Sprintf("Variable \"$%s\" of required type \"%s\" was not provided.", name, typeString(def.Type))
+			}
+		default:
+			if reason := checkValue(def.Type, value); reason != "" {
+				message = fmt.Sprintf("Variable \"$%s\" got invalid value %s; %s.", name, encodeValue(value), reason)
+			}
+		}
+		if message != "" {
+			errs = append(errs, gqlerrors.FormatError(gqlerrors.NewLocatedError(message, []ast.Node{def})))
+		}
+	}
+	return errs
+}
+
+// findOperation returns the operation a request executes: the one named
+// operationName, or the only operation when no name is given
+func findOperation(doc *ast.Document, operationName string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" {
+			if found != nil {
+				// Ambiguous; graphql.Do reports it
+				return nil
+			}
+			found = operation
+		} else if operation.Name != nil && operation.Name.Value == operationName {
+			return operation
+		}
+	}
+	return found
+}
+
+// checkValue returns why value cannot be used as typ, or "" when it can. A
+// single value is accepted for a list type, as GraphQL input coercion allows.
+func checkValue(typ ast.Type, value interface{}) string {
+	switch typ := typ.(type) {
+	case *ast.NonNull:
+		if value == nil {
+			return fmt.Sprintf("expected non-null type %s", typeString(typ))
+		}
+		return checkValue(typ.Type, value)
+	case *ast.List:
+		if value == nil {
+			return ""
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return checkValue(typ.Type, value)
+		}
+		for i, item := range items {
+			if reason := checkValue(typ.Type, item); reason != "" {
+				return fmt.Sprintf("item %d: %s", i, reason)
+			}
+		}
+		return ""
+	case *ast.Named:
+		if value == nil || typ.Name == nil {
+			return ""
+		}
+		if !scalarAccepts(typ.Name.Value, value) {
+			return "expected type " + typ.Name.Value
+		}
+	}
+	return ""
+}
+
+// scalarAccepts reports whether a JSON variable value is valid for a built-in
+// scalar. Custom scalars and other types accept anything here.
+func scalarAccepts(name string, value interface{}) bool {
+	switch name {
+	case "Int":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number) && number >= math.MinInt32 && number <= math.MaxInt32
+	case "Float":
+		_, ok := value.(float64)
+		return ok
+	case "String":
+		_, ok := value.(string)
+		return ok
+	case "Boolean":
+		_, ok := value.(bool)
+		return ok
+	case "ID":
+		switch value := value.(type) {
+		case string:
+			return true
+		case float64:
+			return value == math.Trunc(value)
+		}
+		return false
+	}
+	return true
+}
+
+// typeString formats a type reference as written in a query, e.g. [Int!]!
+func typeString(typ ast.Type) string {
+	switch typ := typ.(type) {
+	case *ast.NonNull:
+		return typeString(typ.Type) + "!"
+	case *ast.List:
+		return "[" + typeString(typ.Type) + "]"
+	case *ast.Named:
+		if typ.Name != nil {
+			return typ.Name.Value
+		}
+	}
+	return ""
+}
+
+// encodeValue formats a variable value as JSON for error messages
+func encodeValue(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// newVariablesHandler creates a handler with a user query taking an Int! id,
+// an optional Float score and an optional list of String tags
+func newVariablesHandler(t *testing.T, strictStatus bool) *Handler {
+	t.Helper()
+
+	config := &models.GraphQLConfig{
+		Enabled:      true,
+		StrictStatus: strictStatus,
+		Types: []models.GraphQLType{
+			{Name: "User", Fields: map[string]models.GraphQLField{
+				"id":   {Type: "Int!"},
+				"name": {Type: "String!"},
+			}},
+		},
+		Queries: []models.GraphQLQuery{
+			{
+				Name:       "user",
+				ReturnType: "User",
+				Args:       map[string]string{"id": "Int!", "score": "Float", "tags": "[String]"},
+				Response:   `{"id": 1, "name": "Alice"}`,
+			},
+		},
+	}
+
+	handler, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	return handler
+}
+
+// postVariables posts query with JSON-encoded variables
+func postVariables(t *testing.T, handler *Handler, query, variables string) (int, map[string]interface{}) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": json.RawMessage(variables)})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response %s: %v", w.Body.String(), err)
+	}
+	return w.Code, result
+}
+
+const userByIDQuery = "query GetUser($id: Int!, $score: Float, $tags: [String]) { user(id: $id, score: $score, tags: $tags) { id name } }"
+
+func TestServeHTTP_WrongTypedVariable(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables string
+		expected  string
+	}{
+		{"string for Int", `{"id": "abc"}`, `Variable "$id" got invalid value "abc"; expected type Int.`},
+		{"float for Int", `{"id": 5.5}`, `Variable "$id" got invalid value 5.5; expected type Int.`},
+		{"Int out of range", `{"id": 9999999999}`, `Variable "$id" got invalid value 9999999999; expected type Int.`},
+		{"null for non-null", `{"id": null}`, `Variable "$id" got invalid value null; expected non-null type Int!.`},
+		{"string for Float", `{"id": 1, "score": "3.5"}`, `Variable "$score" got invalid value "3.5"; expected type Float.`},
+		{"wrong list item", `{"id": 1, "tags": ["a", 2]}`, `Variable "$tags" got invalid value ["a",2]; item 1: expected type String.`},
+		{"missing required", `{}`, `Variable "$id" of required type "Int!" was not provided.`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, result := postVariables(t, newVariablesHandler(t, false), userByIDQuery, tt.variables)
+			if code != 200 {
+				t.Errorf("Expected status 200, got %d", code)
+			}
+			if data, ok := result["data"]; ok && data != nil {
+				t.Errorf("Expected no data for invalid variables, got %v", data)
+			}
+
+			errs, _ := result["errors"].([]interface{})
+			if len(errs) != 1 {
+				t.Fatalf("Expected 1 error, got %v", result["errors"])
+			}
+			gqlErr := errs[0].(map[string]interface{})
+			if gqlErr["message"] != tt.expected {
+				t.Errorf("Expected message %q, got %q", tt.expected, gqlErr["message"])
+			}
+			// The error points at the variable definition
+			locations, _ := gqlErr["locations"].([]interface{})
+			if len(locations) != 1 {
+				t.Errorf("Expected the variable's location, got %v", gqlErr["locations"])
+			}
+		})
+	}
+}
+
+func TestServeHTTP_WrongTypedVariableStrictStatus(t *testing.T) {
+	code, result := postVariables(t, newVariablesHandler(t, true), userByIDQuery, `{"id": "abc"}`)
+	if code != 400 {
+		t.Errorf("Expected status 400 with strict_status, got %d", code)
+	}
+	if result["errors"] == nil {
+		t.Error("Expected errors for invalid variable")
+	}
+}
+
+func TestServeHTTP_CorrectlyTypedVariables(t *testing.T) {
+	valid := []string{
+		`{"id": 1}`,
+		`{"id": 1, "score": 3}`,
+		`{"id": 1, "score": 3.5, "tags": ["a", "b"]}`,
+		`{"id": 1, "tags": "single"}`,
+		`{"id": 1, "score": null}`,
+	}
+
+	for _, variables := range valid {
+		code, result := postVariables(t, newVariablesHandler(t, true), userByIDQuery, variables)
+		if code != 200 || result["errors"] != nil {
+			t.Errorf("Expected %s to be accepted, got %d %v", variables, code, result["errors"])
+			continue
+		}
+		user := result["data"].(map[string]interface{})["user"].(map[string]interface{})
+		if user["name"] != "Alice" {
+			t.Errorf("Expected user Alice for %s, got %v", variables, user)
+		}
+	}
+}
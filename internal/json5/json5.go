@@ -0,0 +1,389 @@
+// Package json5 converts JSON5 documents to compact, standard JSON.
+//
+// Supported extensions over JSON:
+//
+//	// line and /* block */ comments
+//	trailing commas in objects and arrays
+//	unquoted object keys (identifiers such as name, _id, $ref)
+//	'single-quoted' strings, and \x escapes and escaped line breaks in strings
+//	hexadecimal numbers, leading + signs, and leading or trailing decimal points
+//
+// Response template tokens such as {{body}} are copied unchanged wherever a
+// value may appear, so templated responses can be written in JSON5 too.
+// Infinity and NaN are rejected because JSON cannot represent them.
+package json5
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ToJSON converts a JSON5 document to compact JSON. Object keys keep their
+// order and numbers keep their precision.
+func ToJSON(src string) (string, error) {
+	c := &converter{src: src}
+	c.skipSpace()
+	if err := c.value(); err != nil {
+		return "", err
+	}
+	c.skipSpace()
+	if c.pos < len(c.src) {
+		return "", c.errorf("unexpected %q after value", c.peekRune())
+	}
+	return c.out.String(), nil
+}
+
+// converter transcodes src to out in a single pass
+type converter struct {
+	src string
+	pos int
+	out strings.Builder
+}
+
+// errorf reports an error at the current line and column
+func (c *converter) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(c.src[:c.pos], "\n")
+	col := c.pos - strings.LastIndex(c.src[:c.pos], "\n")
+	return fmt.Errorf("json5: line %d column %d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+// peekRune returns the rune at the current position, or 0 at the end
+func (c *converter) peekRune() rune {
+	if c.pos >= len(c.src) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(c.src[c.pos:])
+	return r
+}
+
+// skipSpace skips whitespace and comments
+func (c *converter) skipSpace() {
+	for c.pos < len(c.src) {
+		rest := c.src[c.pos:]
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			c.pos += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				c.pos = len(c.src)
+				return
+			}
+			c.pos += end + 4
+		default:
+			r, size := utf8.DecodeRuneInString(rest)
+			if !unicode.IsSpace(r) && r != '\ufeff' {
+				return
+			}
+			c.pos += size
+		}
+	}
+}
+
+// value converts a single value
+func (c *converter) value() error {
+	if c.pos >= len(c.src) {
+		return c.errorf("unexpected end of input")
+	}
+
+	rest := c.src[c.pos:]
+	switch ch := rest[0]; {
+	case strings.HasPrefix(rest, "{{"):
+		return c.templateToken()
+	case ch == '{':
+		return c.object()
+	case ch == '[':
+		return c.array()
+	case ch == '"' || ch == '\'':
+		s, err := c.stringLiteral()
+		if err != nil {
+			return err
+		}
+		c.writeString(s)
+		return nil
+	case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+		return c.number()
+	}
+
+	for _, literal := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(rest, literal) {
+			c.out.WriteString(literal)
+			c.pos += len(literal)
+			return nil
+		}
+	}
+	if strings.HasPrefix(rest, "Infinity") || strings.HasPrefix(rest, "NaN") {
+		return c.errorf("Infinity and NaN cannot be represented in JSON")
+	}
+	return c.errorf("unexpected %q", c.peekRune())
+}
+
+// templateToken copies a {{...}} template token unchanged
+func (c *converter) templateToken() error {
+	end := strings.Index(c.src[c.pos:], "}}")
+	if end < 0 {
+		return c.errorf("unterminated template token")
+	}
+	c.out.WriteString(c.src[c.pos : c.pos+end+2])
+	c.pos += end + 2
+	return nil
+}
+
+// object converts an object, quoting keys and dropping a trailing comma
+func (c *converter) object() error {
+	c.pos++ // {
+	c.out.WriteByte('{')
+	for first := true; ; first = false {
+		c.skipSpace()
+		if c.pos < len(c.src) && c.src[c.pos] == '}' {
+			break
+		}
+		if !first {
+			c.out.WriteByte(',')
+		}
+
+		key, err := c.key()
+		if err != nil {
+			return err
+		}
+		c.writeString(key)
+
+		c.skipSpace()
+		if c.pos >= len(c.src) || c.src[c.pos] != ':' {
+			return c.errorf("expected ':' after key %q", key)
+		}
+		c.pos++
+		c.out.WriteByte(':')
+
+		c.skipSpace()
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		c.skipSpace()
+		if c.pos < len(c.src) && c.src[c.pos] == ',' {
+			c.pos++
+			continue
+		}
+		if c.pos < len(c.src) && c.src[c.pos] == '}' {
+			break
+		}
+		return c.errorf("expected ',' or '}' in object")
+	}
+	c.pos++ // }
+	c.out.WriteByte('}')
+	return nil
+}
+
+// key reads a quoted or identifier object key
+func (c *converter) key() (string, error) {
+	if c.pos >= len(c.src) {
+		return "", c.errorf("unexpected end of input in object")
+	}
+	if ch := c.src[c.pos]; ch == '"' || ch == '\'' {
+		return c.stringLiteral()
+	}
+
+	start := c.pos
+	for c.pos < len(c.src) {
+		r, size := utf8.DecodeRuneInString(c.src[c.pos:])
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && (c.pos == start || !unicode.IsDigit(r)) {
+			break
+		}
+		c.pos += size
+	}
+	if c.pos == start {
+		return "", c.errorf("expected object key, found %q", c.peekRune())
+	}
+	return c.src[start:c.pos], nil
+}
+
+// array converts an array, dropping a trailing comma
+func (c *converter) array() error {
+	c.pos++ // [
+	c.out.WriteByte('[')
+	for first := true; ; first = false {
+		c.skipSpace()
+		if c.pos < len(c.src) && c.src[c.pos] == ']' {
+			break
+		}
+		if !first {
+			c.out.WriteByte(',')
+		}
+
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		c.skipSpace()
+		if c.pos < len(c.src) && c.src[c.pos] == ',' {
+			c.pos++
+			continue
+		}
+		if c.pos < len(c.src) && c.src[c.pos] == ']' {
+			break
+		}
+		return c.errorf("expected ',' or ']' in array")
+	}
+	c.pos++ // ]
+	c.out.WriteByte(']')
+	return nil
+}
+
+// stringLiteral decodes a single- or double-quoted string
+func (c *converter) stringLiteral() (string, error) {
+	quote := c.src[c.pos]
+	c.pos++
+
+	var sb strings.Builder
+	for c.pos < len(c.src) {
+		ch := c.src[c.pos]
+		switch {
+		case ch == quote:
+			c.pos++
+			return sb.String(), nil
+		case ch == '\n' || ch == '\r':
+			return "", c.errorf("unescaped line break in string")
+		case ch != '\\':
+			sb.WriteByte(ch)
+			c.pos++
+			continue
+		}
+
+		// Escape sequence
+		c.pos++
+		if c.pos >= len(c.src) {
+			break
+		}
+		esc := c.src[c.pos]
+		c.pos++
+		switch esc {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'v':
+			sb.WriteByte('\v')
+		case '0':
+			sb.WriteByte(0)
+		case '\n':
+			// Escaped line break continues the string
+		case '\r':
+			if c.pos < len(c.src) && c.src[c.pos] == '\n' {
+				c.pos++
+			}
+		case 'x', 'u':
+			digits := 2
+			if esc == 'u' {
+				digits = 4
+			}
+			if c.pos+digits > len(c.src) {
+				return "", c.errorf("truncated \\%c escape", esc)
+			}
+			code, err := strconv.ParseUint(c.src[c.pos:c.pos+digits], 16, 32)
+			if err != nil {
+				return "", c.errorf("invalid \\%c escape", esc)
+			}
+			c.pos += digits
+			r := rune(code)
+			// Characters outside the BMP are written as a surrogate pair
+			if utf16.IsSurrogate(r) && strings.HasPrefix(c.src[c.pos:], "\\u") && c.pos+6 <= len(c.src) {
+				if low, err := strconv.ParseUint(c.src[c.pos+2:c.pos+6], 16, 32); err == nil {
+					if combined := utf16.DecodeRune(r, rune(low)); combined != unicode.ReplacementChar {
+						r = combined
+						c.pos += 6
+					}
+				}
+			}
+			sb.WriteRune(r)
+		default:
+			// \" \' \\ \/ and any other character stand for themselves
+			sb.WriteByte(esc)
+		}
+	}
+	return "", c.errorf("unterminated string")
+}
+
+// writeString writes s as a JSON string without escaping HTML characters
+func (c *converter) writeString(s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // strings always encode
+	c.out.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// number converts a number to JSON syntax
+func (c *converter) number() error {
+	start := c.pos
+	negative := false
+	if ch := c.src[c.pos]; ch == '+' || ch == '-' {
+		negative = ch == '-'
+		c.pos++
+	}
+	rest := c.src[c.pos:]
+	if strings.HasPrefix(rest, "Infinity") || strings.HasPrefix(rest, "NaN") {
+		return c.errorf("Infinity and NaN cannot be represented in JSON")
+	}
+
+	// Hexadecimal integers
+	if strings.HasPrefix(rest, "0x") || strings.HasPrefix(rest, "0X") {
+		c.pos += 2
+		digitsStart := c.pos
+		for c.pos < len(c.src) && strings.IndexByte("0123456789abcdefABCDEF", c.src[c.pos]) >= 0 {
+			c.pos++
+		}
+		n, err := strconv.ParseUint(c.src[digitsStart:c.pos], 16, 64)
+		if err != nil {
+			return c.errorf("invalid hexadecimal number %q", c.src[start:c.pos])
+		}
+		if negative {
+			c.out.WriteByte('-')
+		}
+		c.out.WriteString(strconv.FormatUint(n, 10))
+		return nil
+	}
+
+	for c.pos < len(c.src) && strings.IndexByte("0123456789.eE+-", c.src[c.pos]) >= 0 {
+		c.pos++
+	}
+	literal := c.src[start:c.pos]
+	literal = strings.TrimPrefix(literal, "+")
+
+	// JSON needs digits on both sides of the decimal point
+	mantissa, exponent, _ := strings.Cut(strings.ToLower(literal), "e")
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") {
+		sign, mantissa = "-", mantissa[1:]
+	}
+	if strings.HasPrefix(mantissa, ".") {
+		mantissa = "0" + mantissa
+	}
+	mantissa = strings.TrimSuffix(mantissa, ".")
+	normalized := sign + mantissa
+	if strings.Contains(strings.ToLower(literal), "e") {
+		normalized += "e" + exponent
+	}
+
+	if !json.Valid([]byte(normalized)) {
+		return c.errorf("invalid number %q", c.src[start:c.pos])
+	}
+	c.out.WriteString(normalized)
+	return nil
+}
@@ -0,0 +1,70 @@
+package json5
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain json", `{"a": [1, 2, 3], "b": null}`, `{"a":[1,2,3],"b":null}`},
+		{"line comment", "{\n  // the id\n  \"id\": 1\n}", `{"id":1}`},
+		{"block comment", `[1, /* two */ 2]`, `[1,2]`},
+		{"trailing commas", `{"a": [1, 2,], "b": true,}`, `{"a":[1,2],"b":true}`},
+		{"unquoted keys", `{id: 1, _type: "user", $ref: "x", user2: 2}`, `{"id":1,"_type":"user","$ref":"x","user2":2}`},
+		{"single quotes", `{'name': 'O"Brien', "it's": 'it\'s'}`, `{"name":"O\"Brien","it's":"it's"}`},
+		{"escapes", `'tab\there \x41 é 😀'`, `"tab\there A é 😀"`},
+		{"escaped line break", "'one \\\ntwo'", `"one two"`},
+		{"html characters", `'<a href="x">&</a>'`, `"<a href=\"x\">&</a>"`},
+		{"hex numbers", `[0x1F, -0xa]`, `[31,-10]`},
+		{"decimal points", `[.5, 5., +1, -.25, 1.5e3, 2.E-1]`, `[0.5,5,1,-0.25,1.5e3,2e-1]`},
+		{"template tokens", `{id: {{query.id}}, name: "{{upper .Query.name}}", items: [{{body}},]}`, `{"id":{{query.id}},"name":"{{upper .Query.name}}","items":[{{body}}]}`},
+		{"empty containers", `{a: {}, b: [], }`, `{"a":{},"b":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON(tt.input)
+			if err != nil {
+				t.Fatalf("ToJSON failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestToJSON_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"unterminated object", `{"a": 1`, "expected ',' or '}'"},
+		{"unterminated string", `{"a": 'x}`, "unterminated string"},
+		{"missing colon", `{a 1}`, "expected ':' after key"},
+		{"infinity", `{a: Infinity}`, "Infinity and NaN"},
+		{"negative nan", `[-NaN]`, "Infinity and NaN"},
+		{"trailing data", `{} {}`, "after value"},
+		{"lone comma", `[,]`, "unexpected ','"},
+		{"bad number", `[1.2.3]`, "invalid number"},
+		{"position", "{\n  a: 1,\n  b: ?\n}", "line 3 column 6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToJSON(tt.input)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
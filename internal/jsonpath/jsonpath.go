@@ -0,0 +1,64 @@
+// Package jsonpath evaluates JSONPath expressions against values decoded by
+// encoding/json. Expressions are parsed by github.com/PaesslerAG/jsonpath,
+// extended with the gval operators filters compare and combine values with.
+//
+// Supported syntax:
+//
+//	$                     the root value
+//	.name ["name"]        object member
+//	.* [*]                every member or element
+//	[0] [1,2]             array indexes
+//	[1:3] [:2] [-2:]      array slice (negative bounds count from the end)
+//	..name ..*            recursive descent
+//	[?(@.price < 10)]     filter using ==, !=, <, <=, >, >=, =~, && and ||
+//
+// String literals are double-quoted or backquoted.
+package jsonpath
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PaesslerAG/gval"
+	"github.com/PaesslerAG/jsonpath"
+)
+
+// language is JSONPath with the full gval expression syntax inside filters
+var language = gval.NewLanguage(gval.Full(), jsonpath.Language())
+
+// Path is a compiled JSONPath expression
+type Path struct {
+	expr string
+	eval gval.Evaluable
+}
+
+// Compile parses a JSONPath expression starting with "$"
+func Compile(expr string) (*Path, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("jsonpath %q: must start with $", expr)
+	}
+	eval, err := language.NewEvaluable(expr)
+	if err != nil {
+		return nil, fmt.Errorf("jsonpath %q: %w", expr, err)
+	}
+	return &Path{expr: expr, eval: eval}, nil
+}
+
+// String returns the source expression
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Get returns the value the path selects. Paths using wildcards, slices,
+// recursive descent or filters select a list of every match, possibly empty;
+// other paths select a single value. ok is false when a member or index the
+// path names does not exist.
+func (p *Path) Get(value interface{}) (result interface{}, ok bool) {
+	result, err := p.eval(context.Background(), value)
+	if err != nil {
+		return nil, false
+	}
+	return result, true
+}
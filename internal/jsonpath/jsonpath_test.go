@@ -0,0 +1,96 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const storeJSON = `{
+	"store": {
+		"books": [
+			{"title": "Sayings", "author": "Rees", "price": 8.95},
+			{"title": "Sword", "author": "Waugh", "price": 12.99, "isbn": "0-553"},
+			{"title": "Moby Dick", "author": "Melville", "price": 8.99, "isbn": "0-395"}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	}
+}`
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		t.Fatalf("Failed to decode JSON: %v", err)
+	}
+	return value
+}
+
+func TestPath_Get(t *testing.T) {
+	doc := decode(t, storeJSON)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"child", "$.store.bicycle.color", "red"},
+		{"bracket name", `$["store"]["bicycle"]["color"]`, "red"},
+		{"index", "$.store.books[1].title", "Sword"},
+		{"indexes", "$.store.books[0,2].title", []interface{}{"Sayings", "Moby Dick"}},
+		{"slice", "$.store.books[0:2].author", []interface{}{"Rees", "Waugh"}},
+		{"negative slice", "$.store.books[-1:].title", []interface{}{"Moby Dick"}},
+		{"wildcard", "$.store.books[*].author", []interface{}{"Rees", "Waugh", "Melville"}},
+		{"recursive", "$.store.books..price", []interface{}{8.95, 12.99, 8.99}},
+		{"filter comparison", "$.store.books[?(@.price < 9)].title", []interface{}{"Sayings", "Moby Dick"}},
+		{"filter string", `$.store.books[?(@.author == "Waugh")].price`, []interface{}{12.99}},
+		{"filter existence", "$.store.books[?(@.isbn != nil)].title", []interface{}{"Sword", "Moby Dick"}},
+		{"filter combined", `$.store.books[?(@.price > 9 || @.author == "Rees")].title`, []interface{}{"Sayings", "Sword"}},
+		{"filter regexp", "$.store.books[?(@.title =~ `^S`)].title", []interface{}{"Sayings", "Sword"}},
+		{"filter no match", "$.store.books[?(@.price > 100)].title", []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) failed: %v", tt.expr, err)
+			}
+
+			got, ok := path.Get(doc)
+			if !ok || !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Get(%q) = %#v, %v, want %#v", tt.expr, got, ok, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPath_GetMissing(t *testing.T) {
+	doc := decode(t, storeJSON)
+
+	for _, expr := range []string{"$.store.missing", "$.store.books[7]", "$.store.bicycle.color.shade"} {
+		path, err := Compile(expr)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", expr, err)
+		}
+		if got, ok := path.Get(doc); ok {
+			t.Errorf("Get(%q) = %#v, expected no match", expr, got)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	tests := []string{
+		"store.books",
+		"$.store.",
+		"$.store.books[",
+		"$.store.books[?(@.price < )]",
+		"$.store.books[?(@.author == 'Waugh')]",
+	}
+
+	for _, expr := range tests {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q) expected error, got nil", expr)
+		}
+	}
+}
@@ -1,20 +1,298 @@
 package models
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/jsonpath"
+)
 
 // Config represents the entire application configuration
 type Config struct {
-	Server    ServerConfig      `toml:"server"`
-	Endpoints []EndpointConfig  `toml:"endpoints"`
-	GraphQL   *GraphQLConfig    `toml:"graphql"`
+	OpenAPI        string            `toml:"openapi"` // OpenAPI 3 JSON or YAML spec to generate endpoints from (optional)
+	Server         ServerConfig      `toml:"server"`
+	DefaultHeaders map[string]string `toml:"default_headers"`
+	CORS           *CORSConfig       `toml:"cors"` // Global CORS policy for every route (optional)
+	Endpoints      []EndpointConfig  `toml:"endpoints"`
+	GraphQL        *GraphQLConfig    `toml:"graphql"`
+	Responses      []NamedResponse   `toml:"responses"` // Reusable bodies referenced by response_ref
+	Files          []string          `toml:"-"`         // Config files loaded, in load order
+
+	// Answers requests no endpoint matches instead of the built-in 404 (optional)
+	DefaultEndpoint *DefaultEndpointConfig `toml:"default_endpoint"`
+
+	// Request methods served as another method, e.g. LIST = "GET" (optional)
+	MethodAliases map[string]string `toml:"method_aliases"`
+
+	// Envelope for the server's own error responses, such as 404 and 405 (optional)
+	Errors *ErrorsConfig `toml:"errors"`
+
+	// Named text included in response bodies with {{> name}} (optional)
+	Fragments map[string]string `toml:"fragments"`
+
+	// Server-wide latency growing with the number of in-flight requests (optional)
+	Throttle *ThrottleConfig `toml:"throttle"`
+
+	// Separate liveness and readiness probes alongside /health (optional)
+	Health *HealthConfig `toml:"health"`
+
+	// Local directories served as static files under URL prefixes (optional)
+	Static []StaticConfig `toml:"static"`
+}
+
+// StaticConfig serves the files of a local directory under a URL prefix.
+// Relative directories are resolved against the config file declaring them.
+type StaticConfig struct {
+	Prefix string `toml:"prefix"` // e.g. /assets/; a trailing slash is implied
+	Dir    string `toml:"dir"`
+}
+
+// validate checks the prefix and directory are set; the loader checks the
+// directory exists
+func (s *StaticConfig) validate(index int) []error {
+	var errs []error
+	if !strings.HasPrefix(strings.TrimSpace(s.Prefix), "/") {
+		errs = append(errs, fmt.Errorf("static[%d]: prefix %q must start with /", index, s.Prefix))
+	}
+	if strings.TrimSpace(s.Dir) == "" {
+		errs = append(errs, fmt.Errorf("static[%d]: dir is required", index))
+	}
+	return errs
+}
+
+// DefaultEndpointConfig is the catch-all response for requests that match no
+// endpoint. The response and header values are templated like an endpoint's.
+type DefaultEndpointConfig struct {
+	Status   int               `toml:"status"` // default 404
+	Response string            `toml:"response"`
+	Headers  map[string]string `toml:"headers"`
+}
+
+// GetStatus returns the status with the 404 default
+func (d *DefaultEndpointConfig) GetStatus() int {
+	if d.Status == 0 {
+		return http.StatusNotFound
+	}
+	return d.Status
+}
+
+// validate checks the status and, when a JSON Content-Type is declared, the
+// response
+func (d *DefaultEndpointConfig) validate() []error {
+	if d == nil {
+		return nil
+	}
+
+	var errs []error
+	if d.Status != 0 && (d.Status < 100 || d.Status > 599) {
+		errs = append(errs, fmt.Errorf("default_endpoint: status %d outside range 100-599", d.Status))
+	}
+	endpoint := EndpointConfig{Headers: d.Headers}
+	if endpoint.declaresJSON() && !validJSONTemplate(d.Response) {
+		errs = append(errs, errors.New("default_endpoint: response is not valid JSON"))
+	}
+	for _, err := range jsonPathErrors(d.Response) {
+		errs = append(errs, fmt.Errorf("default_endpoint: response: %w", err))
+	}
+	return errs
+}
+
+// ErrorsConfig is the body and Content-Type of the errors the server produces
+// itself: unmatched paths and methods, oversized bodies, concurrency limits
+// and timeouts. The template may use {{error.status}}, {{error.title}},
+// {{error.message}} and {{error.detail}} besides the usual request variables.
+type ErrorsConfig struct {
+	Template    string `toml:"template"`
+	ContentType string `toml:"content_type"` // default application/json
+}
+
+// GetContentType returns the Content-Type with the application/json default
+func (e *ErrorsConfig) GetContentType() string {
+	if e.ContentType == "" {
+		return "application/json"
+	}
+	return e.ContentType
+}
+
+// validate checks that a template is set and, for a JSON Content-Type, that
+// it is valid JSON
+func (e *ErrorsConfig) validate() []error {
+	if e == nil {
+		return nil
+	}
+
+	var errs []error
+	if strings.TrimSpace(e.Template) == "" {
+		errs = append(errs, errors.New("errors: template is required"))
+	} else if strings.Contains(strings.ToLower(e.GetContentType()), "json") && !validJSONTemplate(e.Template) {
+		errs = append(errs, errors.New("errors: template is not valid JSON"))
+	}
+	return errs
+}
+
+// ThrottleConfig delays every request by a latency that grows with the
+// number of requests in flight, simulating a server slowing under load
+type ThrottleConfig struct {
+	BaseMS      int `toml:"base_ms"`      // Delay with no other requests in flight
+	IncrementMS int `toml:"increment_ms"` // Added per other request in flight
+	MaxMS       int `toml:"max_ms"`       // Cap on the delay; 0 is uncapped (optional)
+}
+
+// validate checks the throttle; a nil throttle is valid
+func (t *ThrottleConfig) validate() []error {
+	if t == nil {
+		return nil
+	}
+
+	var errs []error
+	if t.BaseMS < 0 || t.IncrementMS < 0 || t.MaxMS < 0 {
+		errs = append(errs, errors.New("throttle: base_ms, increment_ms and max_ms cannot be negative"))
+	}
+	if t.BaseMS == 0 && t.IncrementMS == 0 {
+		errs = append(errs, errors.New("throttle: base_ms or increment_ms is required"))
+	}
+	return errs
+}
+
+// HealthConfig enables Kubernetes-style probes: liveness answers 200 while
+// the process runs, readiness 503 while the configuration is (re)loading
+type HealthConfig struct {
+	LivenessPath  string `toml:"liveness_path"`  // default /livez
+	ReadinessPath string `toml:"readiness_path"` // default /readyz
+}
+
+// GetLivenessPath returns the liveness probe path with the /livez default
+func (h *HealthConfig) GetLivenessPath() string {
+	if h.LivenessPath == "" {
+		return "/livez"
+	}
+	return h.LivenessPath
+}
+
+// GetReadinessPath returns the readiness probe path with the /readyz default
+func (h *HealthConfig) GetReadinessPath() string {
+	if h.ReadinessPath == "" {
+		return "/readyz"
+	}
+	return h.ReadinessPath
+}
+
+// validate checks the probe paths are distinct from each other, /health and
+// every endpoint; a nil block is valid
+func (h *HealthConfig) validate(endpoints []EndpointConfig) []error {
+	if h == nil {
+		return nil
+	}
+
+	var errs []error
+	paths := []struct{ key, path string }{
+		{"liveness_path", h.GetLivenessPath()},
+		{"readiness_path", h.GetReadinessPath()},
+	}
+	for _, probe := range paths {
+		if !strings.HasPrefix(probe.path, "/") {
+			errs = append(errs, fmt.Errorf("health: %s %q must start with /", probe.key, probe.path))
+		}
+		if probe.path == "/health" {
+			errs = append(errs, fmt.Errorf("health: %s cannot be /health", probe.key))
+		}
+		for _, endpoint := range endpoints {
+			if strings.TrimSpace(endpoint.Path) == probe.path {
+				errs = append(errs, fmt.Errorf("health: %s %s is also an endpoint path", probe.key, probe.path))
+				break
+			}
+		}
+	}
+	if h.GetLivenessPath() == h.GetReadinessPath() {
+		errs = append(errs, fmt.Errorf("health: liveness_path and readiness_path are both %s", h.GetLivenessPath()))
+	}
+	return errs
+}
+
+// CORSConfig is a CORS policy applied to every route, including GraphQL and
+// the health check. Endpoint headers override it.
+type CORSConfig struct {
+	AllowedOrigins   []string `toml:"allowed_origins"` // default ["*"]
+	AllowedMethods   []string `toml:"allowed_methods"` // default GET, POST, PUT, PATCH, DELETE, OPTIONS
+	AllowedHeaders   []string `toml:"allowed_headers"` // default: echo the requested headers
+	ExposedHeaders   []string `toml:"exposed_headers"`
+	AllowCredentials bool     `toml:"allow_credentials"`
+	MaxAge           int      `toml:"max_age"` // seconds preflight responses may be cached
+}
+
+// NamedResponse is a response body shared by endpoints through response_ref
+type NamedResponse struct {
+	Name     string `toml:"name"`
+	Response string `toml:"response"`
 }
 
 // ServerConfig contains server-level settings
 type ServerConfig struct {
-	Port         int    `toml:"port"`
-	Host         string `toml:"host"`
-	ReadTimeout  int    `toml:"read_timeout"`
-	WriteTimeout int    `toml:"write_timeout"`
+	Port              int    `toml:"port"`
+	Host              string `toml:"host"`
+	ReadTimeout       int    `toml:"read_timeout"`
+	WriteTimeout      int    `toml:"write_timeout"`
+	IdleTimeout       int    `toml:"idle_timeout"`
+	ReadHeaderTimeout int    `toml:"read_header_timeout"`
+	MaxBodyBytes      int64  `toml:"max_body_bytes"` // 0 means unlimited
+	TLSCertFile       string `toml:"tls_cert_file"`
+	TLSKeyFile        string `toml:"tls_key_file"`
+	RequestID         bool   `toml:"request_id"`  // echo or generate X-Request-Id on every response
+	H2C               bool   `toml:"h2c"`         // also accept HTTP/2 over cleartext connections
+	SocketPath        string `toml:"socket_path"` // listen on this Unix socket instead of host:port
+
+	// Addresses ("host:port") to listen on at once, replacing host and port
+	Listen []string `toml:"listen"`
+
+	// Try the following ports when the configured one is in use
+	AutoPort         bool `toml:"auto_port"`
+	AutoPortAttempts int  `toml:"auto_port_attempts"` // ports to try in total, default 10
+
+	// Request logging; access_log defaults to true
+	AccessLog         *bool    `toml:"access_log"`
+	RedactQueryParams []string `toml:"redact_query_params"` // query parameters logged as ***
+
+	// Response body templates for unmatched paths and methods (optional)
+	NotFoundResponse         string `toml:"not_found_response"`
+	MethodNotAllowedResponse string `toml:"method_not_allowed_response"`
+
+	// Record endpoint requests for GET /__admin/requests (spy mode)
+	CaptureRequests bool `toml:"capture_requests"`
+	CaptureLimit    int  `toml:"capture_limit"` // requests kept per endpoint, default 100
+
+	// Environment variables response templates may read with {{env.NAME}};
+	// exact names, or prefixes ending in "*"
+	TemplateEnv []string `toml:"template_env"`
+
+	// Connection handling, e.g. for load tests; max_connections 0 means unlimited
+	DisableKeepAlive bool `toml:"disable_keepalive"`
+	MaxConnections   int  `toml:"max_connections"`
+
+	// Seed for weighted responses, latency and faults, making runs
+	// reproducible; unset uses unseeded randomness
+	RandomSeed *int64 `toml:"random_seed"`
+
+	// Serve POST requests carrying X-HTTP-Method-Override as the method it
+	// names, when that method is listed (default PUT, PATCH and DELETE)
+	MethodOverride        bool     `toml:"method_override"`
+	MethodOverrideMethods []string `toml:"method_override_methods"`
+
+	// Fail to load, instead of warning at startup, when a response uses a
+	// template token no variable or function defines
+	StrictTemplates bool `toml:"strict_templates"`
 }
 
 // EndpointConfig defines a REST endpoint
@@ -22,26 +300,565 @@ type EndpointConfig struct {
 	Path        string            `toml:"path"`
 	Method      string            `toml:"method"`
 	Status      int               `toml:"status"`
+	StatusFrom  string            `toml:"status_from"` // "query.NAME" or "header.NAME" to take the status from the request (optional)
 	Response    string            `toml:"response"`
+	ResponseRef string            `toml:"response_ref"` // Name of a top-level [[responses]] body (optional)
 	Headers     map[string]string `toml:"headers"`
-	Delay       int               `toml:"delay"` // milliseconds
+	Delay       int               `toml:"delay"`   // milliseconds
+	Latency     *LatencyConfig    `toml:"latency"` // Sampled latency distribution, added to delay (optional)
+	Fault       *FaultConfig      `toml:"fault"`   // Injected network failures (optional)
 	Description string            `toml:"description"`
+	ServerName  string            `toml:"server_name"` // TLS SNI server name to match (optional)
+	Host        string            `toml:"host"`        // Host header (without port) to match (optional)
+	Responses   []ResponseVariant `toml:"responses"`   // Alternative responses picked per request (optional)
+	Cookies     []CookieConfig    `toml:"cookies"`     // Set-Cookie headers to emit (optional)
+	// Method -> response body; the endpoint serves each listed method (optional)
+	MethodResponses map[string]string `toml:"method_responses"`
+	// Raw response bytes, base64-encoded; written as-is without templating (optional)
+	ResponseBase64 string `toml:"response_base64"`
+	// Syntax of the response bodies: "json" (default) or "json5", which is
+	// converted to standard JSON at load time (optional)
+	ResponseFormat string `toml:"response_format"`
+	// Responses chosen by the request's Accept-Language header (optional)
+	Localized []LocalizedResponse `toml:"localized"`
+	// Retry-After sent with 429, 503 and 3xx responses (optional)
+	RetryAfter RetryAfter `toml:"retry_after"`
+	// Extra delays for requests matching a rule; the first match applies (optional)
+	DelayWhen []DelayRule `toml:"delay_when"`
+	// Send an ETag computed from the response body and answer matching
+	// If-None-Match requests with 304 Not Modified (optional)
+	ETag bool `toml:"etag"`
+	// Cap on requests served at once (optional)
+	Concurrency *ConcurrencyConfig `toml:"concurrency"`
+	// Write the response in this many flushed chunks (optional)
+	StreamChunks int `toml:"stream_chunks"`
+	// Milliseconds between streamed chunks; default 100 (optional)
+	StreamInterval int `toml:"stream_interval"`
+	// Milliseconds the endpoint may take before answering 504 Gateway
+	// Timeout instead; delays count towards it (optional)
+	TimeoutMS int `toml:"timeout_ms"`
+	// Protocol the response is encoded for: "grpc-web" frames the response as
+	// a unary gRPC-Web message followed by trailers (optional)
+	Type string `toml:"type"`
+	// gRPC status code (0-16) and message sent in the gRPC-Web trailers
+	GRPCStatus  int    `toml:"grpc_status"`
+	GRPCMessage string `toml:"grpc_message"`
+	// Headers requests must carry; others are rejected before any delay (optional)
+	RequiredHeaders []RequiredHeader `toml:"required_headers"`
+	// Status (default 400) and body template for rejected requests; without a
+	// body the error envelope is used (optional)
+	RequiredHeadersStatus   int    `toml:"required_headers_status"`
+	RequiredHeadersResponse string `toml:"required_headers_response"`
+	// Ordered response headers, added after headers; names may repeat (optional)
+	HeaderList []HeaderConfig `toml:"header"`
+	// Set to false to keep the endpoint configured but unregistered (default true)
+	Enabled *bool `toml:"enabled"`
+	// Serve response byte for byte: no templating, fragments or JSON checks (optional)
+	RawResponse bool `toml:"raw_response"`
+	// Requests served before switching to the quota-exceeded response (optional)
+	Quota *QuotaConfig `toml:"quota"`
+	// Body template served, instead of rejecting, to requests without the
+	// required headers (optional)
+	AnonymousResponse string `toml:"anonymous_response"`
+	// Grow the rendered body to this many bytes, filling {{padding}} or
+	// appending spaces (optional)
+	PadToBytes int `toml:"pad_to_bytes"`
+	// Set to false to serve bodies and header values as written, without
+	// substituting template tokens or reading the request body (default true)
+	Template *bool `toml:"template"`
+	// Files holding alternative response bodies, one picked at random per
+	// request; read into responses by the config loader (optional)
+	ResponseFiles []string `toml:"response_files"`
+	// HTTP request sent in the background after responding, for
+	// asynchronous APIs; status defaults to 202 (optional)
+	Callback *CallbackConfig `toml:"callback"`
+	// Query parameter -> value the request must carry to be routed here; an
+	// empty value only requires the parameter to be present (optional)
+	MatchQuery map[string]string `toml:"match_query"`
+	// Real upstream the requests are forwarded to instead of serving a
+	// response, with a fallback for when it is down (optional)
+	Proxy *ProxyConfig `toml:"proxy"`
+}
+
+// HeaderConfig is one response header from a [[endpoints.header]] table
+type HeaderConfig struct {
+	Name  string `toml:"name"`
+	Value string `toml:"value"`
+}
+
+// RequiredHeader is a request header an endpoint insists on
+type RequiredHeader struct {
+	Name  string `toml:"name"`
+	Value string `toml:"value"` // exact value required; empty accepts any non-empty value
+}
+
+// GetRequiredHeadersStatus returns the status for rejected requests with the
+// 400 default
+func (e *EndpointConfig) GetRequiredHeadersStatus() int {
+	if e.RequiredHeadersStatus == 0 {
+		return http.StatusBadRequest
+	}
+	return e.RequiredHeadersStatus
+}
+
+// TypeGRPCWeb is the endpoint type for gRPC-Web responses
+const TypeGRPCWeb = "grpc-web"
+
+// IsGRPCWeb reports whether the endpoint answers with gRPC-Web framing
+func (e *EndpointConfig) IsGRPCWeb() bool {
+	return e.Type == TypeGRPCWeb
+}
+
+// ConfiguredContentType returns the Content-Type set by headers or, failing
+// that, the first one in the header list; empty when neither sets one
+func (e *EndpointConfig) ConfiguredContentType() string {
+	for key, value := range e.Headers {
+		if strings.EqualFold(key, "Content-Type") {
+			return value
+		}
+	}
+	for _, header := range e.HeaderList {
+		if strings.EqualFold(header.Name, "Content-Type") {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// RetryAfter is a Retry-After header value: a number of seconds or an
+// HTTP-date. In TOML it is an integer (retry_after = 120) or a string
+// (retry_after = "Wed, 21 Oct 2026 07:28:00 GMT").
+type RetryAfter string
+
+// UnmarshalTOML accepts both the integer and the string form
+func (r *RetryAfter) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case int64:
+		*r = RetryAfter(strconv.FormatInt(v, 10))
+		return nil
+	case string:
+		*r = RetryAfter(v)
+		return nil
+	}
+	return fmt.Errorf("retry_after must be an integer or a string, got %T", data)
+}
+
+// validate checks that r is a non-negative number of seconds or an HTTP-date
+func (r RetryAfter) validate() error {
+	if _, err := strconv.ParseUint(string(r), 10, 32); err == nil {
+		return nil
+	}
+	if _, err := http.ParseTime(string(r)); err == nil {
+		return nil
+	}
+	return fmt.Errorf("retry_after %q must be a number of seconds or an HTTP-date", string(r))
+}
+
+// LatencyConfig describes a response latency distribution by its
+// percentiles, in milliseconds. P95 and P99 are optional; without them every
+// request waits P50.
+type LatencyConfig struct {
+	P50 int `toml:"p50"`
+	P95 int `toml:"p95"`
+	P99 int `toml:"p99"`
+}
+
+// DelayRule delays requests that match all of its conditions. Values are
+// compared exactly; body keys are dotted paths into a JSON or form body,
+// like {{body.FIELD}}.
+type DelayRule struct {
+	Query   map[string]string `toml:"query"`   // query parameter -> value
+	Headers map[string]string `toml:"headers"` // header -> value
+	Body    map[string]string `toml:"body"`    // body field path -> value
+	Delay   int               `toml:"delay"`   // milliseconds, added to delay and latency
+}
+
+// FaultConfig injects network failures into an endpoint's responses
+type FaultConfig struct {
+	// Fraction of requests (0-1) whose connection is closed without a response
+	DropProbability float64 `toml:"drop_probability"`
+}
+
+// CallbackConfig is a request an endpoint sends after it responds, the way
+// an asynchronous API reports completion. URL, body and header values support
+// the same template variables as the response body, so the target can come
+// from the request, e.g. url = "{{body.callback_url}}".
+type CallbackConfig struct {
+	URL     string            `toml:"url"`
+	Method  string            `toml:"method"`   // default POST
+	Body    string            `toml:"body"`     // JSON unless headers set another Content-Type
+	DelayMS int               `toml:"delay_ms"` // milliseconds to wait after responding
+	Headers map[string]string `toml:"headers"`
+}
+
+// GetMethod returns the uppercase callback method with the POST default
+func (c *CallbackConfig) GetMethod() string {
+	if c.Method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(c.Method)
+}
+
+// IsJSON reports whether the callback body is JSON: true unless headers set
+// a Content-Type that is not
+func (c *CallbackConfig) IsJSON() bool {
+	for name, value := range c.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			return strings.Contains(strings.ToLower(value), "json")
+		}
+	}
+	return true
+}
+
+func (c *CallbackConfig) validate(label string) []error {
+	var errs []error
+	if strings.TrimSpace(c.URL) == "" {
+		errs = append(errs, fmt.Errorf("%s: callback url is required", label))
+	}
+	if !validMethods[c.GetMethod()] {
+		errs = append(errs, fmt.Errorf("%s: callback method %q is not a valid HTTP method", label, c.Method))
+	}
+	if c.DelayMS < 0 {
+		errs = append(errs, fmt.Errorf("%s: callback delay_ms cannot be negative", label))
+	}
+	if c.IsJSON() && !validJSONTemplate(c.Body) {
+		errs = append(errs, fmt.Errorf("%s: callback body is not valid JSON", label))
+	}
+	return errs
+}
+
+// defaultProxyTimeout bounds connecting to an upstream and waiting for its
+// response headers when timeout_ms is not set
+const defaultProxyTimeout = 10 * time.Second
+
+// ProxyConfig forwards an endpoint's requests to a real upstream, answering
+// with a fallback when the upstream is unreachable or too slow. This lets the
+// mock stand in for the parts of a service not yet migrated.
+type ProxyConfig struct {
+	URL              string `toml:"url"`               // upstream base URL; the request path and query are appended
+	TimeoutMS        int    `toml:"timeout_ms"`        // milliseconds to connect and receive response headers; default 10000
+	FallbackStatus   int    `toml:"fallback_status"`   // status when the upstream fails; default 502
+	FallbackResponse string `toml:"fallback_response"` // body when the upstream fails; empty uses the error envelope
+}
+
+// GetTimeout returns the upstream timeout with the 10 second default
+func (p *ProxyConfig) GetTimeout() time.Duration {
+	if p.TimeoutMS == 0 {
+		return defaultProxyTimeout
+	}
+	return time.Duration(p.TimeoutMS) * time.Millisecond
+}
+
+// GetFallbackStatus returns the upstream failure status with the 502 default
+func (p *ProxyConfig) GetFallbackStatus() int {
+	if p.FallbackStatus == 0 {
+		return http.StatusBadGateway
+	}
+	return p.FallbackStatus
+}
+
+func (p *ProxyConfig) validate(label string) []error {
+	var errs []error
+	if target, err := url.Parse(p.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		errs = append(errs, fmt.Errorf("%s: proxy url %q must be an absolute http or https URL", label, p.URL))
+	}
+	if p.TimeoutMS < 0 {
+		errs = append(errs, fmt.Errorf("%s: proxy timeout_ms cannot be negative", label))
+	}
+	if p.FallbackStatus != 0 && (p.FallbackStatus < 100 || p.FallbackStatus > 599) {
+		errs = append(errs, fmt.Errorf("%s: proxy fallback_status %d outside range 100-599", label, p.FallbackStatus))
+	}
+	return errs
+}
+
+// proxyIgnoredFields lists the fields set on a proxied endpoint that only
+// shape mocked responses, so the upstream's response would silently ignore
+// them. Only headers and header apply, to proxied and fallback responses.
+func (e *EndpointConfig) proxyIgnoredFields() []string {
+	set := []struct {
+		name  string
+		isSet bool
+	}{
+		{"response", e.Response != ""},
+		{"response_ref", e.ResponseRef != ""},
+		{"responses", len(e.Responses) > 0},
+		{"method_responses", len(e.MethodResponses) > 0},
+		{"response_base64", e.ResponseBase64 != ""},
+		{"response_files", len(e.ResponseFiles) > 0},
+		{"response_format", e.ResponseFormat != ""},
+		{"raw_response", e.RawResponse},
+		{"localized", len(e.Localized) > 0},
+		{"status", e.Status != 0},
+		{"status_from", e.StatusFrom != ""},
+		{"cookies", len(e.Cookies) > 0},
+		{"delay", e.Delay != 0},
+		{"delay_when", len(e.DelayWhen) > 0},
+		{"latency", e.Latency != nil},
+		{"fault", e.Fault != nil},
+		{"concurrency", e.Concurrency != nil},
+		{"quota", e.Quota != nil},
+		{"required_headers", len(e.RequiredHeaders) > 0 || e.RequiredHeadersStatus != 0 || e.RequiredHeadersResponse != ""},
+		{"anonymous_response", e.AnonymousResponse != ""},
+		{"timeout_ms", e.TimeoutMS != 0},
+		{"stream_chunks", e.StreamChunks != 0 || e.StreamInterval != 0},
+		{"pad_to_bytes", e.PadToBytes != 0},
+		{"type", e.Type != ""},
+		{"grpc_status", e.GRPCStatus != 0 || e.GRPCMessage != ""},
+		{"callback", e.Callback != nil},
+		{"etag", e.ETag},
+		{"retry_after", e.RetryAfter != ""},
+	}
+
+	var fields []string
+	for _, field := range set {
+		if field.isSet {
+			fields = append(fields, field.name)
+		}
+	}
+	return fields
+}
+
+// Concurrency overflow behaviors
+const (
+	OverflowReject = "reject" // answer excess requests with 503
+	OverflowQueue  = "queue"  // wait for a free slot, up to QueueTimeout
+)
+
+// ConcurrencyConfig caps how many requests an endpoint serves at once,
+// simulating a backend with a limited worker pool
+type ConcurrencyConfig struct {
+	Max          int    `toml:"max"`
+	Overflow     string `toml:"overflow"`      // "reject" (default) or "queue"
+	QueueTimeout int    `toml:"queue_timeout"` // milliseconds a queued request waits before a 503; 0 waits indefinitely
+}
+
+// GetOverflow returns the overflow behavior with a default
+func (c *ConcurrencyConfig) GetOverflow() string {
+	if c.Overflow == "" {
+		return OverflowReject
+	}
+	return c.Overflow
+}
+
+// QuotaConfig switches an endpoint to a quota-exceeded response once it has
+// served Limit requests, optionally until a fixed window resets the count
+type QuotaConfig struct {
+	Limit    int    `toml:"limit"`
+	Status   int    `toml:"status"`    // status after the limit; default 429
+	Response string `toml:"response"`  // body after the limit; empty uses the error envelope
+	WindowMS int    `toml:"window_ms"` // milliseconds before the count resets; 0 never resets
+}
+
+// GetStatus returns the quota-exceeded status with the 429 default
+func (q *QuotaConfig) GetStatus() int {
+	if q.Status == 0 {
+		return http.StatusTooManyRequests
+	}
+	return q.Status
+}
+
+func (q *QuotaConfig) validate(label string) []error {
+	var errs []error
+	if q.Limit <= 0 {
+		errs = append(errs, fmt.Errorf("%s: quota limit must be positive", label))
+	}
+	if q.Status != 0 && (q.Status < 100 || q.Status > 599) {
+		errs = append(errs, fmt.Errorf("%s: quota status %d outside range 100-599", label, q.Status))
+	}
+	if q.WindowMS < 0 {
+		errs = append(errs, fmt.Errorf("%s: quota window_ms cannot be negative", label))
+	}
+	if !validJSONTemplate(q.Response) {
+		errs = append(errs, fmt.Errorf("%s: quota response is not valid JSON", label))
+	}
+	return errs
 }
 
+func (c *ConcurrencyConfig) validate(label string) []error {
+	var errs []error
+	if c.Max <= 0 {
+		errs = append(errs, fmt.Errorf("%s: concurrency max must be positive", label))
+	}
+	switch c.GetOverflow() {
+	case OverflowReject:
+		if c.QueueTimeout != 0 {
+			errs = append(errs, fmt.Errorf("%s: concurrency queue_timeout requires overflow = \"queue\"", label))
+		}
+	case OverflowQueue:
+		if c.QueueTimeout < 0 {
+			errs = append(errs, fmt.Errorf("%s: concurrency queue_timeout cannot be negative", label))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s: concurrency overflow %q must be \"reject\" or \"queue\"", label, c.Overflow))
+	}
+	return errs
+}
+
+// CookieConfig defines a cookie set on the response. The value supports
+// the same template variables as the response body.
+type CookieConfig struct {
+	Name     string `toml:"name"`
+	Value    string `toml:"value"`
+	Path     string `toml:"path"`
+	MaxAge   int    `toml:"max_age"` // seconds; negative deletes the cookie
+	HTTPOnly bool   `toml:"http_only"`
+	Secure   bool   `toml:"secure"`
+	SameSite string `toml:"same_site"` // "lax", "strict" or "none"
+}
+
+// GetSameSite converts the configured SameSite value to its http constant
+func (c *CookieConfig) GetSameSite() (http.SameSite, bool) {
+	switch strings.ToLower(c.SameSite) {
+	case "":
+		return http.SameSiteDefaultMode, true
+	case "lax":
+		return http.SameSiteLaxMode, true
+	case "strict":
+		return http.SameSiteStrictMode, true
+	case "none":
+		return http.SameSiteNoneMode, true
+	}
+	return http.SameSiteDefaultMode, false
+}
+
+// ResponseVariant is one of several responses an endpoint can return.
+// Unset status falls back to the endpoint status; headers are merged over
+// the endpoint headers.
+type ResponseVariant struct {
+	Status   int               `toml:"status"`
+	Response string            `toml:"response"`
+	Headers  map[string]string `toml:"headers"`
+	Weight   int               `toml:"weight"` // relative weight, defaults to 1
+}
+
+// LocalizedResponse is the response body for one language. Lang is a BCP 47
+// language tag such as "en", "en-GB" or "pt-BR".
+type LocalizedResponse struct {
+	Lang     string `toml:"lang"`
+	Response string `toml:"response"`
+}
+
+// languageTagPattern matches well-formed BCP 47 language tags: a 2-8 letter
+// primary language followed by 1-8 character alphanumeric subtags
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
 // GraphQLConfig defines GraphQL endpoint configuration
 type GraphQLConfig struct {
-	Enabled bool                `toml:"enabled"`
-	Path    string              `toml:"path"`
-	Types   []GraphQLType       `toml:"types"`
-	Queries []GraphQLQuery      `toml:"queries"`
+	Enabled   bool              `toml:"enabled"`
+	Path      string            `toml:"path"`
+	Types     []GraphQLType     `toml:"types"`
+	Scalars   []GraphQLScalar   `toml:"scalars"`
+	Queries   []GraphQLQuery    `toml:"queries"`
 	Mutations []GraphQLMutation `toml:"mutations"`
+
+	// Match the path regardless of letter case (a trailing slash is always allowed)
+	CaseInsensitivePath bool `toml:"case_insensitive_path"`
+
+	// Query limits; zero disables the check
+	MaxDepth      int `toml:"max_depth"`
+	MaxComplexity int `toml:"max_complexity"`
+	// HTTP status for over-limit queries: 200 (default, per spec) or 400
+	LimitStatus int `toml:"limit_status"`
+
+	// Respond 400 to queries that fail to parse or validate instead of 200
+	StrictStatus bool `toml:"strict_status"`
+
+	// Names of the root query and mutation objects, shown by introspection
+	// (default RootQuery and RootMutation)
+	QueryTypeName    string `toml:"query_type_name"`
+	MutationTypeName string `toml:"mutation_type_name"`
+
+	// Queries clients may send by hash alone, as Apollo persisted queries
+	Persisted []GraphQLPersistedQuery `toml:"persisted"`
+}
+
+// GraphQLPersistedQuery is a query clients send as the SHA-256 hash of its
+// text in the persistedQuery extension instead of the text itself
+type GraphQLPersistedQuery struct {
+	Hash   string `toml:"hash"` // hex SHA-256 of query; computed when empty
+	Query  string `toml:"query"`
+	Source string `toml:"-"` // Config file defining the query, set by the loader
+}
+
+// GetHash returns the lowercase hex hash identifying the query, computing
+// the SHA-256 of the query text when no hash is configured
+func (p *GraphQLPersistedQuery) GetHash() string {
+	if p.Hash == "" {
+		sum := sha256.Sum256([]byte(p.Query))
+		return hex.EncodeToString(sum[:])
+	}
+	return strings.ToLower(p.Hash)
+}
+
+// GetQueryTypeName returns the root query object name with the RootQuery default
+func (g *GraphQLConfig) GetQueryTypeName() string {
+	if g.QueryTypeName == "" {
+		return "RootQuery"
+	}
+	return g.QueryTypeName
+}
+
+// GetMutationTypeName returns the root mutation object name with the
+// RootMutation default
+func (g *GraphQLConfig) GetMutationTypeName() string {
+	if g.MutationTypeName == "" {
+		return "RootMutation"
+	}
+	return g.MutationTypeName
 }
 
 // GraphQLType represents a GraphQL type definition
 type GraphQLType struct {
-	Name        string              `toml:"name"`
-	Fields      map[string]string   `toml:"fields"`
-	Description string              `toml:"description"`
+	Name        string                  `toml:"name"`
+	Fields      map[string]GraphQLField `toml:"fields"`
+	Description string                  `toml:"description"`
+	Source      string                  `toml:"-"` // Config file defining the type, set by the loader
+}
+
+// GraphQLField is a field of a GraphQL type. In TOML it is either a type
+// string (id = "Int!") or a table with its own response
+// (posts = { type = "[Post]", response = '[...]' }), which is returned for
+// the field instead of the value in the parent's response.
+type GraphQLField struct {
+	Type     string `toml:"type"`
+	Response string `toml:"response"`
+}
+
+// UnmarshalTOML accepts both the string and the table form of a field
+func (f *GraphQLField) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		f.Type = v
+		return nil
+	case map[string]interface{}:
+		for key, value := range v {
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("field %s must be a string", key)
+			}
+			switch key {
+			case "type":
+				f.Type = str
+			case "response":
+				f.Response = str
+			default:
+				return fmt.Errorf("unknown field %q in GraphQL field definition", key)
+			}
+		}
+		if f.Type == "" {
+			return fmt.Errorf("GraphQL field definition is missing type")
+		}
+		return nil
+	}
+	return fmt.Errorf("GraphQL field must be a type string or a table, got %T", data)
+}
+
+// GraphQLScalar declares a custom scalar such as DateTime or JSON. Values
+// pass through unchanged.
+type GraphQLScalar struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+	Source      string `toml:"-"` // Config file defining the scalar, set by the loader
 }
 
 // GraphQLQuery represents a GraphQL query
@@ -51,6 +868,18 @@ type GraphQLQuery struct {
 	Args        map[string]string `toml:"args"`
 	Response    string            `toml:"response"`
 	Description string            `toml:"description"`
+	Source      string            `toml:"-"` // Config file defining the query, set by the loader
+	// Responses for specific argument values; the first matching case wins
+	// and Response answers the rest (optional)
+	Cases []GraphQLCase `toml:"cases"`
+}
+
+// GraphQLCase is one [[graphql.queries.cases]] entry: the response for
+// queries whose arguments equal all of Args. Values are compared as text, so
+// id = 1 matches both ID and Int arguments.
+type GraphQLCase struct {
+	Args     map[string]interface{} `toml:"args"`
+	Response string                 `toml:"response"`
 }
 
 // GraphQLMutation represents a GraphQL mutation
@@ -60,6 +889,7 @@ type GraphQLMutation struct {
 	Args        map[string]string `toml:"args"`
 	Response    string            `toml:"response"`
 	Description string            `toml:"description"`
+	Source      string            `toml:"-"` // Config file defining the mutation, set by the loader
 }
 
 // GetReadTimeout returns the read timeout as a duration
@@ -78,6 +908,22 @@ func (s *ServerConfig) GetWriteTimeout() time.Duration {
 	return time.Duration(s.WriteTimeout) * time.Second
 }
 
+// GetIdleTimeout returns the keep-alive idle timeout as a duration
+func (s *ServerConfig) GetIdleTimeout() time.Duration {
+	if s.IdleTimeout <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(s.IdleTimeout) * time.Second
+}
+
+// GetReadHeaderTimeout returns the header read timeout as a duration
+func (s *ServerConfig) GetReadHeaderTimeout() time.Duration {
+	if s.ReadHeaderTimeout <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(s.ReadHeaderTimeout) * time.Second
+}
+
 // GetPort returns the server port with a default
 func (s *ServerConfig) GetPort() int {
 	if s.Port <= 0 {
@@ -86,6 +932,46 @@ func (s *ServerConfig) GetPort() int {
 	return s.Port
 }
 
+// GetAutoPortAttempts returns how many ports auto_port tries, including the
+// configured one
+func (s *ServerConfig) GetAutoPortAttempts() int {
+	if s.AutoPortAttempts <= 0 {
+		return 10
+	}
+	return s.AutoPortAttempts
+}
+
+// GetCaptureLimit returns how many requests are captured per endpoint
+func (s *ServerConfig) GetCaptureLimit() int {
+	if s.CaptureLimit <= 0 {
+		return 100
+	}
+	return s.CaptureLimit
+}
+
+// GetMethodOverrideMethods returns the methods X-HTTP-Method-Override may
+// select, uppercased, defaulting to PUT, PATCH and DELETE
+func (s *ServerConfig) GetMethodOverrideMethods() []string {
+	if len(s.MethodOverrideMethods) == 0 {
+		return []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	methods := make([]string, len(s.MethodOverrideMethods))
+	for i, method := range s.MethodOverrideMethods {
+		methods[i] = strings.ToUpper(strings.TrimSpace(method))
+	}
+	return methods
+}
+
+// AccessLogEnabled reports whether requests are logged, defaulting to true
+func (s *ServerConfig) AccessLogEnabled() bool {
+	return s.AccessLog == nil || *s.AccessLog
+}
+
+// TLSEnabled reports whether both a TLS certificate and key are configured
+func (s *ServerConfig) TLSEnabled() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
 // GetHost returns the server host with a default
 func (s *ServerConfig) GetHost() string {
 	if s.Host == "" {
@@ -93,3 +979,534 @@ func (s *ServerConfig) GetHost() string {
 	}
 	return s.Host
 }
+
+// AnyMethod is the method of endpoints that answer every HTTP method not
+// configured explicitly on their path. "ANY" is accepted as an alias.
+const AnyMethod = "*"
+
+// validMethods lists the HTTP methods an endpoint may declare
+var validMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodPatch:   true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodConnect: true,
+	http.MethodTrace:   true,
+}
+
+// templateTokenPattern matches template variables such as {{body}} or {{query.id}}
+var templateTokenPattern = regexp.MustCompile(`\{\{[^}]*\}\}`)
+
+// jsonPathTokenPattern matches JSONPath tokens such as {{jsonpath:$.user.name}}
+var jsonPathTokenPattern = regexp.MustCompile(`\{\{jsonpath:([^}]*)\}\}`)
+
+// Validate checks every endpoint and returns all problems found joined into
+// a single error, or nil when the configuration is valid
+func (c *Config) Validate() error {
+	errs := c.Server.validate()
+	errs = append(errs, c.DefaultEndpoint.validate()...)
+	errs = append(errs, validateMethodAliases(c.MethodAliases)...)
+	errs = append(errs, c.Errors.validate()...)
+	errs = append(errs, c.Throttle.validate()...)
+	errs = append(errs, c.Health.validate(c.Endpoints)...)
+	for i := range c.Static {
+		errs = append(errs, c.Static[i].validate(i)...)
+	}
+	errs = append(errs, c.GraphQL.validate()...)
+	for i := range c.Endpoints {
+		errs = append(errs, c.Endpoints[i].Validate(i)...)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks a single endpoint. The index identifies the endpoint in
+// error messages.
+func (e *EndpointConfig) Validate(index int) []error {
+	var errs []error
+	label := fmt.Sprintf("endpoint[%d] %s %s", index, e.Method, e.Path)
+
+	if strings.TrimSpace(e.Path) == "" {
+		errs = append(errs, fmt.Errorf("%s: path cannot be empty", label))
+	}
+	if strings.Contains(e.Path, "?") {
+		errs = append(errs, fmt.Errorf("%s: path cannot contain a query string; use {{query.NAME}} to read query parameters", label))
+	}
+	if e.Method != "" && !validMethods[strings.ToUpper(e.Method)] && !e.IsAnyMethod() {
+		errs = append(errs, fmt.Errorf("%s: unrecognized HTTP method %q", label, e.Method))
+	}
+	if len(e.MethodResponses) > 0 && e.Method != "" {
+		errs = append(errs, fmt.Errorf("%s: method must be empty when method_responses is set", label))
+	}
+	for _, method := range sortedKeys(e.MethodResponses) {
+		if !validMethods[strings.ToUpper(method)] {
+			errs = append(errs, fmt.Errorf("%s: unrecognized HTTP method %q in method_responses", label, method))
+		}
+		if e.declaresJSON() && !validJSONTemplate(e.MethodResponses[method]) {
+			errs = append(errs, fmt.Errorf("%s: method_responses.%s response is not valid JSON", label, method))
+		}
+	}
+	if e.Status != 0 && (e.Status < 100 || e.Status > 599) {
+		errs = append(errs, fmt.Errorf("%s: status %d outside range 100-599", label, e.Status))
+	}
+	if e.StatusFrom != "" && !strings.HasPrefix(e.StatusFrom, "query.") && !strings.HasPrefix(e.StatusFrom, "header.") {
+		errs = append(errs, fmt.Errorf("%s: status_from %q must start with query. or header.", label, e.StatusFrom))
+	}
+	if e.ResponseBase64 != "" {
+		if _, err := base64.StdEncoding.DecodeString(e.ResponseBase64); err != nil {
+			errs = append(errs, fmt.Errorf("%s: response_base64 is not valid base64: %w", label, err))
+		}
+		if e.Response != "" || len(e.Responses) > 0 || len(e.MethodResponses) > 0 {
+			errs = append(errs, fmt.Errorf("%s: response_base64 cannot be combined with response, responses or method_responses", label))
+		}
+	} else if e.declaresJSON() && !e.RawResponse && !validJSONTemplate(e.Response) {
+		errs = append(errs, fmt.Errorf("%s: response is not valid JSON", label))
+	}
+	if e.RawResponse && (e.ResponseBase64 != "" || len(e.Responses) > 0 || len(e.MethodResponses) > 0 || len(e.Localized) > 0 || e.ResponseFormat != "") {
+		errs = append(errs, fmt.Errorf("%s: raw_response cannot be combined with response_base64, responses, method_responses, localized or response_format", label))
+	}
+	if len(e.ResponseFiles) > 0 && e.responseFilesConflict() {
+		errs = append(errs, fmt.Errorf("%s: %w", label, errResponseFilesConflict))
+	}
+	if len(e.Localized) > 0 && (len(e.Responses) > 0 || len(e.MethodResponses) > 0 || e.ResponseBase64 != "") {
+		errs = append(errs, fmt.Errorf("%s: localized cannot be combined with responses, method_responses or response_base64", label))
+	}
+	seenLangs := make(map[string]bool, len(e.Localized))
+	for i, localized := range e.Localized {
+		if !languageTagPattern.MatchString(localized.Lang) {
+			errs = append(errs, fmt.Errorf("%s: localized[%d] lang %q is not a valid language tag", label, i, localized.Lang))
+		} else if lang := strings.ToLower(localized.Lang); seenLangs[lang] {
+			errs = append(errs, fmt.Errorf("%s: localized[%d] duplicate lang %q", label, i, localized.Lang))
+		} else {
+			seenLangs[lang] = true
+		}
+		if e.declaresJSON() && !validJSONTemplate(localized.Response) {
+			errs = append(errs, fmt.Errorf("%s: localized[%d] response is not valid JSON", label, i))
+		}
+		for _, err := range jsonPathErrors(localized.Response) {
+			errs = append(errs, fmt.Errorf("%s: localized[%d]: %w", label, i, err))
+		}
+	}
+	if e.ResponseFormat != "" && e.ResponseFormat != "json" && e.ResponseFormat != "json5" {
+		errs = append(errs, fmt.Errorf("%s: unrecognized response_format %q (expected json or json5)", label, e.ResponseFormat))
+	}
+	if e.Host != "" && !validHost(e.Host) {
+		errs = append(errs, fmt.Errorf("%s: host %q must be a host name without scheme, port or path", label, e.Host))
+	}
+	if e.RetryAfter != "" {
+		if err := e.RetryAfter.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", label, err))
+		}
+	}
+	if e.Latency != nil {
+		errs = append(errs, e.Latency.validate(label)...)
+	}
+	for i, rule := range e.DelayWhen {
+		if len(rule.Query) == 0 && len(rule.Headers) == 0 && len(rule.Body) == 0 {
+			errs = append(errs, fmt.Errorf("%s: delay_when[%d] needs at least one query, headers or body condition", label, i))
+		}
+		if rule.Delay <= 0 {
+			errs = append(errs, fmt.Errorf("%s: delay_when[%d] delay must be positive", label, i))
+		}
+	}
+	if e.Fault != nil && (e.Fault.DropProbability < 0 || e.Fault.DropProbability > 1) {
+		errs = append(errs, fmt.Errorf("%s: fault drop_probability %v outside range 0-1", label, e.Fault.DropProbability))
+	}
+	if e.Quota != nil {
+		errs = append(errs, e.Quota.validate(label)...)
+	}
+	if e.Concurrency != nil {
+		errs = append(errs, e.Concurrency.validate(label)...)
+	}
+	if e.Callback != nil {
+		errs = append(errs, e.Callback.validate(label)...)
+	}
+	if e.Proxy != nil {
+		errs = append(errs, e.Proxy.validate(label)...)
+		if fields := e.proxyIgnoredFields(); len(fields) > 0 {
+			errs = append(errs, fmt.Errorf("%s: proxy cannot be combined with %s; the upstream answers instead, use fallback_response for outages", label, strings.Join(fields, ", ")))
+		}
+		if (e.ConfiguredContentType() == "" || e.declaresJSON()) && !validJSONTemplate(e.Proxy.FallbackResponse) {
+			errs = append(errs, fmt.Errorf("%s: proxy fallback_response is not valid JSON", label))
+		}
+	}
+	for name := range e.MatchQuery {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("%s: match_query parameter name cannot be empty", label))
+		}
+	}
+	if e.TimeoutMS < 0 {
+		errs = append(errs, fmt.Errorf("%s: timeout_ms cannot be negative", label))
+	}
+	if e.StreamChunks < 0 {
+		errs = append(errs, fmt.Errorf("%s: stream_chunks cannot be negative", label))
+	}
+	if e.StreamInterval < 0 {
+		errs = append(errs, fmt.Errorf("%s: stream_interval cannot be negative", label))
+	}
+	if e.StreamInterval > 0 && e.StreamChunks == 0 {
+		errs = append(errs, fmt.Errorf("%s: stream_interval requires stream_chunks", label))
+	}
+	if e.Type != "" && !e.IsGRPCWeb() {
+		errs = append(errs, fmt.Errorf("%s: unrecognized type %q (expected %s)", label, e.Type, TypeGRPCWeb))
+	}
+	if (e.GRPCStatus != 0 || e.GRPCMessage != "") && !e.IsGRPCWeb() {
+		errs = append(errs, fmt.Errorf("%s: grpc_status and grpc_message require type = %q", label, TypeGRPCWeb))
+	}
+	if e.GRPCStatus < 0 || e.GRPCStatus > 16 {
+		errs = append(errs, fmt.Errorf("%s: grpc_status %d outside range 0-16", label, e.GRPCStatus))
+	}
+	for i, header := range e.RequiredHeaders {
+		if strings.TrimSpace(header.Name) == "" {
+			errs = append(errs, fmt.Errorf("%s: required_headers[%d] name cannot be empty", label, i))
+		}
+	}
+	if len(e.RequiredHeaders) == 0 && (e.RequiredHeadersStatus != 0 || e.RequiredHeadersResponse != "") {
+		errs = append(errs, fmt.Errorf("%s: required_headers_status and required_headers_response require required_headers", label))
+	}
+	if e.RequiredHeadersStatus != 0 && (e.RequiredHeadersStatus < 400 || e.RequiredHeadersStatus > 599) {
+		errs = append(errs, fmt.Errorf("%s: required_headers_status %d outside range 400-599", label, e.RequiredHeadersStatus))
+	}
+	if !validJSONTemplate(e.RequiredHeadersResponse) {
+		errs = append(errs, fmt.Errorf("%s: required_headers_response is not valid JSON", label))
+	}
+	if e.PadToBytes < 0 {
+		errs = append(errs, fmt.Errorf("%s: pad_to_bytes cannot be negative", label))
+	}
+	if e.PadToBytes > 0 && (e.ResponseBase64 != "" || e.RawResponse) {
+		errs = append(errs, fmt.Errorf("%s: pad_to_bytes cannot be combined with response_base64 or raw_response", label))
+	}
+	if e.AnonymousResponse != "" {
+		if len(e.RequiredHeaders) == 0 {
+			errs = append(errs, fmt.Errorf("%s: anonymous_response requires required_headers", label))
+		}
+		if e.RequiredHeadersStatus != 0 || e.RequiredHeadersResponse != "" {
+			errs = append(errs, fmt.Errorf("%s: anonymous_response cannot be combined with required_headers_status or required_headers_response", label))
+		}
+		if e.ResponseBase64 != "" || e.RawResponse {
+			errs = append(errs, fmt.Errorf("%s: anonymous_response cannot be combined with response_base64 or raw_response", label))
+		}
+		if !validJSONTemplate(e.AnonymousResponse) {
+			errs = append(errs, fmt.Errorf("%s: anonymous_response is not valid JSON", label))
+		}
+	}
+	for i, header := range e.HeaderList {
+		if strings.TrimSpace(header.Name) == "" {
+			errs = append(errs, fmt.Errorf("%s: header[%d] name cannot be empty", label, i))
+		}
+	}
+	for i, cookie := range e.Cookies {
+		if cookie.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: cookies[%d] name cannot be empty", label, i))
+		}
+		if _, ok := cookie.GetSameSite(); !ok {
+			errs = append(errs, fmt.Errorf("%s: cookies[%d] unrecognized same_site %q", label, i, cookie.SameSite))
+		}
+	}
+	if !e.RawResponse {
+		for _, err := range jsonPathErrors(e.Response) {
+			errs = append(errs, fmt.Errorf("%s: response: %w", label, err))
+		}
+	}
+	for _, method := range sortedKeys(e.MethodResponses) {
+		for _, err := range jsonPathErrors(e.MethodResponses[method]) {
+			errs = append(errs, fmt.Errorf("%s: method_responses.%s: %w", label, method, err))
+		}
+	}
+	for i, variant := range e.Responses {
+		for _, err := range jsonPathErrors(variant.Response) {
+			errs = append(errs, fmt.Errorf("%s: responses[%d]: %w", label, i, err))
+		}
+		if variant.Weight < 0 {
+			errs = append(errs, fmt.Errorf("%s: responses[%d] weight %d must be positive", label, i, variant.Weight))
+		}
+		if variant.Status != 0 && (variant.Status < 100 || variant.Status > 599) {
+			errs = append(errs, fmt.Errorf("%s: responses[%d] status %d outside range 100-599", label, i, variant.Status))
+		}
+		if e.declaresJSON() && !validJSONTemplate(variant.Response) {
+			errs = append(errs, fmt.Errorf("%s: responses[%d] response is not valid JSON", label, i))
+		}
+	}
+
+	return errs
+}
+
+// validateMethodAliases checks that every alias maps to a different, concrete
+// method
+func validateMethodAliases(aliases map[string]string) []error {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, alias := range names {
+		target := strings.TrimSpace(aliases[alias])
+		switch {
+		case strings.TrimSpace(alias) == "" || strings.ContainsAny(alias, " \t"):
+			errs = append(errs, fmt.Errorf("method_aliases: invalid method %q", alias))
+		case target == "":
+			errs = append(errs, fmt.Errorf("method_aliases: %s must map to a method", alias))
+		case target == AnyMethod || strings.EqualFold(target, "ANY"):
+			errs = append(errs, fmt.Errorf("method_aliases: %s cannot map to the wildcard method", alias))
+		case strings.EqualFold(alias, target):
+			errs = append(errs, fmt.Errorf("method_aliases: %s maps to itself", alias))
+		}
+	}
+	return errs
+}
+
+// validate checks the server settings that cannot be checked by type alone
+func (s *ServerConfig) validate() []error {
+	var errs []error
+	for _, addr := range s.Listen {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, fmt.Errorf("server: listen address %q: %w", addr, err))
+		}
+	}
+	if len(s.Listen) > 0 && s.SocketPath != "" {
+		errs = append(errs, errors.New("server: listen cannot be combined with socket_path"))
+	}
+	if s.MaxConnections < 0 {
+		errs = append(errs, fmt.Errorf("server: max_connections %d must not be negative", s.MaxConnections))
+	}
+	if len(s.MethodOverrideMethods) > 0 && !s.MethodOverride {
+		errs = append(errs, errors.New("server: method_override_methods requires method_override"))
+	}
+	for _, method := range s.MethodOverrideMethods {
+		method = strings.TrimSpace(method)
+		switch {
+		case method == "" || strings.ContainsAny(method, " \t"):
+			errs = append(errs, fmt.Errorf("server: method_override_methods: invalid method %q", method))
+		case method == AnyMethod || strings.EqualFold(method, "ANY"):
+			errs = append(errs, errors.New("server: method_override_methods cannot include the wildcard method"))
+		}
+	}
+	return errs
+}
+
+// validate checks that percentiles are positive and non-decreasing
+func (l *LatencyConfig) validate(label string) []error {
+	var errs []error
+	if l.P50 <= 0 {
+		errs = append(errs, fmt.Errorf("%s: latency p50 must be positive", label))
+	}
+	if l.P95 != 0 && l.P95 < l.P50 {
+		errs = append(errs, fmt.Errorf("%s: latency p95 %d is below p50 %d", label, l.P95, l.P50))
+	}
+	if l.P99 != 0 && l.P99 < max(l.P95, l.P50) {
+		errs = append(errs, fmt.Errorf("%s: latency p99 %d is below p95/p50", label, l.P99))
+	}
+	return errs
+}
+
+// IsEnabled reports whether the endpoint is served, defaulting to true
+func (e *EndpointConfig) IsEnabled() bool {
+	return e.Enabled == nil || *e.Enabled
+}
+
+// IsTemplated reports whether template tokens are substituted, defaulting to
+// true
+func (e *EndpointConfig) IsTemplated() bool {
+	return e.Template == nil || *e.Template
+}
+
+// NeedsDynamicHandler reports whether serving the endpoint takes more than
+// writing a precomputed response: it waits, limits requests, picks among
+// responses, reacts to the request or renders templated values. Bench mode
+// precomputes the responses of every other endpoint.
+func (e *EndpointConfig) NeedsDynamicHandler() bool {
+	return e.Delay > 0 ||
+		e.Latency != nil ||
+		e.Fault != nil ||
+		e.Concurrency != nil ||
+		e.StreamChunks > 0 ||
+		e.StatusFrom != "" ||
+		len(e.Responses) > 0 ||
+		len(e.Localized) > 0 ||
+		len(e.DelayWhen) > 0 ||
+		len(e.Cookies) > 0 ||
+		len(e.RequiredHeaders) > 0 ||
+		e.Quota != nil ||
+		e.PadToBytes > 0 ||
+		e.Callback != nil ||
+		e.Proxy != nil ||
+		e.IsGRPCWeb() ||
+		e.hasTemplatedValues()
+}
+
+// hasTemplatedValues reports whether the response body or a header value
+// contains template tokens that are substituted
+func (e *EndpointConfig) hasTemplatedValues() bool {
+	if !e.IsTemplated() {
+		return false
+	}
+	if strings.Contains(e.Response, "{{") {
+		return true
+	}
+	for _, value := range e.Headers {
+		if strings.Contains(value, "{{") {
+			return true
+		}
+	}
+	for _, header := range e.HeaderList {
+		if strings.Contains(header.Value, "{{") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAnyMethod reports whether the endpoint answers any HTTP method
+func (e *EndpointConfig) IsAnyMethod() bool {
+	return e.Method == AnyMethod || strings.EqualFold(e.Method, "ANY")
+}
+
+// RouteKey identifies the request an endpoint answers. Two endpoints with the
+// same key would shadow each other.
+func (e *EndpointConfig) RouteKey() string {
+	method := strings.ToUpper(e.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if e.IsAnyMethod() {
+		method = AnyMethod
+	}
+	key := method + " " + e.Path
+	if e.Host != "" {
+		key += " (host: " + strings.ToLower(e.Host) + ")"
+	}
+	if e.ServerName != "" {
+		key += " (sni: " + strings.ToLower(e.ServerName) + ")"
+	}
+	if len(e.MatchQuery) > 0 {
+		query := url.Values{}
+		for name, value := range e.MatchQuery {
+			query.Set(name, value)
+		}
+		key += " (query: " + query.Encode() + ")"
+	}
+	return key
+}
+
+// errResponseFilesConflict reports response_files set alongside another
+// source of the response body
+var errResponseFilesConflict = errors.New("response_files cannot be combined with response, response_ref, responses, method_responses, response_base64, raw_response, localized or response_format")
+
+// responseFilesConflict reports whether a field that response_files cannot
+// be combined with is set
+func (e *EndpointConfig) responseFilesConflict() bool {
+	return e.Response != "" || e.ResponseRef != "" || len(e.Responses) > 0 || len(e.MethodResponses) > 0 ||
+		e.ResponseBase64 != "" || e.RawResponse || len(e.Localized) > 0 || e.ResponseFormat != ""
+}
+
+// LoadResponseFiles reads ResponseFiles into equally weighted response
+// variants, so each request is answered by one file picked at random, and
+// clears ResponseFiles so they are read only once. Relative paths are
+// relative to the working directory.
+func (e *EndpointConfig) LoadResponseFiles() error {
+	if len(e.ResponseFiles) == 0 {
+		return nil
+	}
+	if e.responseFilesConflict() {
+		return errResponseFilesConflict
+	}
+
+	var errs []error
+	variants := make([]ResponseVariant, 0, len(e.ResponseFiles))
+	for _, file := range e.ResponseFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("response_files: %w", err))
+			continue
+		}
+		variants = append(variants, ResponseVariant{Response: string(data)})
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	e.Responses = variants
+	e.ResponseFiles = nil
+	return nil
+}
+
+// RouteKeys returns the route key of every method the endpoint serves: one
+// per method_responses entry, or just RouteKey
+func (e *EndpointConfig) RouteKeys() []string {
+	expanded := e.ExpandMethods()
+	keys := make([]string, len(expanded))
+	for i := range expanded {
+		keys[i] = expanded[i].RouteKey()
+	}
+	return keys
+}
+
+// ExpandMethods returns one endpoint per entry in MethodResponses, each with
+// its method and response set. Endpoints without MethodResponses are
+// returned unchanged.
+func (e *EndpointConfig) ExpandMethods() []EndpointConfig {
+	if len(e.MethodResponses) == 0 {
+		return []EndpointConfig{*e}
+	}
+
+	expanded := make([]EndpointConfig, 0, len(e.MethodResponses))
+	for _, method := range sortedKeys(e.MethodResponses) {
+		endpoint := *e
+		endpoint.Method = strings.ToUpper(method)
+		endpoint.Response = e.MethodResponses[method]
+		endpoint.MethodResponses = nil
+		expanded = append(expanded, endpoint)
+	}
+	return expanded
+}
+
+// validHost reports whether host is a bare host name or IP address, as
+// matched against the request's Host header with the port removed
+func validHost(host string) bool {
+	if strings.ContainsAny(host, "/?#@ \t") {
+		return false
+	}
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return false
+	}
+	return !strings.Contains(host, ":") || net.ParseIP(host) != nil
+}
+
+// sortedKeys returns map keys in sorted order for deterministic iteration
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validJSONTemplate reports whether a response is empty or valid JSON once
+// template tokens are swapped for a JSON literal
+func validJSONTemplate(response string) bool {
+	if strings.TrimSpace(response) == "" {
+		return true
+	}
+	candidate := templateTokenPattern.ReplaceAllString(response, "0")
+	return json.Valid([]byte(candidate))
+}
+
+// jsonPathErrors compiles every {{jsonpath:...}} token in a response
+func jsonPathErrors(response string) []error {
+	var errs []error
+	for _, match := range jsonPathTokenPattern.FindAllStringSubmatch(response, -1) {
+		if _, err := jsonpath.Compile(match[1]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// declaresJSON reports whether the endpoint explicitly sets a JSON Content-Type
+func (e *EndpointConfig) declaresJSON() bool {
+	return strings.Contains(strings.ToLower(e.ConfiguredContentType()), "json")
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -29,6 +31,54 @@ func TestServerConfig_GetReadTimeout(t *testing.T) {
 	}
 }
 
+func TestServerConfig_GetIdleTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  int
+		expected time.Duration
+	}{
+		{"default zero", 0, 60 * time.Second},
+		{"negative", -1, 60 * time.Second},
+		{"custom value", 120, 120 * time.Second},
+		{"small value", 2, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ServerConfig{IdleTimeout: tt.timeout}
+			got := cfg.GetIdleTimeout()
+
+			if got != tt.expected {
+				t.Errorf("GetIdleTimeout() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestServerConfig_GetReadHeaderTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  int
+		expected time.Duration
+	}{
+		{"default zero", 0, 5 * time.Second},
+		{"negative", -3, 5 * time.Second},
+		{"custom value", 10, 10 * time.Second},
+		{"small value", 1, 1 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ServerConfig{ReadHeaderTimeout: tt.timeout}
+			got := cfg.GetReadHeaderTimeout()
+
+			if got != tt.expected {
+				t.Errorf("GetReadHeaderTimeout() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestServerConfig_GetWriteTimeout(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -101,6 +151,31 @@ func TestServerConfig_GetHost(t *testing.T) {
 	}
 }
 
+func TestServerConfig_TLSEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		expected bool
+	}{
+		{"both set", "cert.pem", "key.pem", true},
+		{"cert only", "cert.pem", "", false},
+		{"key only", "", "key.pem", false},
+		{"neither", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ServerConfig{TLSCertFile: tt.certFile, TLSKeyFile: tt.keyFile}
+			got := cfg.TLSEnabled()
+
+			if got != tt.expected {
+				t.Errorf("TLSEnabled() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestEndpointConfig(t *testing.T) {
 	endpoint := EndpointConfig{
 		Path:        "/api/users",
@@ -140,9 +215,9 @@ func TestGraphQLConfig(t *testing.T) {
 		Types: []GraphQLType{
 			{
 				Name: "User",
-				Fields: map[string]string{
-					"id":   "Int!",
-					"name": "String!",
+				Fields: map[string]GraphQLField{
+					"id":   {Type: "Int!"},
+					"name": {Type: "String!"},
 				},
 				Description: "A user type",
 			},
@@ -219,3 +294,527 @@ func TestConfig_FullStructure(t *testing.T) {
 		t.Error("Expected GraphQL to be enabled")
 	}
 }
+
+func TestConfig_Validate_Valid(t *testing.T) {
+	cfg := Config{
+		Endpoints: []EndpointConfig{
+			{Path: "/api/users", Method: "GET", Status: 200, Response: `{"users": []}`, Headers: map[string]string{"Content-Type": "application/json"}},
+			{Path: "/api/users", Method: "post", Status: 201, Response: `{"received": {{body}}, "path": "{{path}}"}`, Headers: map[string]string{"content-type": "application/json"}},
+			{Path: "/api/users/1", Method: "DELETE", Status: 204, Response: "", Headers: map[string]string{"Content-Type": "application/json"}},
+			{Path: "/text", Response: "not json", Headers: map[string]string{"Content-Type": "text/plain"}},
+			{Path: "/logo.png", ResponseBase64: "iVBORw0KGgo=", Headers: map[string]string{"Content-Type": "image/png"}},
+			{Path: "/busy", Status: 503, RetryAfter: "Wed, 21 Oct 2026 07:28:00 GMT"},
+			{Path: "/limited", Status: 429, RetryAfter: "30"},
+			{Path: "/api", Host: "users.local"},
+			{Path: "/api", Host: "::1"},
+			{Path: "/any", Method: "*"},
+			{Path: "/any", Method: "any"},
+		},
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid config, got error: %v", err)
+	}
+}
+
+func TestConfig_Validate_Failures(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint EndpointConfig
+		want     string
+	}{
+		{"empty path", EndpointConfig{Method: "GET", Response: "{}"}, "path cannot be empty"},
+		{"unknown method", EndpointConfig{Path: "/x", Method: "FETCH", Response: "{}"}, `unrecognized HTTP method "FETCH"`},
+		{"status too low", EndpointConfig{Path: "/x", Status: 99, Response: "{}"}, "status 99 outside range"},
+		{"status too high", EndpointConfig{Path: "/x", Status: 600, Response: "{}"}, "status 600 outside range"},
+		{"malformed JSON", EndpointConfig{Path: "/x", Response: `{"broken": `, Headers: map[string]string{"Content-Type": "application/json"}}, "response is not valid JSON"},
+		{"unnamed cookie", EndpointConfig{Path: "/x", Cookies: []CookieConfig{{Value: "v"}}}, "cookies[0] name cannot be empty"},
+		{"bad same_site", EndpointConfig{Path: "/x", Cookies: []CookieConfig{{Name: "c", SameSite: "sometimes"}}}, `unrecognized same_site "sometimes"`},
+		{"method with method_responses", EndpointConfig{Path: "/x", Method: "GET", MethodResponses: map[string]string{"POST": "{}"}}, "method must be empty when method_responses is set"},
+		{"bad method_responses key", EndpointConfig{Path: "/x", MethodResponses: map[string]string{"FETCH": "{}"}}, `unrecognized HTTP method "FETCH" in method_responses`},
+		{"negative weight", EndpointConfig{Path: "/x", Responses: []ResponseVariant{{Response: "{}", Weight: -1}}}, "responses[0] weight -1 must be positive"},
+		{"latency without p50", EndpointConfig{Path: "/x", Latency: &LatencyConfig{P95: 100}}, "latency p50 must be positive"},
+		{"latency p95 below p50", EndpointConfig{Path: "/x", Latency: &LatencyConfig{P50: 100, P95: 50}}, "latency p95 50 is below p50 100"},
+		{"bad status_from", EndpointConfig{Path: "/x", StatusFrom: "body.code"}, `status_from "body.code" must start with query. or header.`},
+		{"bad jsonpath", EndpointConfig{Path: "/x", Response: `{"a": "{{jsonpath:$.items[}}"}`}, `jsonpath "$.items[": parsing error`},
+		{"drop_probability above 1", EndpointConfig{Path: "/x", Fault: &FaultConfig{DropProbability: 1.5}}, "fault drop_probability 1.5 outside range 0-1"},
+		{"bad response_base64", EndpointConfig{Path: "/x", ResponseBase64: "not base64!"}, "response_base64 is not valid base64"},
+		{"bad localized lang", EndpointConfig{Path: "/x", Localized: []LocalizedResponse{{Lang: "en_US", Response: "{}"}}}, `localized[0] lang "en_US" is not a valid language tag`},
+		{"duplicate localized lang", EndpointConfig{Path: "/x", Localized: []LocalizedResponse{{Lang: "fr", Response: "{}"}, {Lang: "FR", Response: "{}"}}}, `localized[1] duplicate lang "FR"`},
+		{"localized with responses", EndpointConfig{Path: "/x", Localized: []LocalizedResponse{{Lang: "fr", Response: "{}"}}, Responses: []ResponseVariant{{Response: "{}"}}}, "localized cannot be combined"},
+		{"host with port", EndpointConfig{Path: "/x", Host: "users.local:8080"}, `host "users.local:8080" must be a host name without scheme, port or path`},
+		{"host with scheme", EndpointConfig{Path: "/x", Host: "http://users.local"}, `host "http://users.local"`},
+		{"query string in path", EndpointConfig{Path: "/api/users?active=true"}, "path cannot contain a query string"},
+		{"blank path", EndpointConfig{Path: "  "}, "path cannot be empty"},
+		{"negative timeout_ms", EndpointConfig{Path: "/x", TimeoutMS: -1}, "timeout_ms cannot be negative"},
+		{"negative stream_chunks", EndpointConfig{Path: "/x", StreamChunks: -1}, "stream_chunks cannot be negative"},
+		{"stream_interval without stream_chunks", EndpointConfig{Path: "/x", StreamInterval: 10}, "stream_interval requires stream_chunks"},
+		{"concurrency without max", EndpointConfig{Path: "/x", Concurrency: &ConcurrencyConfig{}}, "concurrency max must be positive"},
+		{"unknown concurrency overflow", EndpointConfig{Path: "/x", Concurrency: &ConcurrencyConfig{Max: 1, Overflow: "drop"}}, `concurrency overflow "drop" must be "reject" or "queue"`},
+		{"queue_timeout without queue", EndpointConfig{Path: "/x", Concurrency: &ConcurrencyConfig{Max: 1, QueueTimeout: 100}}, `concurrency queue_timeout requires overflow = "queue"`},
+		{"delay_when without conditions", EndpointConfig{Path: "/x", DelayWhen: []DelayRule{{Delay: 100}}}, "delay_when[0] needs at least one query, headers or body condition"},
+		{"delay_when without delay", EndpointConfig{Path: "/x", DelayWhen: []DelayRule{{Query: map[string]string{"simulate": "slow"}}}}, "delay_when[0] delay must be positive"},
+		{"bad retry_after", EndpointConfig{Path: "/x", Status: 503, RetryAfter: "soon"}, `retry_after "soon" must be a number of seconds or an HTTP-date`},
+		{"negative retry_after", EndpointConfig{Path: "/x", Status: 429, RetryAfter: "-5"}, `retry_after "-5"`},
+		{"bad response_format", EndpointConfig{Path: "/x", Response: `{}`, ResponseFormat: "yaml"}, `unrecognized response_format "yaml"`},
+		{"response_base64 with response", EndpointConfig{Path: "/x", Response: "{}", ResponseBase64: "iVBORw=="}, "response_base64 cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Endpoints: []EndpointConfig{tt.endpoint}}
+			err := cfg.Validate()
+
+			if err == nil {
+				t.Fatal("Expected validation error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %q", tt.want, err.Error())
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_DefaultEndpoint(t *testing.T) {
+	cfg := Config{DefaultEndpoint: &DefaultEndpointConfig{
+		Status:   700,
+		Response: `{"path": {{path}`,
+		Headers:  map[string]string{"Content-Type": "application/json"},
+	}}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected error for invalid default endpoint, got nil")
+	}
+	for _, expected := range []string{"default_endpoint: status 700 outside range 100-599", "default_endpoint: response is not valid JSON"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	}
+
+	cfg.DefaultEndpoint = &DefaultEndpointConfig{Response: `{"path": "{{path}}"}`}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid default endpoint, got %v", err)
+	}
+}
+
+func TestConfig_Validate_Listen(t *testing.T) {
+	cfg := Config{Server: ServerConfig{Listen: []string{"127.0.0.1:8080", ":8443"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid listen addresses, got error: %v", err)
+	}
+
+	cfg.Server.Listen = append(cfg.Server.Listen, "localhost")
+	cfg.Server.SocketPath = "/tmp/mock.sock"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	for _, want := range []string{`listen address "localhost"`, "listen cannot be combined with socket_path"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %q", want, err.Error())
+		}
+	}
+}
+
+func TestConfig_Validate_MaxConnections(t *testing.T) {
+	cfg := Config{Server: ServerConfig{MaxConnections: 100, DisableKeepAlive: true}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid connection settings, got error: %v", err)
+	}
+
+	cfg.Server.MaxConnections = -1
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "max_connections -1 must not be negative") {
+		t.Errorf("Expected max_connections error, got %v", err)
+	}
+}
+
+func TestConfig_Validate_MethodAliases(t *testing.T) {
+	cfg := Config{MethodAliases: map[string]string{"LIST": "GET", "purge": "delete"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid method aliases, got error: %v", err)
+	}
+
+	cfg.MethodAliases = map[string]string{"FETCH": "", "ALL": "*", "GET": "get", "BAD VERB": "GET"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	for _, want := range []string{"FETCH must map to a method", "ALL cannot map to the wildcard method", "GET maps to itself", `invalid method "BAD VERB"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %q", want, err.Error())
+		}
+	}
+}
+
+func TestConfig_Validate_MethodOverride(t *testing.T) {
+	cfg := Config{Server: ServerConfig{MethodOverride: true, MethodOverrideMethods: []string{"delete", "PUT"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid method override, got error: %v", err)
+	}
+	if got := cfg.Server.GetMethodOverrideMethods(); len(got) != 2 || got[0] != "DELETE" {
+		t.Errorf("Expected [DELETE PUT], got %v", got)
+	}
+	if got := (&ServerConfig{}).GetMethodOverrideMethods(); len(got) != 3 {
+		t.Errorf("Expected default PUT, PATCH and DELETE, got %v", got)
+	}
+
+	cfg.Server = ServerConfig{MethodOverrideMethods: []string{"*", "BAD VERB"}}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	for _, want := range []string{"method_override_methods requires method_override", "cannot include the wildcard method", `invalid method "BAD VERB"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %q", want, err.Error())
+		}
+	}
+}
+
+func TestConfig_Validate_Static(t *testing.T) {
+	cfg := Config{Static: []StaticConfig{{Prefix: "/assets", Dir: "public"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid static config, got error: %v", err)
+	}
+
+	cfg.Static = []StaticConfig{{Prefix: "assets", Dir: ""}}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	for _, want := range []string{`static[0]: prefix "assets" must start with /`, "static[0]: dir is required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %q", want, err.Error())
+		}
+	}
+}
+
+func TestConfig_Validate_Health(t *testing.T) {
+	cfg := Config{Health: &HealthConfig{}, Endpoints: []EndpointConfig{{Path: "/api/users", Response: "{}"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid health probes, got error: %v", err)
+	}
+
+	tests := []struct {
+		health   HealthConfig
+		expected string
+	}{
+		{HealthConfig{LivenessPath: "livez"}, `health: liveness_path "livez" must start with /`},
+		{HealthConfig{ReadinessPath: "/health"}, "health: readiness_path cannot be /health"},
+		{HealthConfig{ReadinessPath: "/livez"}, "health: liveness_path and readiness_path are both /livez"},
+		{HealthConfig{LivenessPath: "/api/users"}, "health: liveness_path /api/users is also an endpoint path"},
+	}
+	for _, tt := range tests {
+		cfg.Health = &tt.health
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+func TestConfig_Validate_Throttle(t *testing.T) {
+	cfg := Config{Throttle: &ThrottleConfig{BaseMS: 5, IncrementMS: 10, MaxMS: 500}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid throttle, got error: %v", err)
+	}
+
+	tests := []struct {
+		throttle ThrottleConfig
+		expected string
+	}{
+		{ThrottleConfig{MaxMS: 100}, "throttle: base_ms or increment_ms is required"},
+		{ThrottleConfig{BaseMS: 10, IncrementMS: -1}, "throttle: base_ms, increment_ms and max_ms cannot be negative"},
+	}
+	for _, tt := range tests {
+		cfg.Throttle = &tt.throttle
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+func TestConfig_Validate_Errors(t *testing.T) {
+	cfg := Config{Errors: &ErrorsConfig{Template: `{"status": {{error.status}}, "title": "{{error.title}}"}`}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid error envelope, got error: %v", err)
+	}
+
+	cfg.Errors = &ErrorsConfig{ContentType: "application/problem+json", Template: `{"status": `}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "errors: template is not valid JSON") {
+		t.Errorf("Expected invalid JSON error, got %v", err)
+	}
+
+	// Non-JSON envelopes are not parsed
+	cfg.Errors = &ErrorsConfig{ContentType: "text/plain", Template: `error {{error.status}}`}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid text envelope, got error: %v", err)
+	}
+
+	cfg.Errors = &ErrorsConfig{}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "errors: template is required") {
+		t.Errorf("Expected missing template error, got %v", err)
+	}
+}
+
+func TestEndpointConfig_Validate_GRPCWeb(t *testing.T) {
+	valid := EndpointConfig{Path: "/users.UserService/GetUser", Method: "POST", Type: TypeGRPCWeb, GRPCStatus: 5, GRPCMessage: "not found"}
+	if errs := valid.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected valid grpc-web endpoint, got %v", errs)
+	}
+
+	tests := []struct {
+		endpoint EndpointConfig
+		expected string
+	}{
+		{EndpointConfig{Path: "/rpc", Type: "grpc"}, `unrecognized type "grpc"`},
+		{EndpointConfig{Path: "/rpc", Type: TypeGRPCWeb, GRPCStatus: 17}, "grpc_status 17 outside range 0-16"},
+		{EndpointConfig{Path: "/rpc", GRPCStatus: 5}, "grpc_status and grpc_message require type"},
+	}
+	for _, tt := range tests {
+		errs := tt.endpoint.Validate(0)
+		if len(errs) == 0 || !strings.Contains(errs[0].Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, errs)
+		}
+	}
+}
+
+func TestEndpointConfig_Validate_RequiredHeaders(t *testing.T) {
+	valid := EndpointConfig{Path: "/api/orders", RequiredHeaders: []RequiredHeader{{Name: "X-Api-Key"}}, RequiredHeadersStatus: 401}
+	if errs := valid.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected valid required headers, got %v", errs)
+	}
+
+	tests := []struct {
+		endpoint EndpointConfig
+		expected string
+	}{
+		{EndpointConfig{Path: "/a", RequiredHeaders: []RequiredHeader{{Value: "x"}}}, "required_headers[0] name cannot be empty"},
+		{EndpointConfig{Path: "/a", RequiredHeadersStatus: 401}, "require required_headers"},
+		{EndpointConfig{Path: "/a", RequiredHeaders: []RequiredHeader{{Name: "X"}}, RequiredHeadersStatus: 200}, "required_headers_status 200 outside range 400-599"},
+		{EndpointConfig{Path: "/a", RequiredHeaders: []RequiredHeader{{Name: "X"}}, RequiredHeadersResponse: `{"a":`}, "required_headers_response is not valid JSON"},
+		{EndpointConfig{Path: "/a", AnonymousResponse: `{}`}, "anonymous_response requires required_headers"},
+		{EndpointConfig{Path: "/a", RequiredHeaders: []RequiredHeader{{Name: "X"}}, RequiredHeadersStatus: 401, AnonymousResponse: `{}`}, "cannot be combined with required_headers_status"},
+		{EndpointConfig{Path: "/a", RequiredHeaders: []RequiredHeader{{Name: "X"}}, RawResponse: true, AnonymousResponse: `{}`}, "cannot be combined with response_base64 or raw_response"},
+		{EndpointConfig{Path: "/a", RequiredHeaders: []RequiredHeader{{Name: "X"}}, AnonymousResponse: `{"a":`}, "anonymous_response is not valid JSON"},
+	}
+	for _, tt := range tests {
+		errs := tt.endpoint.Validate(0)
+		if len(errs) == 0 || !strings.Contains(errs[0].Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, errs)
+		}
+	}
+}
+
+func TestEndpointConfig_Validate_Callback(t *testing.T) {
+	valid := EndpointConfig{Path: "/api/jobs", Callback: &CallbackConfig{URL: "{{body.callback_url}}", Body: `{"id": "{{query.id}}", "done": true}`}}
+	if errs := valid.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected valid callback, got %v", errs)
+	}
+	if got := valid.Callback.GetMethod(); got != "POST" {
+		t.Errorf("Expected default callback method POST, got %s", got)
+	}
+
+	tests := []struct {
+		callback CallbackConfig
+		expected string
+	}{
+		{CallbackConfig{}, "callback url is required"},
+		{CallbackConfig{URL: "http://x", Method: "FETCH"}, `callback method "FETCH" is not a valid HTTP method`},
+		{CallbackConfig{URL: "http://x", DelayMS: -1}, "callback delay_ms cannot be negative"},
+		{CallbackConfig{URL: "http://x", Body: `{"a":`}, "callback body is not valid JSON"},
+	}
+	for _, tt := range tests {
+		endpoint := EndpointConfig{Path: "/a", Callback: &tt.callback}
+		errs := endpoint.Validate(0)
+		if len(errs) == 0 || !strings.Contains(errs[0].Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, errs)
+		}
+	}
+
+	plain := EndpointConfig{Path: "/a", Callback: &CallbackConfig{URL: "http://x", Body: "done", Headers: map[string]string{"content-type": "text/plain"}}}
+	if errs := plain.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected a text callback body to be valid, got %v", errs)
+	}
+}
+
+func TestEndpointConfig_RouteKey_MatchQuery(t *testing.T) {
+	endpoint := EndpointConfig{Path: "/search", MatchQuery: map[string]string{"type": "order", "archived": ""}}
+	if got, expected := endpoint.RouteKey(), "GET /search (query: archived=&type=order)"; got != expected {
+		t.Errorf("Expected route key %q, got %q", expected, got)
+	}
+
+	invalid := EndpointConfig{Path: "/search", MatchQuery: map[string]string{" ": "x"}}
+	errs := invalid.Validate(0)
+	if len(errs) == 0 || !strings.Contains(errs[0].Error(), "match_query parameter name cannot be empty") {
+		t.Errorf("Expected an empty match_query name error, got %v", errs)
+	}
+}
+
+func TestEndpointConfig_RouteKeys(t *testing.T) {
+	endpoint := EndpointConfig{Path: "/api/users", MethodResponses: map[string]string{"post": "{}", "GET": "{}"}}
+	got := strings.Join(endpoint.RouteKeys(), ", ")
+	if expected := "GET /api/users, POST /api/users"; got != expected {
+		t.Errorf("Expected route keys %q, got %q", expected, got)
+	}
+
+	plain := EndpointConfig{Path: "/api/users", Method: "delete"}
+	if got := plain.RouteKeys(); len(got) != 1 || got[0] != "DELETE /api/users" {
+		t.Errorf("Expected the single route key DELETE /api/users, got %v", got)
+	}
+}
+
+func TestEndpointConfig_Validate_ResponseFilesConflict(t *testing.T) {
+	for _, endpoint := range []EndpointConfig{
+		{Path: "/api/user", ResponseFiles: []string{"a.json"}, RawResponse: true},
+		{Path: "/api/user", ResponseFiles: []string{"a.json"}, ResponseFormat: "json5"},
+		{Path: "/api/user", ResponseFiles: []string{"a.json"}, Localized: []LocalizedResponse{{Lang: "en", Response: "{}"}}},
+	} {
+		errs := endpoint.Validate(0)
+		if len(errs) == 0 || !strings.Contains(errors.Join(errs...).Error(), "response_files cannot be combined") {
+			t.Errorf("Expected a response_files conflict, got %v", errs)
+		}
+		if err := endpoint.LoadResponseFiles(); err == nil {
+			t.Error("Expected LoadResponseFiles to refuse the conflicting endpoint")
+		}
+	}
+}
+
+func TestEndpointConfig_Validate_Proxy(t *testing.T) {
+	valid := EndpointConfig{Path: "/api/orders", Proxy: &ProxyConfig{URL: "http://orders.internal:8080"}}
+	if errs := valid.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected valid proxy, got %v", errs)
+	}
+	// Headers apply to proxied responses, and a declared Content-Type frees
+	// the fallback from being JSON
+	text := EndpointConfig{Path: "/api/orders", Headers: map[string]string{"Content-Type": "text/plain"}, Proxy: &ProxyConfig{URL: "http://x", FallbackResponse: "down"}}
+	if errs := text.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected a text fallback to be valid, got %v", errs)
+	}
+	if valid.Proxy.GetFallbackStatus() != 502 || valid.Proxy.GetTimeout() != 10*time.Second {
+		t.Errorf("Expected 502 and 10s defaults, got %d and %v", valid.Proxy.GetFallbackStatus(), valid.Proxy.GetTimeout())
+	}
+
+	tests := []struct {
+		endpoint EndpointConfig
+		expected string
+	}{
+		{EndpointConfig{Path: "/a", Proxy: &ProxyConfig{URL: "orders.internal"}}, "must be an absolute http or https URL"},
+		{EndpointConfig{Path: "/a", Proxy: &ProxyConfig{URL: "http://x", TimeoutMS: -1}}, "proxy timeout_ms cannot be negative"},
+		{EndpointConfig{Path: "/a", Proxy: &ProxyConfig{URL: "http://x", FallbackStatus: 700}}, "proxy fallback_status 700 outside range"},
+		{EndpointConfig{Path: "/a", Proxy: &ProxyConfig{URL: "http://x", FallbackResponse: "{"}}, "proxy fallback_response is not valid JSON"},
+		{EndpointConfig{Path: "/a", Response: "{}", Proxy: &ProxyConfig{URL: "http://x"}}, "proxy cannot be combined with response"},
+		{EndpointConfig{Path: "/a", Status: 201, Delay: 100, ETag: true, Proxy: &ProxyConfig{URL: "http://x"}}, "proxy cannot be combined with status, delay, etag"},
+		{EndpointConfig{Path: "/a", Quota: &QuotaConfig{Limit: 1}, Callback: &CallbackConfig{URL: "http://hook"}, Proxy: &ProxyConfig{URL: "http://x"}}, "proxy cannot be combined with quota, callback"},
+	}
+	for _, tt := range tests {
+		errs := tt.endpoint.Validate(0)
+		if len(errs) == 0 || !strings.Contains(errors.Join(errs...).Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, errs)
+		}
+	}
+}
+
+func TestEndpointConfig_Validate_Quota(t *testing.T) {
+	valid := EndpointConfig{Path: "/api/work", Quota: &QuotaConfig{Limit: 100, WindowMS: 60000}}
+	if errs := valid.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected valid quota, got %v", errs)
+	}
+	if got := valid.Quota.GetStatus(); got != 429 {
+		t.Errorf("Expected default quota status 429, got %d", got)
+	}
+
+	tests := []struct {
+		quota    QuotaConfig
+		expected string
+	}{
+		{QuotaConfig{}, "quota limit must be positive"},
+		{QuotaConfig{Limit: 1, Status: 1000}, "quota status 1000 outside range 100-599"},
+		{QuotaConfig{Limit: 1, WindowMS: -1}, "quota window_ms cannot be negative"},
+		{QuotaConfig{Limit: 1, Response: `{"error":`}, "quota response is not valid JSON"},
+	}
+	for _, tt := range tests {
+		endpoint := EndpointConfig{Path: "/api/work", Quota: &tt.quota}
+		errs := endpoint.Validate(0)
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, errs)
+		}
+	}
+}
+
+func TestEndpointConfig_Validate_RawResponse(t *testing.T) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	raw := EndpointConfig{Path: "/api/users", Response: `{"users": [`, Headers: headers, RawResponse: true}
+	if errs := raw.Validate(0); len(errs) != 0 {
+		t.Errorf("Expected broken JSON to be accepted for a raw response, got %v", errs)
+	}
+
+	raw.Responses = []ResponseVariant{{Response: `{}`}}
+	errs := raw.Validate(0)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "raw_response cannot be combined") {
+		t.Errorf("Expected combination error, got %v", errs)
+	}
+}
+
+func TestEndpointConfig_Validate_HeaderList(t *testing.T) {
+	endpoint := EndpointConfig{Path: "/api/login", HeaderList: []HeaderConfig{{Name: "Set-Cookie", Value: "a=1"}, {Value: "b=2"}}}
+	errs := endpoint.Validate(0)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "header[1] name cannot be empty") {
+		t.Errorf("Expected empty header name error, got %v", errs)
+	}
+
+	// A Content-Type in the header list counts as declared
+	endpoint = EndpointConfig{Path: "/api/login", HeaderList: []HeaderConfig{{Name: "content-type", Value: "text/plain"}}}
+	if got := endpoint.ConfiguredContentType(); got != "text/plain" {
+		t.Errorf("Expected text/plain, got %q", got)
+	}
+}
+
+func TestConfig_Validate_AggregatesErrors(t *testing.T) {
+	cfg := Config{
+		Endpoints: []EndpointConfig{
+			{Path: "", Method: "GET", Response: "{}"},
+			{Path: "/ok", Method: "GET", Response: "{}"},
+			{Path: "/bad", Method: "BOGUS", Status: 1000, Response: "{}"},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 {
+		t.Errorf("Expected 3 aggregated errors, got %d: %v", len(lines), err)
+	}
+	if !strings.Contains(err.Error(), "endpoint[0]") || !strings.Contains(err.Error(), "endpoint[2]") {
+		t.Errorf("Expected errors to identify endpoints by index, got %v", err)
+	}
+}
+
+func TestEndpointConfig_NeedsDynamicHandler(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name     string
+		endpoint EndpointConfig
+		expected bool
+	}{
+		{"static", EndpointConfig{Response: `{"ok": true}`, Headers: map[string]string{"X-Static": "yes"}, ETag: true}, false},
+		{"untemplated tokens", EndpointConfig{Response: `{"path": "{{path}}"}`, Template: &disabled}, false},
+		{"templated response", EndpointConfig{Response: `{"path": "{{path}}"}`}, true},
+		{"templated header", EndpointConfig{Headers: map[string]string{"X-Path": "{{path}}"}}, true},
+		{"templated header list", EndpointConfig{HeaderList: []HeaderConfig{{Name: "Link", Value: "<{{path}}>"}}}, true},
+		{"delay", EndpointConfig{Delay: 10}, true},
+		{"variants", EndpointConfig{Responses: []ResponseVariant{{Response: "{}"}}}, true},
+		{"quota", EndpointConfig{Quota: &QuotaConfig{Limit: 1}}, true},
+		{"grpc-web", EndpointConfig{Type: TypeGRPCWeb}, true},
+		{"proxy", EndpointConfig{Proxy: &ProxyConfig{URL: "http://x"}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.endpoint.NeedsDynamicHandler(); got != tt.expected {
+			t.Errorf("%s: expected NeedsDynamicHandler %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// graphQLNamePattern matches a valid GraphQL name
+var graphQLNamePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// sha256HexPattern matches a hex-encoded SHA-256 hash
+var sha256HexPattern = regexp.MustCompile(`^[0-9A-Fa-f]{64}$`)
+
+// validate checks each query and mutation response against its declared
+// return type, so shape mistakes are reported at load time instead of as
+// GraphQL errors at request time. Fields with their own response are checked
+// against the field type once, where the type is declared.
+func (g *GraphQLConfig) validate() []error {
+	if g == nil || !g.Enabled {
+		return nil
+	}
+
+	v := &responseValidator{types: make(map[string]GraphQLType, len(g.Types))}
+	for _, typeDef := range g.Types {
+		v.types[typeDef.Name] = typeDef
+	}
+
+	var errs []error
+	errs = append(errs, g.validateRootNames()...)
+	for i, persisted := range g.Persisted {
+		if strings.TrimSpace(persisted.Query) == "" {
+			errs = append(errs, fmt.Errorf("graphql persisted[%d]: query is required", i))
+		}
+		if persisted.Hash != "" && !sha256HexPattern.MatchString(persisted.Hash) {
+			errs = append(errs, fmt.Errorf("graphql persisted[%d]: hash %q is not a hex SHA-256", i, persisted.Hash))
+		}
+	}
+	for _, typeDef := range g.Types {
+		for _, fieldName := range sortedKeys(typeDef.Fields) {
+			field := typeDef.Fields[fieldName]
+			if field.Response == "" {
+				continue
+			}
+			label := fmt.Sprintf("graphql type %s field %s", typeDef.Name, fieldName)
+			errs = append(errs, v.checkResponse(label, field.Type, field.Response)...)
+		}
+	}
+	for _, query := range g.Queries {
+		errs = append(errs, v.checkResponse("graphql query "+query.Name, query.ReturnType, query.Response)...)
+		for i, c := range query.Cases {
+			label := fmt.Sprintf("graphql query %s cases[%d]", query.Name, i)
+			if len(c.Args) == 0 {
+				errs = append(errs, fmt.Errorf("%s: args is required", label))
+			}
+			for _, name := range sortedKeys(c.Args) {
+				if _, ok := query.Args[name]; !ok {
+					errs = append(errs, fmt.Errorf("%s: argument %q is not declared in args", label, name))
+				}
+			}
+			errs = append(errs, v.checkResponse(label, query.ReturnType, c.Response)...)
+		}
+	}
+	for _, mutation := range g.Mutations {
+		errs = append(errs, v.checkResponse("graphql mutation "+mutation.Name, mutation.ReturnType, mutation.Response)...)
+	}
+	return errs
+}
+
+// validateRootNames checks the root object names are valid GraphQL names
+// that clash with neither each other nor a configured type
+func (g *GraphQLConfig) validateRootNames() []error {
+	var errs []error
+	roots := []struct{ key, name string }{
+		{"query_type_name", g.GetQueryTypeName()},
+		{"mutation_type_name", g.GetMutationTypeName()},
+	}
+	for _, root := range roots {
+		if !graphQLNamePattern.MatchString(root.name) || strings.HasPrefix(root.name, "__") {
+			errs = append(errs, fmt.Errorf("graphql %s %q is not a valid GraphQL name", root.key, root.name))
+			continue
+		}
+		for _, typeDef := range g.Types {
+			if typeDef.Name == root.name {
+				errs = append(errs, fmt.Errorf("graphql %s %q clashes with a configured type", root.key, root.name))
+				break
+			}
+		}
+	}
+	if g.GetQueryTypeName() == g.GetMutationTypeName() {
+		errs = append(errs, fmt.Errorf("graphql query_type_name and mutation_type_name are both %q", g.GetQueryTypeName()))
+	}
+	return errs
+}
+
+// responseValidator checks response JSON against declared GraphQL types
+type responseValidator struct {
+	types map[string]GraphQLType
+}
+
+// checkResponse parses response and checks it against typeName. Empty
+// responses are left to fail at request time as before.
+func (v *responseValidator) checkResponse(label, typeName, response string) []error {
+	if response == "" {
+		return nil
+	}
+
+	if !json.Valid([]byte(response)) {
+		return []error{fmt.Errorf("%s: response is not valid JSON", label)}
+	}
+	// Keep numbers exact so integers can be told apart from floats
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(response))
+	decoder.UseNumber()
+	_ = decoder.Decode(&value) // valid JSON always decodes
+
+	var errs []error
+	v.check(typeName, value, "response", &errs)
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("%s: %w", label, err)
+	}
+	return errs
+}
+
+// check appends an error for every place value does not fit typeName. path
+// locates value in the response, e.g. response.posts[0].title.
+func (v *responseValidator) check(typeName string, value interface{}, path string, errs *[]error) {
+	nonNull := strings.HasSuffix(typeName, "!")
+	typeName = strings.TrimSuffix(typeName, "!")
+	if value == nil {
+		if nonNull {
+			*errs = append(*errs, fmt.Errorf("%s is null but %s! is non-null", path, typeName))
+		}
+		return
+	}
+
+	if len(typeName) > 2 && strings.HasPrefix(typeName, "[") && strings.HasSuffix(typeName, "]") {
+		items, ok := value.([]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Errorf("%s: expected a list for %s, got %s", path, typeName, jsonKind(value)))
+			return
+		}
+		for i, item := range items {
+			v.check(typeName[1:len(typeName)-1], item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+		return
+	}
+
+	if typeDef, ok := v.types[typeName]; ok {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Errorf("%s: expected an object for %s, got %s", path, typeName, jsonKind(value)))
+			return
+		}
+		for _, fieldName := range sortedKeys(typeDef.Fields) {
+			field := typeDef.Fields[fieldName]
+			if field.Response != "" {
+				// Resolved from the field's own response, checked separately
+				continue
+			}
+			fieldValue, present := object[fieldName]
+			if !present && strings.HasSuffix(field.Type, "!") {
+				*errs = append(*errs, fmt.Errorf("%s: missing non-null field %s of type %s", path, fieldName, field.Type))
+				continue
+			}
+			v.check(field.Type, fieldValue, path+"."+fieldName, errs)
+		}
+		return
+	}
+
+	if !scalarFits(typeName, value) {
+		*errs = append(*errs, fmt.Errorf("%s: expected %s, got %s", path, typeName, jsonKind(value)))
+	}
+}
+
+// scalarFits reports whether value is acceptable for a built-in scalar.
+// Custom scalars pass through unchanged and accept anything, as do unknown
+// types, which the schema already treats as String with a warning.
+func scalarFits(typeName string, value interface{}) bool {
+	switch typeName {
+	case "String":
+		_, ok := value.(string)
+		return ok
+	case "ID":
+		switch value := value.(type) {
+		case string:
+			return true
+		case json.Number:
+			_, err := value.Int64()
+			return err == nil
+		}
+		return false
+	case "Int":
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		n, err := number.Int64()
+		return err == nil && n >= math.MinInt32 && n <= math.MaxInt32
+	case "Float":
+		_, ok := value.(json.Number)
+		return ok
+	case "Boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return true
+}
+
+// jsonKind names the JSON kind of a decoded value for error messages
+func jsonKind(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return fmt.Sprintf("string %q", value)
+	case json.Number:
+		return "number " + value.String()
+	case bool:
+		return fmt.Sprintf("boolean %t", value)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return "null"
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// newUserGraphQL returns a GraphQL config with a User type and a user query
+// returning response
+func newUserGraphQL(response string) *GraphQLConfig {
+	return &GraphQLConfig{
+		Enabled: true,
+		Types: []GraphQLType{
+			{Name: "User", Fields: map[string]GraphQLField{
+				"id":    {Type: "ID!"},
+				"name":  {Type: "String!"},
+				"age":   {Type: "Int"},
+				"tags":  {Type: "[String]"},
+				"posts": {Type: "[Post]", Response: `[{"title": "Hello"}]`},
+			}},
+			{Name: "Post", Fields: map[string]GraphQLField{
+				"title": {Type: "String!"},
+				"score": {Type: "Float"},
+			}},
+		},
+		Queries: []GraphQLQuery{
+			{Name: "user", ReturnType: "User", Response: response},
+		},
+	}
+}
+
+func TestGraphQLConfig_Validate(t *testing.T) {
+	valid := []string{
+		`{"id": "1", "name": "Alice", "age": 30, "tags": ["admin"]}`,
+		`{"id": 1, "name": "Alice", "age": null, "extra": true}`,
+		`null`,
+		``,
+	}
+	for _, response := range valid {
+		if errs := newUserGraphQL(response).validate(); len(errs) > 0 {
+			t.Errorf("Expected %s to be valid, got %v", response, errs)
+		}
+	}
+
+	// Disabled GraphQL is not checked
+	cfg := newUserGraphQL(`{}`)
+	cfg.Enabled = false
+	if errs := cfg.validate(); len(errs) > 0 {
+		t.Errorf("Expected disabled GraphQL to be skipped, got %v", errs)
+	}
+}
+
+func TestGraphQLConfig_ValidateMissingNonNullField(t *testing.T) {
+	errs := newUserGraphQL(`{"id": "1"}`).validate()
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %v", errs)
+	}
+	expected := "graphql query user: response: missing non-null field name of type String!"
+	if errs[0].Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, errs[0].Error())
+	}
+}
+
+func TestGraphQLConfig_ValidateWrongTypedField(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected string
+	}{
+		{"string for Int", `{"id": "1", "name": "Alice", "age": "thirty"}`, `response.age: expected Int, got string "thirty"`},
+		{"float for Int", `{"id": "1", "name": "Alice", "age": 30.5}`, `response.age: expected Int, got number 30.5`},
+		{"number for String", `{"id": "1", "name": 42}`, `response.name: expected String, got number 42`},
+		{"null for non-null", `{"id": "1", "name": null}`, `response.name is null but String! is non-null`},
+		{"object for list", `{"id": "1", "name": "Alice", "tags": {"a": 1}}`, `response.tags: expected a list for [String], got an object`},
+		{"wrong list item", `{"id": "1", "name": "Alice", "tags": ["a", false]}`, `response.tags[1]: expected String, got boolean false`},
+		{"list for object", `[{"id": "1", "name": "Alice"}]`, `response: expected an object for User, got a list`},
+		{"invalid json", `{"id": `, `response is not valid JSON`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := newUserGraphQL(tt.response).validate()
+			if len(errs) != 1 {
+				t.Fatalf("Expected 1 error, got %v", errs)
+			}
+			if !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %q", tt.expected, errs[0].Error())
+			}
+		})
+	}
+}
+
+func TestGraphQLConfig_ValidateFieldResponses(t *testing.T) {
+	cfg := newUserGraphQL(`{"id": "1", "name": "Alice"}`)
+	cfg.Types[0].Fields["posts"] = GraphQLField{Type: "[Post]", Response: `[{"title": "Hello", "score": "high"}]`}
+	cfg.Mutations = []GraphQLMutation{{Name: "createPost", ReturnType: "Post!", Response: `{"score": 1}`}}
+
+	errs := cfg.validate()
+	expected := []string{
+		`graphql type User field posts: response[0].score: expected Float, got string "high"`,
+		`graphql mutation createPost: response: missing non-null field title of type String!`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], err.Error())
+		}
+	}
+}
+
+func TestGraphQLConfig_ValidateRootNames(t *testing.T) {
+	cfg := newUserGraphQL(`{"id": "1", "name": "Alice"}`)
+	cfg.QueryTypeName, cfg.MutationTypeName = "Query", "Mutation"
+	if errs := cfg.validate(); len(errs) != 0 {
+		t.Errorf("Expected valid root names, got %v", errs)
+	}
+
+	cfg.QueryTypeName, cfg.MutationTypeName = "User", "__Mutation"
+	errs := cfg.validate()
+	expected := []string{
+		`graphql query_type_name "User" clashes with a configured type`,
+		`graphql mutation_type_name "__Mutation" is not a valid GraphQL name`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], err.Error())
+		}
+	}
+
+	cfg.QueryTypeName, cfg.MutationTypeName = "Root", "Root"
+	if errs := cfg.validate(); len(errs) != 1 || !strings.Contains(errs[0].Error(), `are both "Root"`) {
+		t.Errorf("Expected duplicate root name error, got %v", errs)
+	}
+}
+
+func TestGraphQLConfig_ValidatePersisted(t *testing.T) {
+	cfg := newUserGraphQL(`{"id": "1", "name": "Alice"}`)
+	cfg.Persisted = []GraphQLPersistedQuery{
+		{Query: "{ user { id } }"},
+		{Hash: "abc", Query: "{ user { name } }"},
+		{Hash: strings.Repeat("a", 64)},
+	}
+
+	errs := cfg.validate()
+	expected := []string{
+		`graphql persisted[1]: hash "abc" is not a hex SHA-256`,
+		`graphql persisted[2]: query is required`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], err.Error())
+		}
+	}
+
+	// Without a configured hash, the query text is hashed
+	sum := sha256.Sum256([]byte("{ user { id } }"))
+	if got := cfg.Persisted[0].GetHash(); got != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected the SHA-256 of the query, got %q", got)
+	}
+}
+
+func TestGraphQLConfig_ValidateCases(t *testing.T) {
+	cfg := newUserGraphQL(`{"id": "1", "name": "Alice"}`)
+	cfg.Queries[0].Args = map[string]string{"id": "ID!"}
+	cfg.Queries[0].Cases = []GraphQLCase{
+		{Args: map[string]interface{}{"id": int64(2)}, Response: `{"id": "2", "name": "Bob"}`},
+		{Response: `{"id": "3", "name": "Carol"}`},
+		{Args: map[string]interface{}{"email": "x"}, Response: `{"id": "4", "name": "Dan"}`},
+		{Args: map[string]interface{}{"id": "5"}, Response: `{"id": "5"}`},
+	}
+
+	errs := cfg.validate()
+	expected := []string{
+		`graphql query user cases[1]: args is required`,
+		`graphql query user cases[2]: argument "email" is not declared in args`,
+		`graphql query user cases[3]: response: missing non-null field name of type String!`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("Expected %q, got %q", expected[i], err.Error())
+		}
+	}
+}
+
+func TestConfig_Validate_GraphQLResponses(t *testing.T) {
+	cfg := Config{GraphQL: newUserGraphQL(`{"id": "1", "name": "Alice", "age": "30"}`)}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected error for mistyped GraphQL response, got nil")
+	}
+	if !strings.Contains(err.Error(), "graphql query user: response.age: expected Int") {
+		t.Errorf("Expected error to locate the field, got %v", err)
+	}
+}
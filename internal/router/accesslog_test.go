@@ -0,0 +1,67 @@
+package router
+
+import (
+	"bytes"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// captureLog collects log output for the duration of a test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestRouterHandler_AccessLogDisabled(t *testing.T) {
+	router := New()
+	router.SetAccessLog(false, nil)
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: "/quiet", Response: `{}`}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+	buf := captureLog(t)
+
+	req := httptest.NewRequest("GET", "/quiet?x=1", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if strings.Contains(buf.String(), "/quiet") {
+		t.Errorf("Expected no access log line, got %q", buf.String())
+	}
+}
+
+func TestRouterHandler_AccessLogRedaction(t *testing.T) {
+	router := New()
+	router.SetAccessLog(true, []string{"token", "Password"})
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: "/login", Response: `{"token": "{{query.token}}"}`}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+	buf := captureLog(t)
+
+	req := httptest.NewRequest("GET", "/login?user=alice&token=s3cret&password=hunter2", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	logged := buf.String()
+	if !strings.Contains(logged, "[GET] /login?user=alice&token=***&password=*** ") {
+		t.Errorf("Expected redacted query in log, got %q", logged)
+	}
+	if strings.Contains(logged, "s3cret") || strings.Contains(logged, "hunter2") {
+		t.Errorf("Expected secrets to be masked, got %q", logged)
+	}
+
+	// The request itself is not modified
+	if w.Body.String() != `{"token": "s3cret"}` {
+		t.Errorf("Expected handler to see the real token, got %s", w.Body.String())
+	}
+}
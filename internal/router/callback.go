@@ -0,0 +1,105 @@
+package router
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// callbackTimeout bounds how long one callback request may take
+const callbackTimeout = 10 * time.Second
+
+// Callbacks tracks endpoint callbacks sent in the background so a server can
+// wait for them before it exits. A Callbacks may be shared by several routers,
+// such as the ones built across config reloads.
+type Callbacks struct {
+	client *http.Client
+	wg     sync.WaitGroup
+	// Cancelled when Wait gives up, abandoning pending callbacks
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewCallbacks creates an empty callback tracker
+func NewCallbacks() *Callbacks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Callbacks{
+		client: &http.Client{Timeout: callbackTimeout},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Wait blocks until every pending callback has been sent. If ctx is done
+// first, the remaining callbacks are cancelled and ctx's error is returned.
+func (c *Callbacks) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		c.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// send fires a callback request in the background after delay
+func (c *Callbacks) send(method, url, body string, header http.Header, delay time.Duration) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		if delay > 0 && !sleepContext(c.ctx, delay) {
+			log.Printf("Callback %s %s cancelled", method, url)
+			return
+		}
+		req, err := http.NewRequestWithContext(c.ctx, method, url, strings.NewReader(body))
+		if err != nil {
+			log.Printf("Invalid callback %s %s: %v", method, url, err)
+			return
+		}
+		req.Header = header
+		resp, err := c.client.Do(req)
+		if err != nil {
+			log.Printf("Callback %s %s failed: %v", method, url, err)
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		log.Printf("Callback %s %s - %d", method, url, resp.StatusCode)
+	}()
+}
+
+// callbacksKey is the context key for the router's callback tracker
+type callbacksKey struct{}
+
+// defaultCallbacks tracks callbacks from handlers served outside a router
+var defaultCallbacks = NewCallbacks()
+
+// sendCallback fires the endpoint's callback for r, rendering its URL, body
+// and header values with render
+func sendCallback(r *http.Request, callback *models.CallbackConfig, render func(string, bool) string) {
+	callbacks, ok := r.Context().Value(callbacksKey{}).(*Callbacks)
+	if !ok {
+		callbacks = defaultCallbacks
+	}
+	header := http.Header{}
+	for key, value := range callback.Headers {
+		header.Set(key, render(value, false))
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	delay := time.Duration(callback.DelayMS) * time.Millisecond
+	callbacks.send(callback.GetMethod(), render(callback.URL, false), render(callback.Body, callback.IsJSON()), header, delay)
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestHandler_Callback(t *testing.T) {
+	type received struct {
+		method      string
+		contentType string
+		token       string
+		body        string
+	}
+	got := make(chan received, 1)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Method, r.Header.Get("Content-Type"), r.Header.Get("X-Token"), string(body)}
+	}))
+	defer target.Close()
+
+	callbacks := NewCallbacks()
+	rt := New()
+	rt.SetCallbacks(callbacks)
+	rt.RegisterEndpoint(models.EndpointConfig{
+		Path:     "/api/jobs",
+		Method:   "POST",
+		Response: `{"status": "queued"}`,
+		Callback: &models.CallbackConfig{
+			URL:     "{{body.callback_url}}",
+			Method:  "put",
+			Body:    `{"job": "{{body.name}}", "status": "done"}`,
+			DelayMS: 10,
+			Headers: map[string]string{"X-Token": "{{query.token}}"},
+		},
+	})
+
+	payload := `{"callback_url": "` + target.URL + `/hook", "name": "say \"hi\""}`
+	req := httptest.NewRequest("POST", "/api/jobs?token=abc", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected default status 202, got %d", w.Code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := callbacks.Wait(ctx); err != nil {
+		t.Fatalf("Expected the callback to finish, got %v", err)
+	}
+	select {
+	case callback := <-got:
+		if callback.method != "PUT" {
+			t.Errorf("Expected a PUT callback, got %s", callback.method)
+		}
+		if callback.contentType != "application/json" {
+			t.Errorf("Expected JSON callback, got Content-Type %q", callback.contentType)
+		}
+		if callback.token != "abc" {
+			t.Errorf("Expected templated X-Token abc, got %q", callback.token)
+		}
+		if expected := `{"job": "say \"hi\"", "status": "done"}`; callback.body != expected {
+			t.Errorf("Expected callback body %s, got %s", expected, callback.body)
+		}
+	default:
+		t.Fatal("Expected the callback target to receive a request")
+	}
+}
+
+func TestHandler_CallbackSkippedOnError(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no callback for an error response")
+	}))
+	defer target.Close()
+
+	callbacks := NewCallbacks()
+	rt := New()
+	rt.SetCallbacks(callbacks)
+	rt.RegisterEndpoint(models.EndpointConfig{
+		Path:     "/api/jobs",
+		Method:   "POST",
+		Status:   500,
+		Response: `{"error": "unavailable"}`,
+		Callback: &models.CallbackConfig{URL: target.URL},
+	})
+
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, httptest.NewRequest("POST", "/api/jobs", nil))
+	if err := callbacks.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected no pending callbacks, got %v", err)
+	}
+}
+
+func TestCallbacks_WaitCancelsPending(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected the delayed callback to be cancelled")
+	}))
+	defer target.Close()
+
+	callbacks := NewCallbacks()
+	callbacks.send("POST", target.URL, "{}", http.Header{}, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := callbacks.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CapturePath serves the captured requests when capture is enabled
+const CapturePath = "/__admin/requests"
+
+// capturedRequest is a recorded endpoint request as returned by CapturePath
+type capturedRequest struct {
+	Time     time.Time   `json:"time"`
+	Endpoint string      `json:"endpoint"` // configured path that served the request
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	Query    string      `json:"query,omitempty"`
+	Headers  http.Header `json:"headers"`
+	Body     string      `json:"body"`
+
+	seq uint64 // capture order across endpoints
+}
+
+// requestCapture keeps the most recent requests of each endpoint so tests can
+// assert on the calls their code made. It is safe for concurrent use.
+type requestCapture struct {
+	mu         sync.Mutex
+	limit      int
+	seq        uint64
+	byEndpoint map[string][]capturedRequest // oldest first, at most limit each
+}
+
+// newRequestCapture creates a capture keeping limit requests per endpoint
+func newRequestCapture(limit int) *requestCapture {
+	return &requestCapture{limit: limit, byEndpoint: make(map[string][]capturedRequest)}
+}
+
+// record stores r as served by the endpoint at path. The body is read in
+// full and replaced so the endpoint handler can still read it. It returns
+// the error that stopped the body being read, such as an oversized body.
+func (c *requestCapture) record(path string, r *http.Request) error {
+	var body []byte
+	var err error
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err = io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	captured := capturedRequest{
+		Time:     time.Now().UTC(),
+		Endpoint: path,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Query:    r.URL.RawQuery,
+		Headers:  r.Header.Clone(),
+		Body:     string(body),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	captured.seq = c.seq
+	requests := append(c.byEndpoint[path], captured)
+	if len(requests) > c.limit {
+		requests = append(requests[:0:0], requests[len(requests)-c.limit:]...)
+	}
+	c.byEndpoint[path] = requests
+	return err
+}
+
+// requests returns the captured requests, oldest first. A non-empty path
+// selects those served by that configured endpoint path or made to that
+// request path.
+func (c *requestCapture) requests(path string) []capturedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matched []capturedRequest
+	for endpoint, requests := range c.byEndpoint {
+		for _, captured := range requests {
+			if path == "" || endpoint == path || captured.Path == path {
+				matched = append(matched, captured)
+			}
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].seq < matched[j].seq })
+	return matched
+}
+
+// reset discards every captured request
+func (c *requestCapture) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byEndpoint = make(map[string][]capturedRequest)
+}
+
+// RegisterCapture records up to limit requests per endpoint and serves them
+// at CapturePath: GET lists them, optionally filtered by ?path=, and DELETE
+// clears them
+func (rt *Router) RegisterCapture(limit int) {
+	rt.capture = newRequestCapture(limit)
+	rt.mux.HandleFunc(CapturePath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requests := rt.capture.requests(r.URL.Query().Get("path"))
+			if requests == nil {
+				requests = []capturedRequest{}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(requests); err != nil {
+				log.Printf("Failed to encode captured requests: %v", err)
+			}
+		case http.MethodDelete:
+			rt.capture.reset()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			MethodNotAllowedHandler([]string{http.MethodDelete, http.MethodGet}, rt.methodNotAllowedResponse)(w, r)
+		}
+	})
+	log.Printf("Registered request capture endpoint: GET %s", CapturePath)
+}
+
+// captureRequest records r for the endpoint at path when capture is enabled,
+// reporting whether the request should still be served
+func (rt *Router) captureRequest(w http.ResponseWriter, r *http.Request, path string) bool {
+	if rt.capture == nil {
+		return true
+	}
+	if err := rt.capture.record(path, r); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			PayloadTooLargeHandler(maxBytesErr.Limit)(w, r)
+			return false
+		}
+		log.Printf("Failed to capture request body: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// newCaptureRouter creates a router capturing up to limit requests per
+// endpoint, with echoing user and order endpoints
+func newCaptureRouter(t *testing.T, limit int) http.Handler {
+	t.Helper()
+
+	rt := New()
+	rt.RegisterCapture(limit)
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/users", Method: "POST", Status: 201, Response: `{"created": {{body}}}`},
+		{Path: "/api/orders/", Method: "GET", Response: `{"orders": []}`},
+	}
+	if err := rt.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+	return rt.Handler()
+}
+
+// capturedRequests fetches the captured requests for path through the admin API
+func capturedRequests(t *testing.T, handler http.Handler, path string) []capturedRequest {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", CapturePath+"?path="+path, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200 from %s, got %d", CapturePath, w.Code)
+	}
+
+	var requests []capturedRequest
+	if err := json.Unmarshal(w.Body.Bytes(), &requests); err != nil {
+		t.Fatalf("Failed to decode captured requests %s: %v", w.Body.String(), err)
+	}
+	return requests
+}
+
+func TestRouterHandler_CaptureRequests(t *testing.T) {
+	handler := newCaptureRouter(t, 10)
+
+	req := httptest.NewRequest("POST", "/api/users?source=test", strings.NewReader(`{"name": "Alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace", "abc")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	// The endpoint still sees the body after it was captured
+	if w.Code != 201 || w.Body.String() != `{"created": {"name":"Alice"}}` {
+		t.Fatalf("Expected endpoint to echo the body, got %d %s", w.Code, w.Body.String())
+	}
+
+	requests := capturedRequests(t, handler, "/api/users")
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 captured request, got %d", len(requests))
+	}
+	captured := requests[0]
+	if captured.Method != "POST" || captured.Path != "/api/users" || captured.Query != "source=test" {
+		t.Errorf("Unexpected captured request line: %s %s?%s", captured.Method, captured.Path, captured.Query)
+	}
+	if captured.Body != `{"name": "Alice"}` {
+		t.Errorf("Expected captured body, got %s", captured.Body)
+	}
+	if captured.Headers.Get("X-Trace") != "abc" {
+		t.Errorf("Expected captured X-Trace header, got %v", captured.Headers)
+	}
+
+	// Other endpoints have captured nothing
+	if requests := capturedRequests(t, handler, "/api/orders/"); len(requests) != 0 {
+		t.Errorf("Expected no captured orders requests, got %d", len(requests))
+	}
+}
+
+func TestRouterHandler_CaptureFilterAndReset(t *testing.T) {
+	handler := newCaptureRouter(t, 10)
+
+	for _, path := range []string{"/api/orders/1", "/api/orders/2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/users", strings.NewReader(`{}`)))
+
+	// Prefix routes can be filtered by their configured path or a request path
+	if requests := capturedRequests(t, handler, "/api/orders/"); len(requests) != 2 {
+		t.Errorf("Expected 2 requests for /api/orders/, got %d", len(requests))
+	}
+	if requests := capturedRequests(t, handler, "/api/orders/2"); len(requests) != 1 {
+		t.Errorf("Expected 1 request for /api/orders/2, got %d", len(requests))
+	}
+
+	// Without a filter every request is listed in order
+	requests := capturedRequests(t, handler, "")
+	if len(requests) != 3 || requests[0].Path != "/api/orders/1" || requests[2].Path != "/api/users" {
+		t.Errorf("Expected all 3 requests in order, got %+v", requests)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("DELETE", CapturePath, nil))
+	if w.Code != 204 {
+		t.Errorf("Expected status 204 from DELETE, got %d", w.Code)
+	}
+	if requests := capturedRequests(t, handler, ""); len(requests) != 0 {
+		t.Errorf("Expected no requests after reset, got %d", len(requests))
+	}
+}
+
+func TestRouterHandler_CaptureLimit(t *testing.T) {
+	handler := newCaptureRouter(t, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := strings.NewReader(fmt.Sprintf(`{"n": %d}`, i))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/users", body))
+		}(i)
+	}
+	wg.Wait()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/users", strings.NewReader(`{"n": "last"}`)))
+
+	requests := capturedRequests(t, handler, "/api/users")
+	if len(requests) != 3 {
+		t.Fatalf("Expected the buffer to keep 3 requests, got %d", len(requests))
+	}
+	if requests[2].Body != `{"n": "last"}` {
+		t.Errorf("Expected the most recent request last, got %s", requests[2].Body)
+	}
+}
+
+func TestRouterHandler_CaptureDisabled(t *testing.T) {
+	rt := New()
+	if err := rt.RegisterEndpoints([]models.EndpointConfig{{Path: "/api/users", Response: `{}`}}); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, httptest.NewRequest("GET", CapturePath, nil))
+	if w.Code != 404 {
+		t.Errorf("Expected 404 when capture is disabled, got %d", w.Code)
+	}
+}
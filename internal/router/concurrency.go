@@ -0,0 +1,75 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// concurrencyLimiter caps the requests an endpoint serves at once with a
+// semaphore channel holding one token per busy slot
+type concurrencyLimiter struct {
+	slots   chan struct{}
+	queue   bool          // wait for a slot instead of rejecting
+	timeout time.Duration // longest wait for a slot; 0 waits until the client gives up
+}
+
+// newConcurrencyLimiter returns nil when cfg is nil
+func newConcurrencyLimiter(cfg *models.ConcurrencyConfig) *concurrencyLimiter {
+	if cfg == nil || cfg.Max <= 0 {
+		return nil
+	}
+	return &concurrencyLimiter{
+		slots:   make(chan struct{}, cfg.Max),
+		queue:   cfg.GetOverflow() == models.OverflowQueue,
+		timeout: time.Duration(cfg.QueueTimeout) * time.Millisecond,
+	}
+}
+
+// acquire takes a slot for r, reporting false when the request overflowed:
+// every slot was busy and rejection is configured, the queue timeout passed,
+// or the client went away while queued. Callers that got a slot must release it.
+func (l *concurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if !l.queue {
+		return false
+	}
+
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// ServiceUnavailableHandler returns a 503 handler for requests over an
+// endpoint's concurrency limit
+func ServiceUnavailableHandler(limit int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("[503] %s %s exceeds %d concurrent requests", r.Method, r.URL.Path, limit)
+		response := fmt.Sprintf(`{"error":"too many concurrent requests","limit":%d}`, limit)
+		writeError(w, r, http.StatusServiceUnavailable, "too many concurrent requests",
+			fmt.Sprintf("more than %d concurrent requests", limit), response)
+	}
+}
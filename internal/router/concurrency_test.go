@@ -0,0 +1,91 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// fireConcurrently sends n simultaneous requests to handler and counts the
+// responses by status
+func fireConcurrently(handler http.HandlerFunc, n int) map[int]int {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	statuses := make(map[int]int)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", "/api/work", nil))
+			mu.Lock()
+			statuses[w.Code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+func TestHandler_ConcurrencyReject(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:        "/api/work",
+		Method:      "GET",
+		Response:    `{"done": true}`,
+		Delay:       200,
+		RetryAfter:  "1",
+		Concurrency: &models.ConcurrencyConfig{Max: 2},
+	})
+
+	statuses := fireConcurrently(handler, 6)
+	if statuses[200] != 2 || statuses[503] != 4 {
+		t.Errorf("Expected 2 requests served and 4 rejected, got %v", statuses)
+	}
+
+	// Slots are released once requests finish
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/work", nil))
+	if w.Code != 200 {
+		t.Errorf("Expected 200 after the busy requests finished, got %d", w.Code)
+	}
+}
+
+func TestHandler_ConcurrencyQueue(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:        "/api/work",
+		Method:      "GET",
+		Response:    `{"done": true}`,
+		Delay:       50,
+		Concurrency: &models.ConcurrencyConfig{Max: 1, Overflow: models.OverflowQueue},
+	})
+
+	start := time.Now()
+	statuses := fireConcurrently(handler, 3)
+	elapsed := time.Since(start)
+
+	if statuses[200] != 3 {
+		t.Errorf("Expected every queued request to be served, got %v", statuses)
+	}
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("Expected queued requests to be served one at a time, took %v", elapsed)
+	}
+}
+
+func TestHandler_ConcurrencyQueueTimeout(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:        "/api/work",
+		Method:      "GET",
+		Response:    `{"done": true}`,
+		Delay:       300,
+		Concurrency: &models.ConcurrencyConfig{Max: 1, Overflow: models.OverflowQueue, QueueTimeout: 20},
+	})
+
+	statuses := fireConcurrently(handler, 2)
+	if statuses[200] != 1 || statuses[503] != 1 {
+		t.Errorf("Expected one request served and one timed out, got %v", statuses)
+	}
+}
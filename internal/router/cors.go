@@ -0,0 +1,121 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// defaultCORSMethods are allowed when the policy lists none
+var defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// corsPolicy applies a global CORS configuration to responses
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	methods          string
+	headers          string // empty echoes Access-Control-Request-Headers
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// newCORSPolicy builds a policy from cfg, filling in defaults. It returns nil
+// when cfg is nil.
+func newCORSPolicy(cfg *models.CORSConfig) *corsPolicy {
+	if cfg == nil {
+		return nil
+	}
+
+	p := &corsPolicy{
+		origins:          make(map[string]bool, len(cfg.AllowedOrigins)),
+		headers:          strings.Join(cfg.AllowedHeaders, ", "),
+		exposedHeaders:   strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		p.anyOrigin = true
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			p.anyOrigin = true
+		}
+		p.origins[origin] = true
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	upper := make([]string, len(methods))
+	for i, method := range methods {
+		upper[i] = strings.ToUpper(method)
+	}
+	p.methods = strings.Join(upper, ", ")
+
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(cfg.MaxAge)
+	}
+	return p
+}
+
+// isPreflight reports whether r is a CORS preflight request
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// setHeaders adds the CORS response headers for an allowed origin. Requests
+// without an Origin header, or from other origins, get none.
+func (p *corsPolicy) setHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+
+	header := w.Header()
+	// Credentialed requests may not use the "*" wildcard, so the allowed
+	// origin is reflected exactly
+	reflect := !p.anyOrigin || p.allowCredentials
+	if reflect {
+		// Responses differ by origin, including the header-less ones sent to
+		// other origins, so caches must not share them
+		header.Add("Vary", "Origin")
+	}
+	if !p.anyOrigin && !p.origins[origin] {
+		return
+	}
+	if reflect {
+		header.Set("Access-Control-Allow-Origin", origin)
+	} else {
+		header.Set("Access-Control-Allow-Origin", "*")
+	}
+	if p.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if p.exposedHeaders != "" {
+		header.Set("Access-Control-Expose-Headers", p.exposedHeaders)
+	}
+}
+
+// preflight answers a preflight request with 204 and the allowed methods
+// and headers
+func (p *corsPolicy) preflight(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	if header.Get("Access-Control-Allow-Origin") != "" {
+		header.Set("Access-Control-Allow-Methods", p.methods)
+		if p.headers != "" {
+			header.Set("Access-Control-Allow-Headers", p.headers)
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+		if p.maxAge != "" {
+			header.Set("Access-Control-Max-Age", p.maxAge)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
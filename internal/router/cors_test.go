@@ -0,0 +1,177 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// newCORSRouter creates a router with a CORS policy, a REST endpoint and a
+// GraphQL endpoint
+func newCORSRouter(t *testing.T, cfg *models.CORSConfig, endpoints ...models.EndpointConfig) http.Handler {
+	t.Helper()
+
+	rt := New()
+	rt.SetCORS(cfg)
+	rt.RegisterHealthCheck()
+	endpoints = append(endpoints, models.EndpointConfig{Path: "/api/users", Method: "GET", Response: `{"users": []}`})
+	if err := rt.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+	rt.RegisterGraphQL("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	})
+	return rt.Handler()
+}
+
+func TestRouterHandler_CORS(t *testing.T) {
+	handler := newCORSRouter(t, &models.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		ExposedHeaders: []string{"X-Total-Count"},
+	})
+
+	for _, tt := range []struct{ method, path string }{
+		{"GET", "/api/users"},
+		{"POST", "/graphql"},
+		{"GET", "/health"},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Errorf("%s %s: expected status 200, got %d", tt.method, tt.path, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("%s %s: expected allowed origin to be echoed, got %q", tt.method, tt.path, got)
+		}
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+			t.Errorf("%s %s: expected exposed headers, got %q", tt.method, tt.path, got)
+		}
+	}
+
+	// Other origins get no CORS headers
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no CORS headers for disallowed origin, got %q", got)
+	}
+}
+
+func TestRouterHandler_CORSPreflight(t *testing.T) {
+	handler := newCORSRouter(t, &models.CORSConfig{MaxAge: 600})
+
+	for _, path := range []string{"/api/users", "/graphql"} {
+		req := httptest.NewRequest("OPTIONS", path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != 204 {
+			t.Errorf("%s: expected status 204, got %d", path, w.Code)
+		}
+		expected := map[string]string{
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type",
+			"Access-Control-Max-Age":       "600",
+		}
+		for key, value := range expected {
+			if got := w.Header().Get(key); got != value {
+				t.Errorf("%s: expected %s %q, got %q", path, key, value, got)
+			}
+		}
+	}
+}
+
+func TestRouterHandler_CORSEndpointOverrides(t *testing.T) {
+	handler := newCORSRouter(t, &models.CORSConfig{},
+		models.EndpointConfig{
+			Path:     "/api/private",
+			Method:   "GET",
+			Response: `{}`,
+			Headers:  map[string]string{"Access-Control-Allow-Origin": "https://admin.example.com"},
+		},
+		models.EndpointConfig{Path: "/api/private", Method: "OPTIONS", Status: 200, Response: `{"custom": true}`},
+	)
+
+	req := httptest.NewRequest("GET", "/api/private", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+		t.Errorf("Expected endpoint header to override the policy, got %q", got)
+	}
+
+	// An OPTIONS endpoint answers its own preflights
+	req = httptest.NewRequest("OPTIONS", "/api/private", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != 200 || w.Body.String() != `{"custom": true}` {
+		t.Errorf("Expected OPTIONS endpoint to handle preflight, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRouterHandler_CORSCredentials(t *testing.T) {
+	handler := newCORSRouter(t, &models.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://admin.example.com"},
+		AllowCredentials: true,
+	})
+
+	// An allowed origin is reflected exactly, never as "*", with credentials
+	for _, method := range []string{"GET", "OPTIONS"} {
+		req := httptest.NewRequest(method, "/api/users", nil)
+		req.Header.Set("Origin", "https://admin.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+			t.Errorf("%s: expected the exact origin, got %q", method, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("%s: expected credentials to be allowed, got %q", method, got)
+		}
+		if got := w.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("%s: expected Vary: Origin, got %q", method, got)
+		}
+	}
+
+	// A disallowed origin gets no CORS headers, but the response still
+	// varies by origin
+	for _, method := range []string{"GET", "OPTIONS"} {
+		req := httptest.NewRequest(method, "/api/users", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		for _, key := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Allow-Methods"} {
+			if got := w.Header().Get(key); got != "" {
+				t.Errorf("%s: expected no %s for a disallowed origin, got %q", method, key, got)
+			}
+		}
+		if got := w.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("%s: expected Vary: Origin for a disallowed origin, got %q", method, got)
+		}
+	}
+
+	// With any origin allowed, credentials still require reflecting it
+	handler = newCORSRouter(t, &models.CORSConfig{AllowCredentials: true})
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	req.Header.Set("Origin", "https://other.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://other.example.com" {
+		t.Errorf("Expected the origin to be reflected with credentials, got %q", got)
+	}
+}
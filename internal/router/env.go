@@ -0,0 +1,63 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envPattern matches environment variable tokens such as {{env.REGION}}
+var envPattern = regexp.MustCompile(`\{\{env\.([A-Za-z_][A-Za-z0-9_]*)\}\}`)
+
+// templateEnvKey is the context key for the environment variables the
+// router lets templates read
+type templateEnvKey struct{}
+
+// SetTemplateEnv sets the environment variables response templates may read
+// with {{env.NAME}}. Entries are exact names, or prefixes ending in "*" such
+// as "APP_*"; "*" allows every variable. Tokens naming any other variable
+// render as an empty string, so secrets in the server's environment are not
+// exposed by accident. Nothing is exposed until it is called.
+func (rt *Router) SetTemplateEnv(names []string) {
+	rt.templateEnv = append([]string(nil), names...)
+}
+
+// withTemplateEnv returns r carrying the environment variables templates may
+// read while serving it
+func withTemplateEnv(r *http.Request, names []string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), templateEnvKey{}, names))
+}
+
+// envAllowed reports whether templates rendered for r may read the
+// environment variable name
+func envAllowed(r *http.Request, name string) bool {
+	allowedNames, _ := r.Context().Value(templateEnvKey{}).([]string)
+	for _, allowed := range allowedNames {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renderEnvTokens replaces {{env.NAME}} tokens with the variable's current
+// value, read at request time. Unset and disallowed variables are replaced
+// with an empty string.
+func renderEnvTokens(response string, r *http.Request) string {
+	if !strings.Contains(response, "{{env.") {
+		return response
+	}
+	return envPattern.ReplaceAllStringFunc(response, func(token string) string {
+		name := envPattern.FindStringSubmatch(token)[1]
+		if !envAllowed(r, name) {
+			return ""
+		}
+		return os.Getenv(name)
+	})
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestProcessResponse_Env(t *testing.T) {
+	t.Setenv("MOCK_REGION", "eu-west-1")
+	t.Setenv("MOCK_EMPTY", "")
+	req := withTemplateEnv(httptest.NewRequest("GET", "/api/region", nil), []string{"MOCK_REGION", "MOCK_UNSET", "MOCK_EMPTY"})
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{"region": "{{env.MOCK_REGION}}"}`, `{"region": "eu-west-1"}`},
+		{`{"zone": "{{env.MOCK_UNSET}}"}`, `{"zone": ""}`},
+		{`{"empty": "{{env.MOCK_EMPTY}}"}`, `{"empty": ""}`},
+	}
+	for _, tt := range tests {
+		if got := processResponse(tt.template, req); got != tt.expected {
+			t.Errorf("processResponse(%s) = %s, expected %s", tt.template, got, tt.expected)
+		}
+	}
+
+	// The value is read when the request is served, not when the template is loaded
+	t.Setenv("MOCK_REGION", "us-east-2")
+	if got := processResponse(`{{env.MOCK_REGION}}`, req); got != "us-east-2" {
+		t.Errorf("Expected the current value us-east-2, got %s", got)
+	}
+}
+
+func TestProcessResponse_EnvAllowlist(t *testing.T) {
+	t.Setenv("MOCK_REGION", "eu-west-1")
+	t.Setenv("MOCK_SECRET", "hunter2")
+	t.Setenv("APP_NAME", "billing")
+
+	tests := []struct {
+		name     string
+		allowed  []string
+		template string
+		expected string
+	}{
+		{"nothing allowed by default", nil, `{{env.MOCK_REGION}}`, ""},
+		{"unlisted variable", []string{"MOCK_REGION"}, `{{env.MOCK_SECRET}}`, ""},
+		{"listed variable", []string{"MOCK_REGION"}, `{{env.MOCK_REGION}}`, "eu-west-1"},
+		{"prefix", []string{"APP_*"}, `{{env.APP_NAME}}/{{env.MOCK_REGION}}`, "billing/"},
+		{"everything", []string{"*"}, `{{env.MOCK_SECRET}}`, "hunter2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withTemplateEnv(httptest.NewRequest("GET", "/api/env", nil), tt.allowed)
+			if got := processResponse(tt.template, req); got != tt.expected {
+				t.Errorf("processResponse(%s) = %q, expected %q", tt.template, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessResponse_EnvNotInjectable(t *testing.T) {
+	t.Setenv("MOCK_SECRET", "hunter2")
+	// A request value containing an env token is inserted as written
+	req := withTemplateEnv(httptest.NewRequest("GET", "/api/echo?q=%7B%7Benv.MOCK_SECRET%7D%7D", nil), []string{"MOCK_SECRET"})
+	if got := processResponse(`{{query.q}}`, req); got != "{{env.MOCK_SECRET}}" {
+		t.Errorf("Expected the query value to be left as written, got %s", got)
+	}
+}
+
+func TestRouter_SetTemplateEnvPerRouter(t *testing.T) {
+	t.Setenv("MOCK_REGION", "eu-west-1")
+	endpoint := models.EndpointConfig{Path: "/api/region", Response: `{{env.MOCK_REGION}}`}
+
+	// Each router keeps its own safelist, so embedded servers don't interfere
+	allowing, denying := New(), New()
+	allowing.SetTemplateEnv([]string{"MOCK_REGION"})
+	for _, rt := range []*Router{allowing, denying} {
+		if err := rt.RegisterEndpoint(endpoint); err != nil {
+			t.Fatalf("RegisterEndpoint failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		rt       *Router
+		expected string
+	}{
+		{allowing, "eu-west-1"},
+		{denying, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.rt.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/region", nil))
+		if w.Body.String() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// errorEnvelope renders the server's own error responses from a configured
+// template
+type errorEnvelope struct {
+	template    string
+	contentType string
+	// Substituted error values are JSON-escaped for JSON content types
+	json bool
+}
+
+// errorEnvelopeKey is the context key for the router's error envelope
+type errorEnvelopeKey struct{}
+
+// newErrorEnvelope creates the envelope for cfg, or nil when cfg is nil
+func newErrorEnvelope(cfg *models.ErrorsConfig) *errorEnvelope {
+	if cfg == nil {
+		return nil
+	}
+	contentType := cfg.GetContentType()
+	return &errorEnvelope{
+		template:    cfg.Template,
+		contentType: contentType,
+		json:        strings.Contains(strings.ToLower(contentType), "json"),
+	}
+}
+
+// render fills in the template for one error. Request variables are
+// substituted first, so request values can never be mistaken for error
+// tokens.
+func (e *errorEnvelope) render(r *http.Request, status int, message, detail string) string {
+	escape := func(s string) string {
+		if !e.json {
+			return s
+		}
+		encoded, _ := json.Marshal(s)
+		return string(encoded[1 : len(encoded)-1])
+	}
+	return strings.NewReplacer(
+		"{{error.status}}", strconv.Itoa(status),
+		"{{error.title}}", escape(http.StatusText(status)),
+		"{{error.message}}", escape(message),
+		"{{error.detail}}", escape(detail),
+	).Replace(processTemplate(e.template, r, e.json))
+}
+
+// writeError writes an error response using the router's error envelope when
+// one is configured, and body as JSON otherwise. message is a short
+// description of the error; detail describes this occurrence of it.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message, detail, body string) {
+	contentType := "application/json"
+	if envelope, ok := r.Context().Value(errorEnvelopeKey{}).(*errorEnvelope); ok {
+		body = envelope.render(r, status, message, detail)
+		contentType = envelope.contentType
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(body)); err != nil {
+		log.Printf("Failed to write %d response: %v", status, err)
+	}
+}
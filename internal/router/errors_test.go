@@ -0,0 +1,118 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// problemEnvelope is an RFC 7807 problem+json error envelope
+var problemEnvelope = &models.ErrorsConfig{
+	ContentType: "application/problem+json",
+	Template:    `{"type": "about:blank", "title": "{{error.title}}", "status": {{error.status}}, "detail": "{{error.detail}}", "instance": "{{path}}"}`,
+}
+
+func TestRouterHandler_ErrorEnvelope(t *testing.T) {
+	router := New()
+	router.SetErrorEnvelope(problemEnvelope)
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: "/api/users", Method: "GET", Response: `{"users": []}`}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		title  string
+		detail string
+	}{
+		{"GET", "/api/missing", 404, "Not Found", "no endpoint for GET /api/missing"},
+		{"DELETE", "/api/users", 405, "Method Not Allowed", "DELETE is not allowed for /api/users; allowed: GET"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+			t.Errorf("%s %s: expected Content-Type application/problem+json, got %s", tt.method, tt.path, contentType)
+		}
+
+		var problem map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s %s: failed to parse %s: %v", tt.method, tt.path, w.Body.String(), err)
+		}
+		if problem["status"] != float64(tt.status) || problem["title"] != tt.title || problem["detail"] != tt.detail || problem["instance"] != tt.path {
+			t.Errorf("%s %s: unexpected envelope %v", tt.method, tt.path, problem)
+		}
+	}
+
+	// The 405 still lists the allowed methods
+	req := httptest.NewRequest("DELETE", "/api/users", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+	if allow := w.Header().Get("Allow"); allow != "GET" {
+		t.Errorf("Expected Allow: GET, got %q", allow)
+	}
+}
+
+func TestRouterHandler_ErrorEnvelopeBodyLimit(t *testing.T) {
+	router := New()
+	router.SetMaxBodyBytes(4)
+	router.SetErrorEnvelope(&models.ErrorsConfig{Template: `{"error": {"code": {{error.status}}, "message": "{{error.message}}"}}`})
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: "/api/upload", Method: "POST"}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/upload", strings.NewReader("too large"))
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	expected := `{"error": {"code": 413, "message": "request body too large"}}`
+	if w.Code != 413 || w.Body.String() != expected {
+		t.Errorf("Expected 413 %s, got %d %s", expected, w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected default Content-Type application/json, got %s", contentType)
+	}
+}
+
+func TestRouterHandler_ErrorEnvelopeEscaping(t *testing.T) {
+	router := New()
+	router.SetErrorEnvelope(&models.ErrorsConfig{Template: `{"detail": "{{error.detail}}"}`})
+
+	// A quote in the path must not break the JSON envelope
+	req := httptest.NewRequest("GET", `/api/"quoted"`, nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	var problem map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Expected valid JSON, got %s: %v", w.Body.String(), err)
+	}
+	if problem["detail"] != `no endpoint for GET /api/"quoted"` {
+		t.Errorf("Unexpected detail %v", problem["detail"])
+	}
+}
+
+func TestRouterHandler_ErrorEnvelopePrecedence(t *testing.T) {
+	router := New()
+	router.SetErrorEnvelope(problemEnvelope)
+	router.SetErrorResponses(`{"missing": "{{path}}"}`, "")
+
+	req := httptest.NewRequest("GET", "/api/missing", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	// not_found_response is more specific than the envelope
+	if w.Code != 404 || w.Body.String() != `{"missing": "/api/missing"}` {
+		t.Errorf("Expected the not_found_response body, got %d %s", w.Code, w.Body.String())
+	}
+}
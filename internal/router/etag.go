@@ -0,0 +1,50 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// computeETag returns a strong entity tag derived from a hash of body
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// sendsETag reports whether a response with status carries an ETag; only
+// successful responses can be revalidated
+func sendsETag(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// notModified reports whether r is a GET or HEAD whose If-None-Match header
+// matches etag, so a 304 can be sent instead of the body
+func notModified(r *http.Request, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	// If-None-Match uses the weak comparison: W/ prefixes are ignored
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified sends a 304 with the validator headers already set on w
+// and no body
+func writeNotModified(w http.ResponseWriter) {
+	w.Header().Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+}
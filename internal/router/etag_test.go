@@ -0,0 +1,109 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestHandler_ETag(t *testing.T) {
+	endpoint := models.EndpointConfig{Path: "/api/users", Method: "GET", Response: `{"users": []}`, ETag: true}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"standard": Handler(endpoint),
+		"bench":    BenchHandler(endpoint),
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", "/api/users", nil))
+			etag := w.Header().Get("ETag")
+			if w.Code != 200 || etag == "" {
+				t.Fatalf("Expected 200 with an ETag, got %d with ETag %q", w.Code, etag)
+			}
+			if etag != computeETag([]byte(`{"users": []}`)) {
+				t.Errorf("Expected the ETag of the body, got %s", etag)
+			}
+
+			req := httptest.NewRequest("GET", "/api/users", nil)
+			req.Header.Set("If-None-Match", `"other", `+etag)
+			w = httptest.NewRecorder()
+			handler(w, req)
+			if w.Code != http.StatusNotModified {
+				t.Fatalf("Expected 304 for a matching If-None-Match, got %d", w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("Expected no body with 304, got %s", w.Body.String())
+			}
+			if w.Header().Get("ETag") != etag {
+				t.Errorf("Expected 304 to repeat the ETag, got %q", w.Header().Get("ETag"))
+			}
+
+			req = httptest.NewRequest("GET", "/api/users", nil)
+			req.Header.Set("If-None-Match", `"stale"`)
+			w = httptest.NewRecorder()
+			handler(w, req)
+			if w.Code != 200 || w.Body.String() != `{"users": []}` {
+				t.Errorf("Expected 200 with the body for a stale ETag, got %d %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ETagTemplated(t *testing.T) {
+	handler := Handler(models.EndpointConfig{Path: "/api/users/", Method: "GET", Response: `{"path": "{{path}}"}`, ETag: true})
+
+	etags := make(map[string]string)
+	for _, path := range []string{"/api/users/1", "/api/users/2"} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", path, nil))
+		etags[path] = w.Header().Get("ETag")
+		if etags[path] != computeETag(w.Body.Bytes()) {
+			t.Errorf("Expected the ETag of the rendered body for %s, got %s", path, etags[path])
+		}
+	}
+	if etags["/api/users/1"] == etags["/api/users/2"] {
+		t.Errorf("Expected different bodies to have different ETags, got %s", etags["/api/users/1"])
+	}
+
+	req := httptest.NewRequest("GET", "/api/users/1", nil)
+	req.Header.Set("If-None-Match", etags["/api/users/2"])
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != 200 {
+		t.Errorf("Expected 200 when another body's ETag is sent, got %d", w.Code)
+	}
+}
+
+func TestHandler_ETagOnlyForSuccess(t *testing.T) {
+	handler := Handler(models.EndpointConfig{Path: "/api/users", Method: "GET", Status: 404, Response: `{"error": "not found"}`, ETag: true})
+
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	req.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != 404 || w.Header().Get("ETag") != "" {
+		t.Errorf("Expected a plain 404 without ETag, got %d with ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestHandler_ETagGRPCWeb(t *testing.T) {
+	handler := Handler(models.EndpointConfig{Path: "/greeter.Greeter/SayHello", Method: "POST", Type: models.TypeGRPCWeb, Response: "hello", ETag: true})
+
+	etags := make(map[string]string)
+	for _, contentType := range []string{grpcWebContentType, grpcWebTextContentType} {
+		req := httptest.NewRequest("POST", "/greeter.Greeter/SayHello", nil)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		etags[contentType] = w.Header().Get("ETag")
+		if etags[contentType] != computeETag(w.Body.Bytes()) {
+			t.Errorf("Expected the ETag of the framed %s body, got %s", contentType, etags[contentType])
+		}
+	}
+	if etags[grpcWebContentType] == etags[grpcWebTextContentType] {
+		t.Errorf("Expected binary and text framing to have different ETags, got %s", etags[grpcWebContentType])
+	}
+}
@@ -0,0 +1,161 @@
+package router
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// funcTokenPattern matches function call tokens such as {{upper .Query.name}}.
+// Only tokens whose first word is a registered function are evaluated; the
+// built-in variables ({{path}}, {{query.id}}, ...) never match one.
+var funcTokenPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)(\s[^{}]*)?\}\}`)
+
+// reservedTokens are built-in template variables that cannot be shadowed by
+// a registered function
+var reservedTokens = map[string]bool{
+	"path": true, "method": true, "body": true, "counter": true,
+	"now": true, "request_id": true, "allowed": true, "padding": true,
+}
+
+// builtinFuncs are the functions text/template predefines, such as
+// {{index .Headers "X-Trace"}} and {{printf "%05d" .Body.id}}
+var builtinFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+var (
+	templateFuncsMu sync.RWMutex
+	// templateFuncs holds the functions callable from response templates,
+	// starting with the default set
+	templateFuncs = template.FuncMap{
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		"replace": func(s, old, new string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+		"default": func(fallback string, value interface{}) interface{} {
+			if value == nil || value == "" {
+				return fallback
+			}
+			return value
+		},
+		"json": func(value interface{}) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+		"base64": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+	}
+)
+
+// RegisterTemplateFunc makes fn callable from response templates as
+// {{name arg...}}. fn must be a function returning one value, or a value and
+// an error, as required by text/template. Registering an existing name
+// replaces it. Call it before registering endpoints.
+func RegisterTemplateFunc(name string, fn interface{}) (err error) {
+	if !funcTokenPattern.MatchString("{{" + name + "}}") {
+		return fmt.Errorf("invalid template function name %q", name)
+	}
+	if reservedTokens[name] {
+		return fmt.Errorf("template function name %q is a built-in variable", name)
+	}
+
+	// template.Funcs panics on functions it cannot call
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("invalid template function %q: %v", name, recovered)
+		}
+	}()
+	template.New(name).Funcs(template.FuncMap{name: fn})
+
+	templateFuncsMu.Lock()
+	defer templateFuncsMu.Unlock()
+	templateFuncs[name] = fn
+	return nil
+}
+
+// templateData is the data available to function tokens, e.g. .Query.id
+type templateData struct {
+	Path    string
+	Method  string
+	Query   map[string]string
+	Headers map[string]string
+	Body    interface{}
+}
+
+// newTemplateData collects the first value of each query parameter and
+// header, and the parsed body (nil when absent)
+func newTemplateData(r *http.Request, body interface{}) templateData {
+	data := templateData{
+		Path:    r.URL.Path,
+		Method:  r.Method,
+		Query:   make(map[string]string),
+		Headers: make(map[string]string, len(r.Header)),
+		Body:    body,
+	}
+	for key, values := range r.URL.Query() {
+		if len(values) > 0 {
+			data.Query[key] = values[0]
+		}
+	}
+	for key, values := range r.Header {
+		if len(values) > 0 {
+			data.Headers[key] = values[0]
+		}
+	}
+	return data
+}
+
+// renderFuncTokens evaluates every function call token in response with
+// text/template. Tokens naming no registered function are left untouched; a
+// failing call is logged and replaced with an empty string. With escape set,
+// the result is JSON-escaped like any other request value, except for json
+// calls whose result is already JSON.
+func renderFuncTokens(response string, r *http.Request, body interface{}, escape bool) string {
+	if !strings.Contains(response, "{{") {
+		return response
+	}
+
+	templateFuncsMu.RLock()
+	defer templateFuncsMu.RUnlock()
+
+	var data *templateData
+	return funcTokenPattern.ReplaceAllStringFunc(response, func(token string) string {
+		name := funcTokenPattern.FindStringSubmatch(token)[1]
+		if _, ok := templateFuncs[name]; !ok && !builtinFuncs[name] {
+			return token
+		}
+		if data == nil {
+			d := newTemplateData(r, body)
+			data = &d
+		}
+
+		tmpl, err := template.New("response").Funcs(templateFuncs).Parse(token)
+		if err != nil {
+			log.Printf("Invalid template function call %s: %v", token, err)
+			return ""
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			log.Printf("Template function call %s failed: %v", token, err)
+			return ""
+		}
+		if escape && name != "json" {
+			return jsonEscape(buf.String())
+		}
+		return buf.String()
+	})
+}
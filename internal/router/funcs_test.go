@@ -0,0 +1,93 @@
+package router
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestRegisterTemplateFunc(t *testing.T) {
+	if err := RegisterTemplateFunc("shout", func(s string) string {
+		return strings.ToUpper(s) + "!"
+	}); err != nil {
+		t.Fatalf("RegisterTemplateFunc failed: %v", err)
+	}
+
+	handler := Handler(models.EndpointConfig{
+		Path:     "/api/greet",
+		Method:   "POST",
+		Response: `{"greeting": "{{shout .Query.name}}", "from": "{{shout .Body.from}}", "path": "{{path}}"}`,
+	})
+
+	req := httptest.NewRequest("POST", "/api/greet?name=alice", strings.NewReader(`{"from": "bob"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	expected := `{"greeting": "ALICE!", "from": "BOB!", "path": "/api/greet"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestRegisterTemplateFunc_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   interface{}
+	}{
+		{"path", strings.ToUpper},
+		{"padding", strings.ToUpper},
+		{"not-valid", strings.ToUpper},
+		{"notAFunc", "value"},
+		{"tooManyResults", func() (string, string, error) { return "", "", nil }},
+	}
+	for _, tt := range tests {
+		if err := RegisterTemplateFunc(tt.name, tt.fn); err == nil {
+			t.Errorf("Expected error registering %q, got nil", tt.name)
+		}
+	}
+}
+
+func TestProcessResponse_DefaultFuncs(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/test?name=Alice&q=a+b", nil)
+	req.Header.Set("X-Trace", "abc")
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{{lower .Query.name}}`, "alice"},
+		{`{{default "anonymous" .Query.missing}}`, "anonymous"},
+		{`{{base64 .Query.name}}`, "QWxpY2U="},
+		{`{{replace .Query.q " " "_"}}`, "a_b"},
+		{`{{index .Headers "X-Trace"}}`, "abc"},
+		{`{{json .Query.name}}`, `"Alice"`},
+		// Unknown functions are left as written
+		{`{{unknown .Query.name}}`, `{{unknown .Query.name}}`},
+	}
+	for _, tt := range tests {
+		if got := processResponse(tt.template, req); got != tt.expected {
+			t.Errorf("processResponse(%s) = %s, expected %s", tt.template, got, tt.expected)
+		}
+	}
+}
+
+func TestProcessResponse_FuncTokensEscaped(t *testing.T) {
+	req := httptest.NewRequest("GET", `/api/test?q=a"b`, nil)
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{"q":"{{upper .Query.q}}"}`, `{"q":"A\"B"}`},
+		{`{"q":{{json .Query.q}}}`, `{"q":"a\"b"}`},
+		{`{{raw:upper .Query.q}}`, `A"B`},
+	}
+	for _, tt := range tests {
+		if got := processResponse(tt.template, req); got != tt.expected {
+			t.Errorf("processResponse(%s) = %s, expected %s", tt.template, got, tt.expected)
+		}
+	}
+}
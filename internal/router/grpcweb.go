@@ -0,0 +1,91 @@
+package router
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gRPC-Web frame flags: a data frame carries a message, a trailer frame the
+// call's status
+const (
+	grpcWebDataFrame    byte = 0x00
+	grpcWebTrailerFrame byte = 0x80
+)
+
+// gRPC-Web content types. The text variant base64-encodes the frames for
+// clients that cannot read binary bodies.
+const (
+	grpcWebContentType     = "application/grpc-web+proto"
+	grpcWebTextContentType = "application/grpc-web-text+proto"
+)
+
+// grpcWebFrame prefixes payload with its gRPC-Web frame header: the flag
+// byte and the payload length as a big-endian uint32
+func grpcWebFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+// grpcWebResponse encodes message as a unary gRPC-Web response: a data frame
+// with the message, then a trailer frame with the status. Calls that fail
+// (status other than 0) carry no message. Requests sent as grpc-web-text get
+// the base64-encoded text variant. It also sets the response Content-Type.
+func grpcWebResponse(w http.ResponseWriter, r *http.Request, message []byte, status int, statusMessage string) []byte {
+	var body []byte
+	if status == 0 {
+		body = grpcWebFrame(grpcWebDataFrame, message)
+	}
+
+	trailers := "grpc-status:" + strconv.Itoa(status) + "\r\n"
+	if statusMessage != "" {
+		trailers += "grpc-message:" + encodeGRPCMessage(statusMessage) + "\r\n"
+	}
+	body = append(body, grpcWebFrame(grpcWebTrailerFrame, []byte(trailers))...)
+
+	if isGRPCWebText(r) {
+		w.Header().Set("Content-Type", grpcWebTextContentType)
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(body)))
+		base64.StdEncoding.Encode(encoded, body)
+		return encoded
+	}
+	w.Header().Set("Content-Type", grpcWebContentType)
+	return body
+}
+
+// isGRPCWebText reports whether the request uses the base64 text variant of
+// gRPC-Web, by its Content-Type or, failing that, its Accept header
+func isGRPCWebText(r *http.Request) bool {
+	for _, value := range []string{r.Header.Get("Content-Type"), r.Header.Get("Accept")} {
+		if value == "" {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(value)
+		if err != nil {
+			continue
+		}
+		return strings.HasPrefix(mediaType, "application/grpc-web-text")
+	}
+	return false
+}
+
+// encodeGRPCMessage percent-encodes a grpc-message value as gRPC requires:
+// every byte outside printable ASCII, and "%" itself
+func encodeGRPCMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
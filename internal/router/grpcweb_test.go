@@ -0,0 +1,138 @@
+package router
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// grpcWebFrames splits a gRPC-Web body into its messages and trailers
+func grpcWebFrames(t *testing.T, body []byte) ([][]byte, map[string]string) {
+	t.Helper()
+
+	var messages [][]byte
+	trailers := map[string]string{}
+	for len(body) > 0 {
+		if len(body) < 5 {
+			t.Fatalf("Truncated frame header: %x", body)
+		}
+		flag, length := body[0], binary.BigEndian.Uint32(body[1:5])
+		if uint32(len(body)-5) < length {
+			t.Fatalf("Frame length %d exceeds remaining %d bytes", length, len(body)-5)
+		}
+		payload := body[5 : 5+length]
+		body = body[5+length:]
+
+		if flag&grpcWebTrailerFrame == 0 {
+			messages = append(messages, payload)
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(payload)), "\r\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				t.Fatalf("Malformed trailer %q", line)
+			}
+			trailers[key] = value
+		}
+		if len(body) > 0 {
+			t.Fatalf("Unexpected data after the trailer frame: %x", body)
+		}
+	}
+	return messages, trailers
+}
+
+// userMessage is a serialized protobuf message: field 1 (id) = 150,
+// field 2 (name) = "Alice"
+var userMessage = []byte{0x08, 0x96, 0x01, 0x12, 0x05, 'A', 'l', 'i', 'c', 'e'}
+
+func TestHandler_GRPCWeb(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:           "/users.UserService/GetUser",
+		Method:         "POST",
+		Type:           models.TypeGRPCWeb,
+		ResponseBase64: base64.StdEncoding.EncodeToString(userMessage),
+	})
+
+	req := httptest.NewRequest("POST", "/users.UserService/GetUser", strings.NewReader("\x00\x00\x00\x00\x00"))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/grpc-web+proto" {
+		t.Errorf("Expected Content-Type application/grpc-web+proto, got %s", contentType)
+	}
+
+	messages, trailers := grpcWebFrames(t, w.Body.Bytes())
+	if len(messages) != 1 || string(messages[0]) != string(userMessage) {
+		t.Errorf("Expected the configured message, got %x", messages)
+	}
+	if trailers["grpc-status"] != "0" {
+		t.Errorf("Expected grpc-status 0, got %v", trailers)
+	}
+}
+
+func TestHandler_GRPCWebStatus(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:        "/users.UserService/GetUser",
+		Method:      "POST",
+		Type:        models.TypeGRPCWeb,
+		GRPCStatus:  5,
+		GRPCMessage: "user 100% not found",
+	})
+
+	req := httptest.NewRequest("POST", "/users.UserService/GetUser", nil)
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	// gRPC errors travel in the trailers of a 200 response, without a message
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	messages, trailers := grpcWebFrames(t, w.Body.Bytes())
+	if len(messages) != 0 {
+		t.Errorf("Expected no message for a failed call, got %x", messages)
+	}
+	if trailers["grpc-status"] != "5" {
+		t.Errorf("Expected grpc-status 5, got %v", trailers)
+	}
+	if trailers["grpc-message"] != "user 100%25 not found" {
+		t.Errorf("Expected percent-encoded grpc-message, got %q", trailers["grpc-message"])
+	}
+}
+
+func TestHandler_GRPCWebText(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:     "/greet.Greeter/SayHello",
+		Method:   "POST",
+		Type:     models.TypeGRPCWeb,
+		Response: "\n\x05hello",
+	})
+
+	req := httptest.NewRequest("POST", "/greet.Greeter/SayHello", nil)
+	req.Header.Set("Content-Type", "application/grpc-web-text")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/grpc-web-text+proto" {
+		t.Errorf("Expected Content-Type application/grpc-web-text+proto, got %s", contentType)
+	}
+	body, err := base64.StdEncoding.DecodeString(w.Body.String())
+	if err != nil {
+		t.Fatalf("Expected a base64 body, got %q: %v", w.Body.String(), err)
+	}
+	messages, trailers := grpcWebFrames(t, body)
+	if len(messages) != 1 || string(messages[0]) != "\n\x05hello" {
+		t.Errorf("Expected the configured message, got %q", messages)
+	}
+	if trailers["grpc-status"] != "0" {
+		t.Errorf("Expected grpc-status 0, got %v", trailers)
+	}
+}
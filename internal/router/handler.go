@@ -1,84 +1,851 @@
 package router
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/jimbo/blandmockapi/internal/jsonpath"
 	"github.com/jimbo/blandmockapi/internal/models"
 )
 
 // Handler creates an HTTP handler for a configured endpoint
 func Handler(endpoint models.EndpointConfig) http.HandlerFunc {
+	return newHandler(endpoint, newAccessLogger(nil), nil)
+}
+
+// BenchHandler creates a handler tuned for throughput benchmarking.
+// Requests are not logged, and endpoints whose response contains no template
+// tokens are served from bytes precomputed at registration time.
+func BenchHandler(endpoint models.EndpointConfig) http.HandlerFunc {
+	return benchHandler(endpoint, nil)
+}
+
+// benchHandler creates a BenchHandler drawing random numbers from random
+func benchHandler(endpoint models.EndpointConfig, random *randomSource) http.HandlerFunc {
+	if endpoint.NeedsDynamicHandler() {
+		return newHandler(endpoint, nil, random)
+	}
+
+	header := http.Header{}
+	status := endpoint.Status
+	if status == 0 {
+		status = 200
+	}
+	body := binaryResponse(endpoint)
+	if body == nil {
+		body = []byte(endpoint.Response)
+	}
+
+	if endpoint.RetryAfter != "" && sendsRetryAfter(endpoint.Status) {
+		header.Set("Retry-After", string(endpoint.RetryAfter))
+	}
+	if endpoint.ETag && sendsETag(status) {
+		header.Set("ETag", computeETag(body))
+	}
+	for key, value := range endpoint.Headers {
+		header.Set(key, value)
+	}
+	for _, h := range endpoint.HeaderList {
+		header.Add(h.Name, h.Value)
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", defaultContentType(endpoint))
+	}
+	etag := header.Get("ETag")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		dst := w.Header()
+		for key, values := range header {
+			dst[key] = values
+		}
+		if endpoint.ETag && sendsETag(status) && notModified(r, etag) {
+			writeNotModified(w)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}
+}
+
+// newHandler builds the standard endpoint handler, logging requests through
+// accessLog unless it is nil and drawing random numbers from random
+func newHandler(endpoint models.EndpointConfig, accessLog *accessLogger, random *randomSource) http.HandlerFunc {
+	// Proxied endpoints answer from their upstream
+	if endpoint.Proxy != nil {
+		return proxyHandler(endpoint, accessLog)
+	}
+
+	variants := newWeightedVariants(endpoint.Responses)
+	localized := newLocalizedResponses(endpoint.Localized)
+	latency := newLatencyDistribution(endpoint.Latency)
+	delayWhen := newDelayRules(endpoint.DelayWhen)
+	limiter := newConcurrencyLimiter(endpoint.Concurrency)
+	quota := newQuotaCounter(endpoint.Quota)
+	timeout := time.Duration(endpoint.TimeoutMS) * time.Millisecond
+	// Cookie and header values and the gRPC message are templated like the body
+	valueTemplates := make([]string, 0, len(endpoint.Cookies)+len(endpoint.Headers)+len(endpoint.HeaderList)+1)
+	for _, cookie := range endpoint.Cookies {
+		valueTemplates = append(valueTemplates, cookie.Value)
+	}
+	for _, value := range endpoint.Headers {
+		valueTemplates = append(valueTemplates, value)
+	}
+	for _, header := range endpoint.HeaderList {
+		valueTemplates = append(valueTemplates, header.Value)
+	}
+	for _, variant := range endpoint.Responses {
+		for _, value := range variant.Headers {
+			valueTemplates = append(valueTemplates, value)
+		}
+	}
+	if endpoint.GRPCMessage != "" {
+		valueTemplates = append(valueTemplates, endpoint.GRPCMessage)
+	}
+	if endpoint.Callback != nil {
+		valueTemplates = append(valueTemplates, endpoint.Callback.URL, endpoint.Callback.Body)
+		for _, value := range endpoint.Callback.Headers {
+			valueTemplates = append(valueTemplates, value)
+		}
+	}
+
+	// Per-endpoint sequence backing {{counter}}, shared across concurrent requests
+	var counter atomic.Int64
+
+	// Raw bytes served instead of a templated response; nil when unset
+	binary := binaryResponse(endpoint)
+
+	// Endpoints with templating disabled serve every value as written
+	templated := endpoint.IsTemplated()
+
+	// The ETag of a body that never changes is computed once. gRPC-Web
+	// bodies are framed per request, as binary or base64 text.
+	var staticETag string
+	if endpoint.ETag && variants == nil && localized == nil && endpoint.AnonymousResponse == "" && endpoint.PadToBytes == 0 && !endpoint.IsGRPCWeb() {
+		if binary != nil {
+			staticETag = computeETag(binary)
+		} else if !templated || !hasTemplateTokens(endpoint.Response) {
+			staticETag = computeETag([]byte(endpoint.Response))
+		}
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Log the request
-		log.Printf("[%s] %s %s", r.Method, r.URL.Path, r.RemoteAddr)
+		accessLog.log(r)
+
+		// Reject requests without the required headers before doing any work,
+		// or serve them the anonymous response when one is configured
+		anonymous := false
+		if name, present := missingHeader(r, endpoint.RequiredHeaders); name != "" {
+			if endpoint.AnonymousResponse == "" {
+				rejectMissingHeader(w, r, endpoint, name, present)
+				return
+			}
+			anonymous = true
+		}
+		if endpoint.AnonymousResponse != "" {
+			for _, header := range endpoint.RequiredHeaders {
+				w.Header().Add("Vary", header.Name)
+			}
+		}
+
+		// Count the request against the quota, switching to the
+		// quota-exceeded response beyond the limit
+		if quota != nil {
+			if allowed, reset := quota.take(time.Now()); !allowed {
+				rejectOverQuota(w, r, endpoint, reset)
+				return
+			}
+		}
+
+		// Bound the time spent before responding; waits below end early when
+		// the timeout passes or the client goes away
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		abandon := func() {
+			if timedOut(r) {
+				GatewayTimeoutHandler(timeout)(w, r)
+			}
+		}
+
+		// Hold a worker slot for the whole request, delays included, when
+		// concurrency is capped
+		if limiter != nil {
+			if !limiter.acquire(r) {
+				if timedOut(r) {
+					abandon()
+					return
+				}
+				if endpoint.RetryAfter != "" {
+					w.Header().Set("Retry-After", string(endpoint.RetryAfter))
+				}
+				ServiceUnavailableHandler(endpoint.Concurrency.Max)(w, r)
+				return
+			}
+			defer limiter.release()
+		}
 
 		// Apply configured delay if specified
-		if endpoint.Delay > 0 {
-			time.Sleep(time.Duration(endpoint.Delay) * time.Millisecond)
+		if endpoint.Delay > 0 && !sleepContext(r.Context(), time.Duration(endpoint.Delay)*time.Millisecond) {
+			abandon()
+			return
+		}
+		if latency != nil && !sleepContext(r.Context(), latency.sample(random)) {
+			abandon()
+			return
+		}
+
+		// Simulate a network drop by closing the connection without a response
+		if endpoint.Fault != nil && random.Float64() < endpoint.Fault.DropProbability && dropConnection(w) {
+			return
 		}
 
-		// Set configured headers
+		// Pick a response variant when the endpoint defines several
+		status := endpoint.Status
+		template := endpoint.Response
+		var variantHeaders map[string]string
+		if variants != nil {
+			variant := variants.pick(random)
+			if variant.Status != 0 {
+				status = variant.Status
+			}
+			template = variant.Response
+			variantHeaders = variant.Headers
+		}
+
+		// Pick the body for the request's preferred language, falling back to
+		// the default response
+		if localized != nil {
+			w.Header().Add("Vary", "Accept-Language")
+			if match, ok := localized.match(r.Header.Get("Accept-Language")); ok {
+				template = match.Response
+				w.Header().Set("Content-Language", match.Lang)
+			}
+		}
+
+		// Requests without the required headers get the limited body
+		if anonymous {
+			template = endpoint.AnonymousResponse
+		}
+
+		// Take the status from the request when configured, rejecting values
+		// that are not a legal status code
+		if endpoint.StatusFrom != "" {
+			dynamic, err := statusFromRequest(endpoint.StatusFrom, r)
+			if err != nil {
+				log.Printf("Invalid dynamic status for %s %s: %v", r.Method, r.URL.Path, err)
+				status = http.StatusBadRequest
+			} else if dynamic != 0 {
+				status = dynamic
+			}
+		}
+
+		// Substitute the counter before request data is inserted so values
+		// from the request can never be mistaken for the token
+		if templated && strings.Contains(template, "{{counter}}") {
+			template = strings.ReplaceAll(template, "{{counter}}", strconv.FormatInt(counter.Add(1), 10))
+		}
+
+		// Read the body up front so oversized uploads are rejected before any
+		// headers are written. Binary responses are never templated, so only
+		// cookie and header values can need it.
+		templates := valueTemplates
+		if binary == nil {
+			templates = append([]string{template}, valueTemplates...)
+		}
+		if !templated {
+			templates = nil
+		}
+		if delayWhen != nil && delayWhen.needsBody {
+			// delay_when body conditions need the body whatever the templates use
+			templates = append([]string{"{{body}}"}, templates...)
+		}
+		body, err := readTemplateBody(r, templates...)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				PayloadTooLargeHandler(maxBytesErr.Limit)(w, r)
+				return
+			}
+			log.Printf("Failed to read request body: %v", err)
+		}
+
+		// Add the delay of the first matching delay_when rule
+		if delayWhen != nil {
+			if delay := delayWhen.match(r, body); delay > 0 && !sleepContext(r.Context(), delay) {
+				abandon()
+				return
+			}
+		}
+
+		render := func(value string, escape bool) string {
+			if !templated {
+				return value
+			}
+			return renderResponse(value, r, body, escape)
+		}
+
+		response := binary
+		if response == nil {
+			response = []byte(render(template, expectsJSON(endpoint, template)))
+		}
+		if endpoint.PadToBytes > 0 {
+			response = padResponse(response, endpoint.PadToBytes)
+		}
+
+		// Frame the message and its status for gRPC-Web clients
+		if endpoint.IsGRPCWeb() {
+			response = grpcWebResponse(w, r, response, endpoint.GRPCStatus, render(endpoint.GRPCMessage, false))
+		}
+
+		// Tell clients when to retry; configured headers may override it
+		if endpoint.RetryAfter != "" && sendsRetryAfter(status) {
+			w.Header().Set("Retry-After", string(endpoint.RetryAfter))
+		}
+
+		// Status defaults to 200, or 202 when a callback follows
+		if status == 0 {
+			status = 200
+			if endpoint.Callback != nil {
+				status = http.StatusAccepted
+			}
+		}
+
+		// Identify the body so clients can revalidate it; configured headers
+		// may override it
+		if endpoint.ETag && sendsETag(status) {
+			etag := staticETag
+			if etag == "" {
+				etag = computeETag(response)
+			}
+			w.Header().Set("ETag", etag)
+		}
+
+		// Set configured headers, templating values but never names
 		for key, value := range endpoint.Headers {
-			w.Header().Set(key, value)
+			w.Header().Set(key, render(value, false))
+		}
+		for key, value := range variantHeaders {
+			w.Header().Set(key, render(value, false))
+		}
+		// The header list adds rather than replaces, keeping order and repeats
+		for _, header := range endpoint.HeaderList {
+			w.Header().Add(header.Name, render(header.Value, false))
+		}
+
+		// Set configured cookies
+		for _, cookie := range endpoint.Cookies {
+			sameSite, _ := cookie.GetSameSite()
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookie.Name,
+				Value:    render(cookie.Value, false),
+				Path:     cookie.Path,
+				MaxAge:   cookie.MaxAge,
+				HttpOnly: cookie.HTTPOnly,
+				Secure:   cookie.Secure,
+				SameSite: sameSite,
+			})
 		}
 
 		// Set default Content-Type if not specified
 		if w.Header().Get("Content-Type") == "" {
-			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Type", defaultContentType(endpoint))
 		}
 
-		// Set status code
-		status := endpoint.Status
-		if status == 0 {
-			status = 200
+		// Answer a matching If-None-Match without the body
+		if endpoint.ETag && sendsETag(status) && notModified(r, w.Header().Get("ETag")) {
+			writeNotModified(w)
+			return
 		}
+
+		// Set status code
 		w.WriteHeader(status)
 
-		// Process and write response
-		response := processResponse(endpoint.Response, r)
-		if _, err := w.Write([]byte(response)); err != nil {
+		if endpoint.StreamChunks > 1 {
+			interval := defaultStreamInterval
+			if endpoint.StreamInterval > 0 {
+				interval = time.Duration(endpoint.StreamInterval) * time.Millisecond
+			}
+			writeChunks(w, r, response, endpoint.StreamChunks, interval)
+		} else if _, err := w.Write(response); err != nil {
 			log.Printf("Failed to write response: %v", err)
 		}
+
+		// Send the callback once the client has its response
+		if endpoint.Callback != nil && status < 400 {
+			sendCallback(r, endpoint.Callback, render)
+		}
+	}
+}
+
+// dropConnection closes the client connection without writing anything,
+// reporting whether it did. Writers that cannot be hijacked, such as HTTP/2
+// streams, are left alone and the request is served normally.
+func dropConnection(w http.ResponseWriter) bool {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		log.Printf("Cannot drop connection, serving response instead: %v", err)
+		return false
+	}
+	if err := conn.Close(); err != nil {
+		log.Printf("Failed to close dropped connection: %v", err)
+	}
+	return true
+}
+
+// binaryResponse decodes the endpoint's response_base64 bytes, or returns the
+// response bytes of a raw_response endpoint. It returns nil when the endpoint
+// has a templated response instead, and logs invalid base64 (rejected by
+// config validation and RegisterEndpoint) as an empty body.
+func binaryResponse(endpoint models.EndpointConfig) []byte {
+	if endpoint.RawResponse {
+		return []byte(endpoint.Response)
+	}
+	if endpoint.ResponseBase64 == "" {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(endpoint.ResponseBase64)
+	if err != nil {
+		log.Printf("Invalid response_base64 for %s %s: %v", endpoint.Method, endpoint.Path, err)
+		return []byte{}
+	}
+	return data
+}
+
+// defaultContentType is the Content-Type sent when the endpoint sets none:
+// JSON for templated responses, octet-stream for binary ones
+func defaultContentType(endpoint models.EndpointConfig) string {
+	if endpoint.ResponseBase64 != "" {
+		return "application/octet-stream"
+	}
+	return "application/json"
+}
+
+// sendsRetryAfter reports whether a configured retry_after applies to
+// status: 429, 503 and redirects, the statuses Retry-After is defined for
+func sendsRetryAfter(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusServiceUnavailable ||
+		(status >= 300 && status < 400)
+}
+
+// statusFromRequest reads a status code from the query parameter or header
+// named by source ("query.NAME" or "header.NAME"). It returns 0 when the
+// request does not carry the value.
+func statusFromRequest(source string, r *http.Request) (int, error) {
+	var raw string
+	switch {
+	case strings.HasPrefix(source, "query."):
+		raw = r.URL.Query().Get(strings.TrimPrefix(source, "query."))
+	case strings.HasPrefix(source, "header."):
+		raw = r.Header.Get(strings.TrimPrefix(source, "header."))
+	}
+	if raw == "" {
+		return 0, nil
+	}
+
+	status, err := strconv.Atoi(raw)
+	if err != nil || status < 100 || status > 599 {
+		return 0, fmt.Errorf("%s %q is not a status code between 100 and 599", source, raw)
+	}
+	return status, nil
+}
+
+// bodyFieldPattern matches dotted body field tokens like {{body.user.name}}
+var bodyFieldPattern = regexp.MustCompile(`\{\{body\.([^}]+)\}\}`)
+
+// weightedVariants selects response variants at random in proportion to
+// their weights. Cumulative weights are computed once at registration.
+type weightedVariants struct {
+	variants   []models.ResponseVariant
+	cumulative []int
+	total      int
+}
+
+// newWeightedVariants normalizes variant weights, treating unset weights as 1.
+// It returns nil when there are no variants.
+func newWeightedVariants(variants []models.ResponseVariant) *weightedVariants {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	wv := &weightedVariants{
+		variants:   variants,
+		cumulative: make([]int, len(variants)),
+	}
+	for i, variant := range variants {
+		weight := variant.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		wv.total += weight
+		wv.cumulative[i] = wv.total
+	}
+	return wv
+}
+
+// pick returns a variant chosen by weighted random selection
+func (wv *weightedVariants) pick(random *randomSource) models.ResponseVariant {
+	n := random.IntN(wv.total)
+	i := sort.SearchInts(wv.cumulative, n+1)
+	return wv.variants[i]
+}
+
+// formPattern matches multipart field tokens like {{form.title}}
+var formPattern = regexp.MustCompile(`\{\{form\.([^}]+)\}\}`)
+
+// filePattern matches multipart file tokens like {{file.avatar.filename}}
+var filePattern = regexp.MustCompile(`\{\{file\.([^}]+)\.(filename|size|content_type)\}\}`)
+
+// jsonPathPattern matches JSONPath tokens like {{jsonpath:$.users[0].name}}
+var jsonPathPattern = regexp.MustCompile(`\{\{jsonpath:([^}]*)\}\}`)
+
+// cookiePattern matches cookie tokens like {{cookie.session}}
+var cookiePattern = regexp.MustCompile(`\{\{cookie\.([^}]+)\}\}`)
+
+// segmentPattern matches positional path tokens like {{segment.2}}
+var segmentPattern = regexp.MustCompile(`\{\{segment\.(\d+)\}\}`)
+
+// modifierPattern matches tokens wrapped in a modifier, like {{raw:query.q}}
+// or {{base64:body.name}}
+var modifierPattern = regexp.MustCompile(`\{\{(raw|base64):([^}]+)\}\}`)
+
+// jsonEscape escapes s for use inside a JSON string, without the quotes
+func jsonEscape(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded[1 : len(encoded)-1])
+}
+
+// hasTemplateTokens reports whether a response contains template variables
+func hasTemplateTokens(response string) bool {
+	return strings.Contains(response, "{{")
+}
+
+// readTemplateBody reads the request body only when one of the templates
+// references it, so large uploads to endpoints that ignore the body are never
+// buffered in memory. Multipart bodies referenced through {{form.*}} or
+// {{file.*}} are parsed into r.MultipartForm instead.
+func readTemplateBody(r *http.Request, templates ...string) ([]byte, error) {
+	if r.Method != "POST" && r.Method != "PUT" && r.Method != "PATCH" {
+		return nil, nil
 	}
+
+	if isMultipart(r) && referencesAny(templates, "{{form.", "{{file.", ":form.", ":file.") {
+		return nil, r.ParseMultipartForm(multipartMaxMemory)
+	}
+
+	if !referencesAny(templates, "{{body", "{{jsonpath:", ".Body", ":body", ":jsonpath:") {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// multipartMaxMemory caps how much of a multipart body is held in memory;
+// larger file parts are spooled to temporary files
+const multipartMaxMemory = 10 << 20
+
+// isMultipart reports whether the request carries a multipart/form-data body
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
 }
 
-// processResponse handles response templating with request data
+// referencesAny reports whether any template contains any of the token prefixes
+func referencesAny(templates []string, prefixes ...string) bool {
+	for _, template := range templates {
+		for _, prefix := range prefixes {
+			if strings.Contains(template, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// processResponse handles templating of a JSON response with request data
 func processResponse(response string, r *http.Request) string {
+	return processTemplate(response, r, true)
+}
+
+// processTemplate reads the request body when response needs it and renders
+// response, JSON-escaping request values when escape is set
+func processTemplate(response string, r *http.Request, escape bool) string {
+	body, err := readTemplateBody(r, response)
+	if err != nil {
+		log.Printf("Failed to read request body: %v", err)
+	}
+	return renderResponse(response, r, body, escape)
+}
+
+// renderResponse substitutes template variables using an already-read body.
+// With escape set, string values taken from the request are JSON-escaped so
+// they cannot break out of the JSON string they are placed in; {{raw:...}}
+// inserts a value as is.
+func renderResponse(response string, r *http.Request, body []byte, escape bool) string {
+	value := func(s string) string {
+		if escape {
+			return jsonEscape(s)
+		}
+		return s
+	}
+
+	// Parse the request body according to its Content-Type. The body is
+	// parsed once and shared by {{body}}, every {{body.field}} token and
+	// function calls using .Body.
+	jsonBody, bodyParsed := parseBody(r, body)
+
+	// Evaluate registered function calls first, so request values
+	// substituted below are never themselves evaluated
+	response = renderFuncTokens(response, r, jsonBody, escape)
+
+	// Replace environment variables allowed by SetTemplateEnv before any
+	// request values, so a request cannot smuggle in an {{env.NAME}} token
+	response = renderEnvTokens(response, r)
+
+	// Replace {{raw:...}} and {{base64:...}} tokens with the unescaped or
+	// base64-encoded value of the token they wrap
+	response = modifierPattern.ReplaceAllStringFunc(response, func(token string) string {
+		match := modifierPattern.FindStringSubmatch(token)
+		inner := renderResponse("{{"+match[2]+"}}", r, body, false)
+		if match[1] == "base64" {
+			return base64.StdEncoding.EncodeToString([]byte(inner))
+		}
+		return inner
+	})
+
 	// Replace common variables
-	response = strings.ReplaceAll(response, "{{path}}", r.URL.Path)
+	response = strings.ReplaceAll(response, "{{path}}", value(r.URL.Path))
 	response = strings.ReplaceAll(response, "{{method}}", r.Method)
 
+	// Replace path segments by zero-based index, using an empty string past
+	// the last segment
+	if strings.Contains(response, "{{segment.") {
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		response = segmentPattern.ReplaceAllStringFunc(response, func(token string) string {
+			index, err := strconv.Atoi(segmentPattern.FindStringSubmatch(token)[1])
+			if err != nil || index >= len(segments) {
+				return ""
+			}
+			return value(segments[index])
+		})
+	}
+
+	// Replace multipart form fields and file metadata, using an empty string
+	// when the request has no such field
+	response = formPattern.ReplaceAllStringFunc(response, func(token string) string {
+		name := formPattern.FindStringSubmatch(token)[1]
+		if r.MultipartForm == nil || len(r.MultipartForm.Value[name]) == 0 {
+			return ""
+		}
+		return value(r.MultipartForm.Value[name][0])
+	})
+	response = filePattern.ReplaceAllStringFunc(response, func(token string) string {
+		match := filePattern.FindStringSubmatch(token)
+		if r.MultipartForm == nil || len(r.MultipartForm.File[match[1]]) == 0 {
+			return ""
+		}
+		file := r.MultipartForm.File[match[1]][0]
+		switch match[2] {
+		case "filename":
+			return value(file.Filename)
+		case "size":
+			return strconv.FormatInt(file.Size, 10)
+		default:
+			return value(file.Header.Get("Content-Type"))
+		}
+	})
+
+	// Replace cookies, using an empty string for absent cookies
+	response = cookiePattern.ReplaceAllStringFunc(response, func(token string) string {
+		name := cookiePattern.FindStringSubmatch(token)[1]
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return value(cookie.Value)
+	})
+
+	// Replace the request ID
+	if strings.Contains(response, "{{request_id}}") {
+		response = strings.ReplaceAll(response, "{{request_id}}", value(requestID(r)))
+	}
+
+	// Replace timestamps
+	if strings.Contains(response, "{{now") {
+		now := time.Now().UTC()
+		response = strings.ReplaceAll(response, "{{now}}", now.Format(time.RFC3339))
+		response = strings.ReplaceAll(response, "{{now.unix}}", strconv.FormatInt(now.Unix(), 10))
+	}
+
+	// Replace the TLS SNI server name (empty for plaintext requests)
+	serverName := ""
+	if r.TLS != nil {
+		serverName = r.TLS.ServerName
+	}
+	response = strings.ReplaceAll(response, "{{tls.servername}}", value(serverName))
+
 	// Replace query parameters
 	for key, values := range r.URL.Query() {
 		if len(values) > 0 {
-			response = strings.ReplaceAll(response, fmt.Sprintf("{{query.%s}}", key), values[0])
+			response = strings.ReplaceAll(response, fmt.Sprintf("{{query.%s}}", key), value(values[0]))
 		}
 	}
 
 	// Replace path parameters (simple implementation)
 	// For more complex routing, could integrate a router library
 
-	// Try to parse and include request body if it's JSON
-	if r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH" {
-		if body, err := io.ReadAll(r.Body); err == nil {
-			var jsonBody interface{}
-			if err := json.Unmarshal(body, &jsonBody); err == nil {
-				if bodyJSON, err := json.Marshal(jsonBody); err == nil {
-					response = strings.ReplaceAll(response, "{{body}}", string(bodyJSON))
-				}
-			}
+	if bodyParsed {
+		if bodyJSON, err := json.Marshal(jsonBody); err == nil {
+			response = strings.ReplaceAll(response, "{{body}}", string(bodyJSON))
 		}
 	}
 
+	// Replace nested body fields such as {{body.user.name}} or {{body.items.0.id}}
+	response = bodyFieldPattern.ReplaceAllStringFunc(response, func(token string) string {
+		if !bodyParsed {
+			return ""
+		}
+		path := bodyFieldPattern.FindStringSubmatch(token)[1]
+		field, ok := lookupPath(jsonBody, path)
+		if !ok {
+			return ""
+		}
+		if str, ok := field.(string); ok {
+			return value(str)
+		}
+		return formatValue(field)
+	})
+
+	// Replace JSONPath extractions such as {{jsonpath:$.items[?(@.qty > 1)].id}}.
+	// The selected value is inserted like {{body.field}}; paths selecting
+	// several matches give a JSON array.
+	response = jsonPathPattern.ReplaceAllStringFunc(response, func(token string) string {
+		if !bodyParsed {
+			return ""
+		}
+		path, err := jsonpath.Compile(jsonPathPattern.FindStringSubmatch(token)[1])
+		if err != nil {
+			log.Printf("Invalid JSONPath in response template: %v", err)
+			return ""
+		}
+		selected, ok := path.Get(jsonBody)
+		if !ok {
+			return ""
+		}
+		if str, isString := selected.(string); isString {
+			return value(str)
+		}
+		return formatValue(selected)
+	})
+
 	return response
 }
 
+// parseBody decodes a request body according to its Content-Type. JSON
+// bodies, and bodies sent without a Content-Type, are decoded as JSON.
+// URL-encoded form bodies become an object holding the first value of each
+// field. Bodies of any other type are not parsed.
+func parseBody(r *http.Request, body []byte) (interface{}, bool) {
+	if body == nil {
+		return nil, false
+	}
+
+	mediaType := ""
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, false
+		}
+		mediaType = parsed
+	}
+
+	var value interface{}
+	switch {
+	case mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, false
+		}
+		return value, true
+	case mediaType == "application/x-www-form-urlencoded":
+		// curl -d sends JSON with this Content-Type unless told otherwise
+		if err := json.Unmarshal(body, &value); err == nil {
+			return value, true
+		}
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, false
+		}
+		fields := make(map[string]interface{}, len(values))
+		for key, vals := range values {
+			if len(vals) > 0 {
+				fields[key] = vals[0]
+			}
+		}
+		return fields, true
+	}
+	return nil, false
+}
+
+// lookupPath walks a parsed JSON value along a dotted path. Numeric segments
+// index into arrays.
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	for _, segment := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// formatValue renders a JSON value for substitution. Strings are inserted
+// raw; numbers, booleans, objects and arrays are inserted as JSON.
+func formatValue(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	if value == nil {
+		return ""
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
 // HealthHandler returns a basic health check handler
 func HealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -90,15 +857,83 @@ func HealthHandler() http.HandlerFunc {
 	}
 }
 
+// VersionPath serves the build and config information of the running server
+const VersionPath = "/__version"
+
+// VersionInfo identifies the running build and its configuration
+type VersionInfo struct {
+	Version     string   `json:"version"`
+	Commit      string   `json:"commit"`
+	BuildDate   string   `json:"build_date"`
+	ConfigFiles []string `json:"config_files"`
+}
+
+// VersionHandler returns a handler reporting info as JSON
+func VersionHandler(info VersionInfo) http.HandlerFunc {
+	if info.ConfigFiles == nil {
+		info.ConfigFiles = []string{}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(info); err != nil {
+			log.Printf("Failed to write version response: %v", err)
+		}
+	}
+}
+
+// PayloadTooLargeHandler returns a 413 handler for request bodies over the limit
+func PayloadTooLargeHandler(limit int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("[413] %s %s exceeds %d bytes", r.Method, r.URL.Path, limit)
+		response := fmt.Sprintf(`{"error":"request body too large","limit":%d}`, limit)
+		writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large",
+			fmt.Sprintf("request body exceeds %d bytes", limit), response)
+	}
+}
+
 // NotFoundHandler returns a custom 404 handler
 func NotFoundHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[404] %s %s", r.Method, r.URL.Path)
+		response := fmt.Sprintf(`{"error":"endpoint not found","path":"%s","method":"%s"}`, r.URL.Path, r.Method)
+		writeError(w, r, http.StatusNotFound, "endpoint not found",
+			fmt.Sprintf("no endpoint for %s %s", r.Method, r.URL.Path), response)
+	}
+}
+
+// CustomNotFoundHandler returns a 404 handler that renders a configured body template
+func CustomNotFoundHandler(template string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("[404] %s %s", r.Method, r.URL.Path)
+		response := processResponse(template, r)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
-		response := fmt.Sprintf(`{"error":"endpoint not found","path":"%s","method":"%s"}`, r.URL.Path, r.Method)
 		if _, err := w.Write([]byte(response)); err != nil {
 			log.Printf("Failed to write 404 response: %v", err)
 		}
 	}
 }
+
+// MethodNotAllowedHandler returns a 405 handler listing the allowed methods.
+// A non-empty template replaces the default body and error envelope, and may
+// use {{allowed}}.
+func MethodNotAllowedHandler(allowed []string, template string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		if template == "" {
+			response := fmt.Sprintf(`{"error":"method not allowed","allowed":%q,"received":"%s"}`, allowed, r.Method)
+			writeError(w, r, http.StatusMethodNotAllowed, "method not allowed",
+				fmt.Sprintf("%s is not allowed for %s; allowed: %s", r.Method, r.URL.Path, strings.Join(allowed, ", ")), response)
+			return
+		}
+
+		response := strings.ReplaceAll(template, "{{allowed}}", strings.Join(allowed, ", "))
+		response = processResponse(response, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		if _, err := w.Write([]byte(response)); err != nil {
+			log.Printf("Failed to write method not allowed response: %v", err)
+		}
+	}
+}
@@ -2,7 +2,20 @@ package router
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -164,6 +177,41 @@ func TestHandler_StatusCodes(t *testing.T) {
 	}
 }
 
+func TestHandler_StatusFrom(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:       "/api/echo-status",
+		Method:     "GET",
+		Status:     202,
+		StatusFrom: "query.code",
+		Response:   `{"ok": true}`,
+	}
+	handler := Handler(endpoint)
+
+	tests := []struct {
+		name     string
+		target   string
+		expected int
+	}{
+		{"valid code", "/api/echo-status?code=404", 404},
+		{"absent falls back to status", "/api/echo-status", 202},
+		{"out of range", "/api/echo-status?code=700", 400},
+		{"not a number", "/api/echo-status?code=teapot", 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
 func TestProcessResponse_PathVariable(t *testing.T) {
 	response := `{"path": "{{path}}"}`
 
@@ -200,6 +248,55 @@ func TestProcessResponse_QueryParameter(t *testing.T) {
 	}
 }
 
+func TestProcessResponse_EscapesRequestValues(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/test?q="+url.QueryEscape("say \"hi\"\nbye"), nil)
+
+	tests := []struct {
+		name     string
+		response string
+		expected string
+	}{
+		{"escaped by default", `{"q": "{{query.q}}"}`, `{"q": "say \"hi\"\nbye"}`},
+		{"raw", `{"q": {{raw:query.q}}}`, "{\"q\": say \"hi\"\nbye}"},
+		{"base64", `{"q": "{{base64:query.q}}"}`, `{"q": "` + base64.StdEncoding.EncodeToString([]byte("say \"hi\"\nbye")) + `"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := processResponse(tt.response, req)
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+
+	// The escaped response is valid JSON holding the original value
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(processResponse(`{"q": "{{query.q}}"}`, req)), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if decoded["q"] != "say \"hi\"\nbye" {
+		t.Errorf("Expected the original value, got %q", decoded["q"])
+	}
+}
+
+func TestHandler_EscapesOnlyJSONResponses(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:     "/api/echo",
+		Method:   "POST",
+		Response: `{{body.text}}`,
+		Headers:  map[string]string{"Content-Type": "text/plain"},
+	})
+
+	req := httptest.NewRequest("POST", "/api/echo", strings.NewReader(`{"text": "a \"quoted\" word"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if expected := `a "quoted" word`; w.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+}
+
 func TestProcessResponse_RequestBody(t *testing.T) {
 	response := `{"received": {{body}}}`
 
@@ -215,6 +312,477 @@ func TestProcessResponse_RequestBody(t *testing.T) {
 	}
 }
 
+func TestProcessResponse_NestedBodyField(t *testing.T) {
+	response := `{"name": "{{body.user.name}}", "age": {{body.user.age}}, "admin": {{body.user.admin}}}`
+
+	body := `{"user":{"name":"Alice","age":30,"admin":true}}`
+	req := httptest.NewRequest("POST", "/api/test", bytes.NewBufferString(body))
+
+	result := processResponse(response, req)
+
+	expected := `{"name": "Alice", "age": 30, "admin": true}`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestProcessResponse_BodyArrayIndex(t *testing.T) {
+	response := `{"first": "{{body.items.0.id}}", "second": "{{body.items.1.id}}"}`
+
+	body := `{"items":[{"id":"a1"},{"id":"b2"}]}`
+	req := httptest.NewRequest("POST", "/api/test", bytes.NewBufferString(body))
+
+	result := processResponse(response, req)
+
+	expected := `{"first": "a1", "second": "b2"}`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestProcessResponse_MissingBodyField(t *testing.T) {
+	response := `{"missing": "{{body.user.email}}", "outOfRange": "{{body.items.5}}", "whole": {{body}}}`
+
+	body := `{"user":{"name":"Alice"},"items":[]}`
+	req := httptest.NewRequest("POST", "/api/test", bytes.NewBufferString(body))
+
+	result := processResponse(response, req)
+
+	expected := `{"missing": "", "outOfRange": "", "whole": {"items":[],"user":{"name":"Alice"}}}`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestProcessResponse_BodyContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{"urlencoded", "application/x-www-form-urlencoded", "name=Alice&role=admin", `{"name": "Alice", "body": {"name":"Alice","role":"admin"}}`},
+		{"json", "application/json; charset=utf-8", `{"name": "Bob", "role": "dev"}`, `{"name": "Bob", "body": {"name":"Bob","role":"dev"}}`},
+		{"plain text not parsed", "text/plain", `{"name": "Carol"}`, `{"name": "", "body": {{body}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/test", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			result := processResponse(`{"name": "{{body.name}}", "body": {{body}}}`, req)
+
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestProcessResponse_JSONPathNested(t *testing.T) {
+	body := `{"user": {"addresses": [{"city": "Leeds"}, {"city": "York"}]}}`
+	req := httptest.NewRequest("POST", "/test", bytes.NewBufferString(body))
+	response := `{"city": "{{jsonpath:$.user.addresses[0].city}}"}`
+
+	result := processResponse(response, req)
+
+	expected := `{"city": "Leeds"}`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestProcessResponse_JSONPathFilter(t *testing.T) {
+	body := `{"items": [{"id": 1, "qty": 0}, {"id": 2, "qty": 3}, {"id": 3, "qty": 5}]}`
+	req := httptest.NewRequest("POST", "/test", bytes.NewBufferString(body))
+	response := `{"in_stock": {{jsonpath:$.items[?(@.qty > 0)].id}}, "plenty": {{jsonpath:$.items[?(@.qty > 4)].id}}, "missing": "{{jsonpath:$.nope}}"}`
+
+	result := processResponse(response, req)
+
+	// A filter selects a list even when a single item matches
+	expected := `{"in_stock": [2,3], "plenty": [3], "missing": ""}`
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestHandler_WeightedResponses(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	endpoint := models.EndpointConfig{
+		Path:   "/ab",
+		Method: "GET",
+		Status: 200,
+		Responses: []models.ResponseVariant{
+			{Response: `{"variant": "A"}`, Weight: 70},
+			{Response: `{"variant": "B"}`, Weight: 30, Status: 202, Headers: map[string]string{"X-Variant": "B"}},
+		},
+	}
+
+	handler := Handler(endpoint)
+
+	const requests = 10000
+	counts := map[string]int{}
+	for i := 0; i < requests; i++ {
+		req := httptest.NewRequest("GET", "/ab", nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		switch w.Body.String() {
+		case `{"variant": "A"}`:
+			counts["A"]++
+			if w.Code != 200 {
+				t.Fatalf("Expected variant A to inherit status 200, got %d", w.Code)
+			}
+		case `{"variant": "B"}`:
+			counts["B"]++
+			if w.Code != 202 || w.Header().Get("X-Variant") != "B" {
+				t.Fatalf("Expected variant B status and headers, got %d %q", w.Code, w.Header().Get("X-Variant"))
+			}
+		default:
+			t.Fatalf("Unexpected body: %s", w.Body.String())
+		}
+	}
+
+	ratioA := float64(counts["A"]) / requests
+	if math.Abs(ratioA-0.7) > 0.03 {
+		t.Errorf("Expected variant A ~70%% of responses, got %.1f%%", ratioA*100)
+	}
+}
+
+func TestNewWeightedVariants_DefaultWeight(t *testing.T) {
+	wv := newWeightedVariants([]models.ResponseVariant{
+		{Response: "a"},
+		{Response: "b", Weight: 3},
+	})
+
+	if wv.total != 4 {
+		t.Errorf("Expected total weight 4, got %d", wv.total)
+	}
+
+	if newWeightedVariants(nil) != nil {
+		t.Error("Expected nil selector for no variants")
+	}
+}
+
+func TestProcessResponse_Now(t *testing.T) {
+	response := `{"createdAt": "{{now}}", "epoch": {{now.unix}}}`
+
+	req := httptest.NewRequest("GET", "/api/test", nil)
+	result := processResponse(response, req)
+
+	var parsed struct {
+		CreatedAt string `json:"createdAt"`
+		Epoch     int64  `json:"epoch"`
+	}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("Expected valid JSON, got %s: %v", result, err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, parsed.CreatedAt)
+	if err != nil {
+		t.Fatalf("Expected RFC3339 timestamp, got %q: %v", parsed.CreatedAt, err)
+	}
+	if time.Since(createdAt) > time.Minute {
+		t.Errorf("Expected current timestamp, got %v", createdAt)
+	}
+	if parsed.Epoch != createdAt.Unix() {
+		t.Errorf("Expected unix time %d, got %d", createdAt.Unix(), parsed.Epoch)
+	}
+}
+
+func TestHandler_Counter(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/api/orders",
+		Method:   "POST",
+		Status:   201,
+		Response: `{"id": {{counter}}, "ref": "order-{{counter}}"}`,
+	}
+
+	handler := Handler(endpoint)
+
+	for want := 1; want <= 2; want++ {
+		req := httptest.NewRequest("POST", "/api/orders", nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		var parsed struct {
+			ID  int    `json:"id"`
+			Ref string `json:"ref"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &parsed); err != nil {
+			t.Fatalf("Expected valid JSON, got %s: %v", w.Body.String(), err)
+		}
+		if parsed.ID != want {
+			t.Errorf("Expected counter %d, got %d", want, parsed.ID)
+		}
+		if parsed.Ref != "order-"+strconv.Itoa(want) {
+			t.Errorf("Expected ref order-%d, got %s", want, parsed.Ref)
+		}
+	}
+}
+
+func TestProcessResponse_Cookie(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		cookies  []*http.Cookie
+		expected string
+	}{
+		{
+			name:     "present cookie",
+			response: `{"session": "{{cookie.session}}"}`,
+			cookies:  []*http.Cookie{{Name: "session", Value: "abc123"}},
+			expected: `{"session": "abc123"}`,
+		},
+		{
+			name:     "absent cookie",
+			response: `{"session": "{{cookie.session}}"}`,
+			cookies:  []*http.Cookie{{Name: "other", Value: "x"}},
+			expected: `{"session": ""}`,
+		},
+		{
+			name:     "special characters in name",
+			response: `{"token": "{{cookie.__Host-auth.v2}}"}`,
+			cookies:  []*http.Cookie{{Name: "__Host-auth.v2", Value: "t0k3n"}},
+			expected: `{"token": "t0k3n"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/test", nil)
+			for _, cookie := range tt.cookies {
+				req.AddCookie(cookie)
+			}
+
+			result := processResponse(tt.response, req)
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestProcessResponse_Segment(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		response string
+		expected string
+	}{
+		{"valid indices", "/api/users/42", `{"resource": "{{segment.1}}", "id": "{{segment.2}}"}`, `{"resource": "users", "id": "42"}`},
+		{"trailing slash", "/api/users/", `{"resource": "{{segment.1}}"}`, `{"resource": "users"}`},
+		{"out of range", "/api/users", `{"id": "{{segment.2}}"}`, `{"id": ""}`},
+		{"root path", "/", `{"first": "{{segment.0}}"}`, `{"first": ""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+
+			result := processResponse(tt.response, req)
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestHandler_SetCookies(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/login",
+		Method:   "POST",
+		Status:   200,
+		Response: `{"ok": true}`,
+		Cookies: []models.CookieConfig{
+			{
+				Name:     "session",
+				Value:    "sess-{{body.user}}",
+				Path:     "/",
+				MaxAge:   3600,
+				HTTPOnly: true,
+				Secure:   true,
+				SameSite: "Strict",
+			},
+			{
+				Name:  "theme",
+				Value: "dark",
+			},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/login", bytes.NewBufferString(`{"user":"alice"}`))
+	w := httptest.NewRecorder()
+
+	Handler(endpoint)(w, req)
+
+	setCookies := w.Header().Values("Set-Cookie")
+	if len(setCookies) != 2 {
+		t.Fatalf("Expected 2 Set-Cookie headers, got %d: %v", len(setCookies), setCookies)
+	}
+
+	expected := "session=sess-alice; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Strict"
+	if setCookies[0] != expected {
+		t.Errorf("Expected cookie %q, got %q", expected, setCookies[0])
+	}
+	if setCookies[1] != "theme=dark" {
+		t.Errorf("Expected cookie %q, got %q", "theme=dark", setCookies[1])
+	}
+}
+
+// failingReader records whether it was read from
+type failingReader struct {
+	read bool
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	f.read = true
+	return 0, errors.New("body should not be read")
+}
+
+func TestHandler_TemplateDisabled(t *testing.T) {
+	disabled := false
+	handler := Handler(models.EndpointConfig{
+		Path:     "/api/upload",
+		Method:   "POST",
+		Response: `{"path": "{{path}}", "echo": "{{body}}", "n": "{{counter}}"}`,
+		Headers:  map[string]string{"X-Path": "{{path}}"},
+		Template: &disabled,
+	})
+
+	body := &failingReader{}
+	req := httptest.NewRequest("POST", "/api/upload", body)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	expected := `{"path": "{{path}}", "echo": "{{body}}", "n": "{{counter}}"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body.String())
+	}
+	if got := w.Header().Get("X-Path"); got != "{{path}}" {
+		t.Errorf("Expected literal header value, got %q", got)
+	}
+	if body.read {
+		t.Error("Expected the request body not to be read")
+	}
+}
+
+func TestHandler_SkipsBodyWithoutToken(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/upload",
+		Method:   "POST",
+		Status:   201,
+		Response: `{"uploaded": true}`,
+	}
+
+	body := &failingReader{}
+	req := httptest.NewRequest("POST", "/upload", body)
+	w := httptest.NewRecorder()
+
+	Handler(endpoint)(w, req)
+
+	if body.read {
+		t.Error("Expected request body not to be read when response has no {{body}} token")
+	}
+	if w.Code != 201 {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+}
+
+func TestHandler_BodyReadOnce(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/echo",
+		Method:   "POST",
+		Response: `{"a": {{body}}, "b": {{body}}}`,
+	}
+
+	req := httptest.NewRequest("POST", "/echo", bytes.NewBufferString(`{"x":1}`))
+	w := httptest.NewRecorder()
+
+	Handler(endpoint)(w, req)
+
+	expected := `{"a": {"x":1}, "b": {"x":1}}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestHandler_MultipartForm(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/upload",
+		Method:   "POST",
+		Response: `{"title": "{{form.title}}", "file": "{{file.doc.filename}}", "size": {{file.doc.size}}, "missing": "{{form.missing}}"}`,
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := mw.WriteField("title", "Quarterly report"); err != nil {
+		t.Fatalf("Failed to write field: %v", err)
+	}
+	part, err := mw.CreateFormFile("doc", "report.txt")
+	if err != nil {
+		t.Fatalf("Failed to create file part: %v", err)
+	}
+	if _, err := part.Write([]byte("hello")); err != nil {
+		t.Fatalf("Failed to write file part: %v", err)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	Handler(endpoint)(w, req)
+
+	expected := `{"title": "Quarterly report", "file": "report.txt", "size": 5, "missing": ""}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestHandler_SkipsNonMultipartBody(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/upload",
+		Method:   "POST",
+		Response: `{"title": "{{form.title}}"}`,
+	}
+
+	body := &failingReader{}
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	Handler(endpoint)(w, req)
+
+	if body.read {
+		t.Error("Expected non-multipart body not to be read for {{form}} tokens")
+	}
+	if w.Body.String() != `{"title": ""}` {
+		t.Errorf("Expected empty form value, got %s", w.Body.String())
+	}
+}
+
+func TestPayloadTooLargeHandler(t *testing.T) {
+	handler := PayloadTooLargeHandler(16)
+
+	req := httptest.NewRequest("POST", "/upload", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if w.Code != 413 {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+
+	expectedBody := `{"error":"request body too large","limit":16}`
+	if w.Body.String() != expectedBody {
+		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
+	}
+}
+
 func TestHealthHandler(t *testing.T) {
 	handler := HealthHandler()
 
@@ -253,3 +821,219 @@ func TestNotFoundHandler(t *testing.T) {
 		t.Error("Expected Content-Type to be application/json")
 	}
 }
+
+func TestHandler_BinaryResponse(t *testing.T) {
+	// PNG signature followed by bytes that are not valid UTF-8
+	png := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0xff, '{', '{'}
+	endpoint := models.EndpointConfig{
+		Path:           "/logo.png",
+		Method:         "POST",
+		ResponseBase64: base64.StdEncoding.EncodeToString(png),
+		Headers:        map[string]string{"Content-Type": "image/png"},
+	}
+
+	for _, handler := range []http.HandlerFunc{Handler(endpoint), BenchHandler(endpoint)} {
+		body := &failingReader{}
+		req := httptest.NewRequest("POST", "/logo.png", body)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if body.read {
+			t.Error("Expected request body not to be read for a binary response")
+		}
+		if !bytes.Equal(w.Body.Bytes(), png) {
+			t.Errorf("Expected body %v, got %v", png, w.Body.Bytes())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+			t.Errorf("Expected Content-Type image/png, got %s", ct)
+		}
+	}
+
+	// Without a configured Content-Type, binary bytes are sent as octet-stream
+	endpoint.Headers = nil
+	w := httptest.NewRecorder()
+	Handler(endpoint)(w, httptest.NewRequest("GET", "/logo.png", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/octet-stream" {
+		t.Errorf("Expected Content-Type application/octet-stream, got %s", ct)
+	}
+}
+
+func TestHandler_FaultDropsConnection(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/flaky",
+		Method:   "GET",
+		Response: `{"ok": true}`,
+		Fault:    &models.FaultConfig{DropProbability: 1},
+	}
+
+	server := httptest.NewServer(Handler(endpoint))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/flaky")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected connection error for dropped request, got status %d", resp.StatusCode)
+	}
+
+	// A probability of zero never drops
+	endpoint.Fault = &models.FaultConfig{DropProbability: 0}
+	reliable := httptest.NewServer(Handler(endpoint))
+	defer reliable.Close()
+	resp, err = http.Get(reliable.URL + "/flaky")
+	if err != nil {
+		t.Fatalf("Expected response with drop_probability 0, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandler_FaultWithoutHijacker(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/flaky",
+		Method:   "GET",
+		Response: `{"ok": true}`,
+		Fault:    &models.FaultConfig{DropProbability: 1},
+	}
+
+	// httptest.ResponseRecorder cannot be hijacked, so the response is served
+	req := httptest.NewRequest("GET", "/flaky", nil)
+	w := httptest.NewRecorder()
+	Handler(endpoint)(w, req)
+
+	if w.Code != 200 || w.Body.String() != `{"ok": true}` {
+		t.Errorf("Expected normal response, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_TemplatedHeaders(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/api/echo/",
+		Method:   "GET",
+		Response: `{}`,
+		Headers: map[string]string{
+			"X-Echo-Path":  "{{path}}",
+			"X-Echo-User":  "user={{query.user}}",
+			"X-{{path}}":   "static",
+			"Content-Type": "application/json",
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/echo/items?user=alice", nil)
+	w := httptest.NewRecorder()
+	Handler(endpoint)(w, req)
+
+	if got := w.Header().Get("X-Echo-Path"); got != "/api/echo/items" {
+		t.Errorf("Expected X-Echo-Path /api/echo/items, got %q", got)
+	}
+	if got := w.Header().Get("X-Echo-User"); got != "user=alice" {
+		t.Errorf("Expected X-Echo-User user=alice, got %q", got)
+	}
+	// Header names are never templated
+	if got := w.Header().Get("X-{{path}}"); got != "static" {
+		t.Errorf("Expected header name to be left as written, got %q", got)
+	}
+
+	// Templated headers disable the precomputed bench response
+	w = httptest.NewRecorder()
+	BenchHandler(endpoint)(w, req)
+	if got := w.Header().Get("X-Echo-Path"); got != "/api/echo/items" {
+		t.Errorf("Expected bench handler to template X-Echo-Path, got %q", got)
+	}
+}
+
+func TestHandler_HeaderList(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/api/login",
+		Method:   "POST",
+		Response: `{}`,
+		Headers:  map[string]string{"Link": "</api/login>; rel=self"},
+		HeaderList: []models.HeaderConfig{
+			{Name: "Link", Value: "</api/users>; rel=next"},
+			{Name: "X-Trace", Value: "first"},
+			{Name: "X-Trace", Value: "second"},
+		},
+	}
+
+	req := httptest.NewRequest("POST", "/api/login", nil)
+	for name, handler := range map[string]http.HandlerFunc{"handler": Handler(endpoint), "bench": BenchHandler(endpoint)} {
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		// Repeated names are all emitted, in order, after the headers map
+		if got := w.Header().Values("X-Trace"); !slices.Equal(got, []string{"first", "second"}) {
+			t.Errorf("%s: expected X-Trace [first second], got %q", name, got)
+		}
+		if got := w.Header().Values("Link"); !slices.Equal(got, []string{"</api/login>; rel=self", "</api/users>; rel=next"}) {
+			t.Errorf("%s: expected both Link headers, got %q", name, got)
+		}
+	}
+
+	// Values are templated like the headers map
+	endpoint.HeaderList = []models.HeaderConfig{{Name: "X-Echo-Path", Value: "{{path}}"}}
+	w := httptest.NewRecorder()
+	BenchHandler(endpoint)(w, req)
+	if got := w.Header().Get("X-Echo-Path"); got != "/api/login" {
+		t.Errorf("Expected X-Echo-Path /api/login, got %q", got)
+	}
+}
+
+func TestHandler_RawResponse(t *testing.T) {
+	broken := `{"users": [{"id": 1, "name": "{{path}}"`
+	endpoint := models.EndpointConfig{
+		Path:        "/api/users",
+		Method:      "GET",
+		Response:    broken,
+		RawResponse: true,
+		Headers:     map[string]string{"Content-Type": "application/json"},
+	}
+
+	req := httptest.NewRequest("GET", "/api/users?x={{query.x}}", nil)
+	for name, handler := range map[string]http.HandlerFunc{"handler": Handler(endpoint), "bench": BenchHandler(endpoint)} {
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		// The truncated JSON and its token arrive exactly as configured
+		if w.Body.String() != broken {
+			t.Errorf("%s: expected %s, got %s", name, broken, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("%s: expected Content-Type application/json, got %q", name, got)
+		}
+	}
+}
+
+func TestHandler_RetryAfter(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:       "/api/orders",
+		Method:     "GET",
+		Status:     503,
+		Response:   `{"error": "maintenance"}`,
+		RetryAfter: "120",
+	}
+
+	for _, handler := range []http.HandlerFunc{Handler(endpoint), BenchHandler(endpoint)} {
+		req := httptest.NewRequest("GET", "/api/orders", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != 503 {
+			t.Errorf("Expected status 503, got %d", w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != "120" {
+			t.Errorf("Expected Retry-After 120, got %q", got)
+		}
+	}
+
+	// Variants that succeed get no Retry-After
+	endpoint.Status = 0
+	endpoint.Responses = []models.ResponseVariant{{Status: 200, Response: `{}`}}
+	w := httptest.NewRecorder()
+	Handler(endpoint)(w, httptest.NewRequest("GET", "/api/orders", nil))
+	if got := w.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Expected no Retry-After on a 200 response, got %q", got)
+	}
+}
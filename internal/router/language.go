@@ -0,0 +1,57 @@
+package router
+
+import (
+	"golang.org/x/text/language"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// localizedResponses picks the response for the best language in a
+// request's Accept-Language header
+type localizedResponses struct {
+	responses []models.LocalizedResponse
+	matcher   language.Matcher
+}
+
+// newLocalizedResponses indexes the configured languages. It returns nil
+// when there are none.
+func newLocalizedResponses(responses []models.LocalizedResponse) *localizedResponses {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	// Tags are validated when the configuration loads
+	tags := make([]language.Tag, len(responses))
+	for i, response := range responses {
+		tags[i] = language.Make(response.Lang)
+	}
+	return &localizedResponses{responses: responses, matcher: language.NewMatcher(tags)}
+}
+
+// match returns the response for the best configured language for
+// acceptLanguage, and false when none is close enough to serve. Matching
+// follows CLDR rules, so "en-US" is served "en", "zh-TW" is served
+// "zh-Hant" and "*" accepts nothing in particular.
+func (lr *localizedResponses) match(acceptLanguage string) (models.LocalizedResponse, bool) {
+	requested := parseAcceptLanguage(acceptLanguage)
+	if len(requested) == 0 {
+		return models.LocalizedResponse{}, false
+	}
+
+	_, index, confidence := lr.matcher.Match(requested...)
+	if confidence == language.No {
+		return models.LocalizedResponse{}, false
+	}
+	return lr.responses[index], true
+}
+
+// parseAcceptLanguage returns the language ranges of an Accept-Language
+// header ordered by descending quality, keeping header order when qualities
+// tie. Ranges with q=0 are dropped, and a malformed header gives none.
+func parseAcceptLanguage(header string) []language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil {
+		return nil
+	}
+	return tags
+}
@@ -0,0 +1,160 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// newLocalizedEndpoint returns an endpoint with English, British English and
+// French bodies and an untranslated default
+func newLocalizedEndpoint() models.EndpointConfig {
+	return models.EndpointConfig{
+		Path:     "/api/greeting",
+		Method:   "GET",
+		Response: `{"greeting": "hello"}`,
+		Localized: []models.LocalizedResponse{
+			{Lang: "en", Response: `{"greeting": "hi"}`},
+			{Lang: "en-GB", Response: `{"greeting": "hiya"}`},
+			{Lang: "fr", Response: `{"greeting": "bonjour"}`},
+		},
+	}
+}
+
+func TestHandler_LocalizedExactMatch(t *testing.T) {
+	endpoint := newLocalizedEndpoint()
+
+	for _, handler := range []http.HandlerFunc{Handler(endpoint), BenchHandler(endpoint)} {
+		req := httptest.NewRequest("GET", "/api/greeting", nil)
+		req.Header.Set("Accept-Language", "en-gb")
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Body.String() != `{"greeting": "hiya"}` {
+			t.Errorf("Expected en-GB body, got %s", w.Body.String())
+		}
+		if got := w.Header().Get("Content-Language"); got != "en-GB" {
+			t.Errorf("Expected Content-Language en-GB, got %q", got)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Language" {
+			t.Errorf("Expected Vary Accept-Language, got %q", got)
+		}
+	}
+}
+
+func TestHandler_LocalizedFallback(t *testing.T) {
+	handler := Handler(newLocalizedEndpoint())
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expectedBody   string
+		expectedLang   string
+	}{
+		{"more specific request", "fr-CA", `{"greeting": "bonjour"}`, "fr"},
+		{"same primary language", "en-US", `{"greeting": "hi"}`, "en"},
+		{"unconfigured language", "de-DE, ja", `{"greeting": "hello"}`, ""},
+		{"no header", "", `{"greeting": "hello"}`, ""},
+		{"wildcard", "*", `{"greeting": "hello"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/greeting", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.expectedLang {
+				t.Errorf("Expected Content-Language %q, got %q", tt.expectedLang, got)
+			}
+		})
+	}
+}
+
+func TestHandler_LocalizedQualityWeighting(t *testing.T) {
+	handler := Handler(newLocalizedEndpoint())
+
+	tests := []struct {
+		acceptLanguage string
+		expectedLang   string
+	}{
+		{"en;q=0.5, fr;q=0.9", "fr"},
+		{"de, fr;q=0.3, en;q=0.7", "en"},
+		{"fr;q=0, en-GB;q=0.1", "en-GB"},
+		{"fr;q=0.8, en;q=0.8", "fr"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/greeting", nil)
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if got := w.Header().Get("Content-Language"); got != tt.expectedLang {
+			t.Errorf("%q: expected Content-Language %q, got %q", tt.acceptLanguage, tt.expectedLang, got)
+		}
+	}
+}
+
+func TestHandler_LocalizedCLDRMatching(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:     "/api/greeting",
+		Method:   "GET",
+		Response: `{"greeting": "hello"}`,
+		Localized: []models.LocalizedResponse{
+			{Lang: "en-GB", Response: `{"greeting": "hiya"}`},
+			{Lang: "zh-Hans", Response: `{"greeting": "ni hao (simplified)"}`},
+			{Lang: "zh-Hant", Response: `{"greeting": "ni hao (traditional)"}`},
+		},
+	})
+
+	tests := []struct {
+		acceptLanguage string
+		expectedLang   string
+	}{
+		{"en_GB", "en-GB"},
+		{"zh-TW", "zh-Hant"},
+		{"zh-CN", "zh-Hans"},
+		{"de, *;q=0.5", ""},
+		{"en;q=bad", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/greeting", nil)
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if got := w.Header().Get("Content-Language"); got != tt.expectedLang {
+			t.Errorf("%q: expected Content-Language %q, got %q", tt.acceptLanguage, tt.expectedLang, got)
+		}
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	got := parseAcceptLanguage("da, en-GB;q=0.8, en;q=0.7, fr;q=0, *;q=0.1, pt_BR;q=0.9")
+	expected := []language.Tag{language.Danish, language.BrazilianPortuguese, language.BritishEnglish, language.English, language.MustParse("mul")}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// A malformed weight makes the whole header unusable
+	if got := parseAcceptLanguage("en, de;q=bad"); got != nil {
+		t.Errorf("Expected no ranges for a malformed header, got %v", got)
+	}
+}
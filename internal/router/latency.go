@@ -0,0 +1,108 @@
+package router
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// Standard normal quantiles for the 95th and 99th percentiles
+const (
+	z95 = 1.6448536269514722
+	z99 = 2.3263478740408408
+)
+
+// latencyDistribution samples delays from a log-normal distribution fitted
+// to configured percentiles
+type latencyDistribution struct {
+	mu    float64 // mean of the log latency in milliseconds
+	sigma float64 // standard deviation of the log latency
+}
+
+// newLatencyDistribution fits a log-normal distribution to the configured
+// percentiles. The median fixes mu; sigma is the least-squares fit to the
+// p95 and p99 targets that are set. It returns nil when cfg is nil.
+func newLatencyDistribution(cfg *models.LatencyConfig) *latencyDistribution {
+	if cfg == nil || cfg.P50 <= 0 {
+		return nil
+	}
+
+	d := &latencyDistribution{mu: math.Log(float64(cfg.P50))}
+	var num, den float64
+	if cfg.P95 > 0 {
+		num += z95 * (math.Log(float64(cfg.P95)) - d.mu)
+		den += z95 * z95
+	}
+	if cfg.P99 > 0 {
+		num += z99 * (math.Log(float64(cfg.P99)) - d.mu)
+		den += z99 * z99
+	}
+	if den > 0 && num > 0 {
+		d.sigma = num / den
+	}
+	return d
+}
+
+// sample draws a single delay
+func (d *latencyDistribution) sample(random *randomSource) time.Duration {
+	ms := math.Exp(d.mu + d.sigma*random.NormFloat64())
+	return time.Duration(math.Round(ms * float64(time.Millisecond)))
+}
+
+// delayRules picks the extra delay for a request from delay_when rules
+type delayRules struct {
+	rules     []models.DelayRule
+	needsBody bool // some rule compares body fields
+}
+
+// newDelayRules returns nil when no rules are configured
+func newDelayRules(rules []models.DelayRule) *delayRules {
+	if len(rules) == 0 {
+		return nil
+	}
+	d := &delayRules{rules: rules}
+	for _, rule := range rules {
+		if len(rule.Body) > 0 {
+			d.needsBody = true
+		}
+	}
+	return d
+}
+
+// match returns the delay of the first rule whose conditions all hold for r,
+// or zero when none matches. body is the already-read request body.
+func (d *delayRules) match(r *http.Request, body []byte) time.Duration {
+	query := r.URL.Query()
+	var parsed interface{}
+	parsedOK := false
+	if d.needsBody {
+		parsed, parsedOK = parseBody(r, body)
+	}
+
+rules:
+	for _, rule := range d.rules {
+		for key, value := range rule.Query {
+			if query.Get(key) != value {
+				continue rules
+			}
+		}
+		for key, value := range rule.Headers {
+			if r.Header.Get(key) != value {
+				continue rules
+			}
+		}
+		for path, value := range rule.Body {
+			if !parsedOK {
+				continue rules
+			}
+			field, ok := lookupPath(parsed, path)
+			if !ok || formatValue(field) != value {
+				continue rules
+			}
+		}
+		return time.Duration(rule.Delay) * time.Millisecond
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"math"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestLatencyDistribution_Percentiles(t *testing.T) {
+	cfg := &models.LatencyConfig{P50: 40, P95: 120, P99: 200}
+	d := newLatencyDistribution(cfg)
+
+	const n = 50000
+	samples := make([]time.Duration, n)
+	for i := range samples {
+		samples[i] = d.sample(nil)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	tests := []struct {
+		name     string
+		quantile float64
+		target   int
+	}{
+		{"p50", 0.50, cfg.P50},
+		{"p95", 0.95, cfg.P95},
+		{"p99", 0.99, cfg.P99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := float64(samples[int(tt.quantile*n)]) / float64(time.Millisecond)
+			// The log-normal fit cannot hit every target exactly, and sampling
+			// adds noise; 15% keeps the test stable while catching bad fits
+			if math.Abs(got-float64(tt.target))/float64(tt.target) > 0.15 {
+				t.Errorf("%s = %.1fms, want within 15%% of %dms", tt.name, got, tt.target)
+			}
+		})
+	}
+}
+
+func TestLatencyDistribution_MedianOnly(t *testing.T) {
+	d := newLatencyDistribution(&models.LatencyConfig{P50: 25})
+
+	for i := 0; i < 10; i++ {
+		if got := d.sample(nil); got != 25*time.Millisecond {
+			t.Fatalf("Expected constant 25ms without p95/p99, got %v", got)
+		}
+	}
+}
+
+func TestHandler_DelayWhen(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:     "/api/search",
+		Method:   "POST",
+		Response: `{"results": []}`,
+		DelayWhen: []models.DelayRule{
+			{Query: map[string]string{"simulate": "slow"}, Delay: 100},
+			{Headers: map[string]string{"X-Simulate": "slow"}, Delay: 100},
+			{Body: map[string]string{"options.mode": "slow"}, Delay: 100},
+		},
+	}
+	handler := newHandler(endpoint, nil, nil)
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		body   string
+		slow   bool
+	}{
+		{"no trigger", "", "", `{"options": {"mode": "fast"}}`, false},
+		{"other query value", "?simulate=fast", "", `{}`, false},
+		{"query trigger", "?simulate=slow", "", `{}`, true},
+		{"header trigger", "", "slow", `{}`, true},
+		{"body trigger", "", "", `{"options": {"mode": "slow"}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/search"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set("X-Simulate", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			start := time.Now()
+			handler(w, req)
+			elapsed := time.Since(start)
+
+			if w.Code != 200 || w.Body.String() != `{"results": []}` {
+				t.Fatalf("Expected the configured response, got %d %s", w.Code, w.Body.String())
+			}
+			if tt.slow && elapsed < 100*time.Millisecond {
+				t.Errorf("Expected a delay of at least 100ms, took %v", elapsed)
+			}
+			if !tt.slow && elapsed >= 100*time.Millisecond {
+				t.Errorf("Expected no delay, took %v", elapsed)
+			}
+		})
+	}
+}
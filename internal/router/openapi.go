@@ -0,0 +1,222 @@
+package router
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// OpenAPIPath is where the generated OpenAPI document is served
+const OpenAPIPath = "/__admin/openapi.json"
+
+// RegisterOpenAPI serves an OpenAPI 3 document describing the registered
+// endpoints at OpenAPIPath
+func (rt *Router) RegisterOpenAPI() {
+	rt.hasOpenAPI = true
+	rt.mux.HandleFunc(OpenAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(OpenAPIDocument(rt.GetEndpoints())); err != nil {
+			log.Printf("Failed to encode OpenAPI document: %v", err)
+		}
+	})
+	log.Printf("Registered OpenAPI endpoint: GET %s", OpenAPIPath)
+}
+
+// OpenAPIDocument builds an OpenAPI 3 document from endpoint configs. Each
+// endpoint contributes its status (and those of its response variants), with
+// the configured response as the example and a schema inferred from it.
+func OpenAPIDocument(endpoints []models.EndpointConfig) map[string]interface{} {
+	paths := make(map[string]interface{})
+	explicit := make(map[string]bool) // "path method" pairs of endpoints with a specific method
+	for _, endpoint := range endpoints {
+		if endpoint.IsAnyMethod() {
+			continue
+		}
+		method := strings.ToLower(endpoint.Method)
+		if method == "" {
+			method = "get"
+		}
+		explicit[endpoint.Path+" "+method] = true
+		addOpenAPIOperation(paths, endpoint, method)
+	}
+
+	// OpenAPI has no wildcard method, so wildcard endpoints describe every
+	// method not configured explicitly on their path
+	for _, endpoint := range endpoints {
+		if !endpoint.IsAnyMethod() {
+			continue
+		}
+		for _, method := range openAPIMethods {
+			if !explicit[endpoint.Path+" "+method] {
+				addOpenAPIOperation(paths, endpoint, method)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Bland Mock API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+	}
+}
+
+// openAPIMethods are the operations an OpenAPI path item can describe
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// addOpenAPIOperation adds endpoint's responses to the operation for method
+// (lowercase) on its path
+func addOpenAPIOperation(paths map[string]interface{}, endpoint models.EndpointConfig, method string) {
+	item, ok := paths[endpoint.Path].(map[string]interface{})
+	if !ok {
+		item = make(map[string]interface{})
+		paths[endpoint.Path] = item
+	}
+
+	operation, ok := item[method].(map[string]interface{})
+	if !ok {
+		operation = map[string]interface{}{
+			"responses": make(map[string]interface{}),
+		}
+		if endpoint.Description != "" {
+			operation["summary"] = endpoint.Description
+		}
+		item[method] = operation
+	}
+	responses := operation["responses"].(map[string]interface{})
+
+	// Endpoints sharing a route (e.g. per SNI server name) keep the first
+	// definition of each status
+	if endpoint.IsGRPCWeb() {
+		addBinaryOpenAPIResponse(responses, endpoint.Status, endpoint.Headers, grpcWebContentType)
+	} else if endpoint.ResponseBase64 != "" {
+		addBinaryOpenAPIResponse(responses, endpoint.Status, endpoint.Headers, "application/octet-stream")
+	} else {
+		addOpenAPIResponse(responses, endpoint.Status, endpoint.Response, endpoint.Headers)
+	}
+	for _, variant := range endpoint.Responses {
+		status := variant.Status
+		if status == 0 {
+			status = endpoint.Status
+		}
+		headers := make(map[string]string, len(endpoint.Headers)+len(variant.Headers))
+		for key, value := range endpoint.Headers {
+			headers[key] = value
+		}
+		for key, value := range variant.Headers {
+			headers[key] = value
+		}
+		addOpenAPIResponse(responses, status, variant.Response, headers)
+	}
+}
+
+// addOpenAPIResponse adds a response object for status unless one exists
+func addOpenAPIResponse(responses map[string]interface{}, status int, body string, headers map[string]string) {
+	code, response, ok := newOpenAPIResponse(responses, status)
+	if !ok {
+		return
+	}
+
+	if strings.TrimSpace(body) != "" {
+		contentType := openAPIContentType(headers, "application/json")
+
+		media := map[string]interface{}{}
+		var example interface{}
+		if strings.Contains(contentType, "json") && json.Unmarshal([]byte(body), &example) == nil {
+			media["example"] = example
+			media["schema"] = inferSchema(example)
+		} else {
+			// Templated or non-JSON bodies are described as strings
+			media["example"] = body
+			media["schema"] = map[string]interface{}{"type": "string"}
+		}
+		response["content"] = map[string]interface{}{contentType: media}
+	}
+
+	responses[code] = response
+}
+
+// addBinaryOpenAPIResponse adds a response object for a response_base64 or
+// grpc-web endpoint unless one exists for status
+func addBinaryOpenAPIResponse(responses map[string]interface{}, status int, headers map[string]string, contentType string) {
+	code, response, ok := newOpenAPIResponse(responses, status)
+	if !ok {
+		return
+	}
+	response["content"] = map[string]interface{}{
+		openAPIContentType(headers, contentType): map[string]interface{}{
+			"schema": map[string]interface{}{"type": "string", "format": "binary"},
+		},
+	}
+	responses[code] = response
+}
+
+// newOpenAPIResponse creates the response object for status, reporting false
+// when responses already documents it
+func newOpenAPIResponse(responses map[string]interface{}, status int) (string, map[string]interface{}, bool) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	code := strconv.Itoa(status)
+	if _, exists := responses[code]; exists {
+		return code, nil, false
+	}
+
+	description := http.StatusText(status)
+	if description == "" {
+		description = "Status " + code
+	}
+	return code, map[string]interface{}{"description": description}, true
+}
+
+// openAPIContentType returns the media type of the configured Content-Type
+// header, or fallback when none is set
+func openAPIContentType(headers map[string]string, fallback string) string {
+	contentType := fallback
+	for key, value := range headers {
+		if strings.EqualFold(key, "Content-Type") {
+			contentType = value
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	return contentType
+}
+
+// inferSchema derives a JSON Schema from an example value. Arrays take the
+// schema of their first element.
+func inferSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			properties[key] = inferSchema(child)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	case []interface{}:
+		schema := map[string]interface{}{"type": "array", "items": map[string]interface{}{}}
+		if len(v) > 0 {
+			schema["items"] = inferSchema(v[0])
+		}
+		return schema
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case float64:
+		if v == math.Trunc(v) {
+			return map[string]interface{}{"type": "integer"}
+		}
+		return map[string]interface{}{"type": "number"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	}
+	return map[string]interface{}{"nullable": true}
+}
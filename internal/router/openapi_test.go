@@ -0,0 +1,92 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestRegisterOpenAPI(t *testing.T) {
+	rt := New()
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/users", Method: "GET", Status: 200, Response: `{"users": [{"id": 1, "name": "Alice"}]}`},
+		{Path: "/api/users", Method: "POST", Status: 201, Response: `{"id": 2}`},
+		{Path: "/api/flaky", Method: "GET", Responses: []models.ResponseVariant{
+			{Status: 200, Response: `{"ok": true}`},
+			{Status: 503, Response: `{"ok": false}`},
+		}},
+	}
+	if err := rt.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+	rt.RegisterOpenAPI()
+
+	req := httptest.NewRequest("GET", OpenAPIPath, nil)
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]struct {
+					Example interface{}            `json:"example"`
+					Schema  map[string]interface{} `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected openapi 3.0.3, got %q", doc.OpenAPI)
+	}
+
+	expected := map[string]map[string]string{
+		"/api/users": {"get": "200", "post": "201"},
+		"/api/flaky": {"get": "503"},
+	}
+	for path, methods := range expected {
+		for method, status := range methods {
+			if _, ok := doc.Paths[path][method].Responses[status]; !ok {
+				t.Errorf("Expected %s %s to document status %s", method, path, status)
+			}
+		}
+	}
+
+	schema := doc.Paths["/api/users"]["get"].Responses["200"].Content["application/json"].Schema
+	if schema["type"] != "object" {
+		t.Errorf("Expected inferred object schema, got %v", schema)
+	}
+}
+
+func TestOpenAPIDocument_WildcardMethod(t *testing.T) {
+	doc := OpenAPIDocument([]models.EndpointConfig{
+		{Path: "/api/items", Method: "*", Status: 202, Response: `{"any": true}`},
+		{Path: "/api/items", Method: "GET", Status: 200, Response: `{"items": []}`},
+	})
+
+	item := doc["paths"].(map[string]interface{})["/api/items"].(map[string]interface{})
+	if _, ok := item["*"]; ok {
+		t.Error("Expected no \"*\" operation in the document")
+	}
+	for method, status := range map[string]string{"get": "200", "post": "202", "delete": "202", "patch": "202"} {
+		operation, ok := item[method].(map[string]interface{})
+		if !ok {
+			t.Errorf("Expected a %s operation", method)
+			continue
+		}
+		responses := operation["responses"].(map[string]interface{})
+		if _, ok := responses[status]; !ok || len(responses) != 1 {
+			t.Errorf("Expected %s to document only status %s, got %v", method, status, responses)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package router
+
+import "bytes"
+
+// paddingToken marks where pad_to_bytes inserts its filler
+const paddingToken = "{{padding}}"
+
+// padResponse grows response to size bytes for pad_to_bytes. The filler
+// replaces the first {{padding}} token, so it can sit inside a JSON string;
+// without the token, trailing spaces are appended, which keeps JSON valid.
+// Responses already at or past size are only stripped of the token.
+func padResponse(response []byte, size int) []byte {
+	i := bytes.Index(response, []byte(paddingToken))
+	if i < 0 {
+		if len(response) >= size {
+			return response
+		}
+		return append(response, bytes.Repeat([]byte(" "), size-len(response))...)
+	}
+
+	rest := len(response) - len(paddingToken)
+	padded := make([]byte, 0, max(size, rest))
+	padded = append(padded, response[:i]...)
+	padded = append(padded, bytes.Repeat([]byte("x"), max(size-rest, 0))...)
+	return append(padded, response[i+len(paddingToken):]...)
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestHandler_PadToBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		size     int
+		expected int
+	}{
+		{"padding token", `{"id": {{query.id}}, "filler": "{{padding}}"}`, 4096, 4096},
+		{"trailing whitespace", `{"id": 1}`, 1024, 1024},
+		{"already larger", `{"id": 1, "name": "a long enough name"}`, 10, len(`{"id": 1, "name": "a long enough name"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Handler(models.EndpointConfig{
+				Path:       "/api/payload",
+				Method:     "GET",
+				Response:   tt.response,
+				PadToBytes: tt.size,
+			})
+
+			req := httptest.NewRequest("GET", "/api/payload?id=7", nil)
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Body.Len() != tt.expected {
+				t.Errorf("Expected %d bytes, got %d", tt.expected, w.Body.Len())
+			}
+			if !json.Valid(w.Body.Bytes()) {
+				t.Errorf("Expected valid JSON, got %s", w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), paddingToken) {
+				t.Errorf("Expected the padding token to be replaced, got %s", w.Body.String())
+			}
+		})
+	}
+}
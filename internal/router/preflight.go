@@ -0,0 +1,182 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// sampleBody is the request body templates are rendered with by
+// CheckTemplates, so {{body}} renders as valid JSON
+const sampleBody = `{}`
+
+// ErrUndefinedToken is wrapped by CheckTemplates errors for tokens no
+// template variable or function defines
+var ErrUndefinedToken = errors.New("undefined template token")
+
+// unrenderedTokenPattern matches template tokens left in a rendered response
+var unrenderedTokenPattern = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// CheckTemplates renders every template of endpoint for a sample request,
+// with no query parameters and an empty JSON object body, and reports tokens
+// no template variable or function defines, and JSON bodies that no longer
+// parse once their tokens are substituted. Besides the responses, it checks
+// the bodies served on rejection or after a quota or outage, and the header,
+// cookie, callback and gRPC message values. Binary and raw responses are not
+// checked, but their header values are.
+func CheckTemplates(endpoint models.EndpointConfig) []error {
+	if !endpoint.IsTemplated() {
+		return nil
+	}
+	if len(endpoint.MethodResponses) > 0 {
+		var errs []error
+		for _, expanded := range endpoint.ExpandMethods() {
+			errs = append(errs, CheckTemplates(expanded)...)
+		}
+		return errs
+	}
+
+	label := "endpoint " + endpoint.RouteKey()
+	var errs []error
+	body := func(field, template string) {
+		errs = append(errs, checkTemplate(endpoint, label+": "+field, template, expectsJSON(endpoint, template))...)
+	}
+	value := func(field, template string) {
+		errs = append(errs, checkTemplate(endpoint, label+": "+field, template, false)...)
+	}
+
+	if endpoint.ResponseBase64 == "" && !endpoint.RawResponse {
+		body("response", endpoint.Response)
+		for i, variant := range endpoint.Responses {
+			body(fmt.Sprintf("responses[%d]", i), variant.Response)
+		}
+		for i, localized := range endpoint.Localized {
+			body(fmt.Sprintf("localized[%d]", i), localized.Response)
+		}
+	}
+	body("anonymous_response", endpoint.AnonymousResponse)
+	body("required_headers_response", endpoint.RequiredHeadersResponse)
+	if endpoint.Quota != nil {
+		body("quota.response", endpoint.Quota.Response)
+	}
+	if endpoint.Proxy != nil {
+		body("proxy.fallback_response", endpoint.Proxy.FallbackResponse)
+	}
+
+	for _, key := range sortedKeys(endpoint.Headers) {
+		value("headers."+key, endpoint.Headers[key])
+	}
+	for i, header := range endpoint.HeaderList {
+		value(fmt.Sprintf("header[%d]", i), header.Value)
+	}
+	for i, variant := range endpoint.Responses {
+		for _, key := range sortedKeys(variant.Headers) {
+			value(fmt.Sprintf("responses[%d].headers.%s", i, key), variant.Headers[key])
+		}
+	}
+	for i, cookie := range endpoint.Cookies {
+		value(fmt.Sprintf("cookies[%d]", i), cookie.Value)
+	}
+	value("grpc_message", endpoint.GRPCMessage)
+
+	if callback := endpoint.Callback; callback != nil {
+		value("callback.url", callback.URL)
+		errs = append(errs, checkTemplate(endpoint, label+": callback.body", callback.Body, callback.IsJSON())...)
+		for _, key := range sortedKeys(callback.Headers) {
+			value("callback.headers."+key, callback.Headers[key])
+		}
+	}
+	return errs
+}
+
+// sortedKeys returns the keys of m in order, so errors are reported
+// deterministically
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// UndefinedTokens reports only the CheckTemplates errors for tokens that no
+// template variable or function defines
+func UndefinedTokens(endpoint models.EndpointConfig) []error {
+	var errs []error
+	for _, err := range CheckTemplates(endpoint) {
+		if errors.Is(err, ErrUndefinedToken) {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkTemplate renders one template for the sample request, checking that
+// it is valid JSON afterwards when asJSON is set
+func checkTemplate(endpoint models.EndpointConfig, label, template string, asJSON bool) []error {
+	if !hasTemplateTokens(template) {
+		// Static JSON is already checked by Config.Validate
+		return nil
+	}
+
+	method := endpoint.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	if endpoint.IsAnyMethod() {
+		// Render as a request whose body templates can read
+		method = http.MethodPost
+	}
+	r, err := http.NewRequest(method, "/", strings.NewReader(sampleBody))
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", label, err)}
+	}
+	r.URL.Path = endpoint.Path
+	r.Header.Set("Content-Type", "application/json")
+
+	template = strings.ReplaceAll(template, "{{counter}}", "1")
+	if endpoint.PadToBytes > 0 {
+		template = strings.ReplaceAll(template, paddingToken, "")
+	}
+	rendered := renderResponse(template, r, []byte(sampleBody), asJSON)
+
+	var errs []error
+	for _, token := range unrenderedTokenPattern.FindAllString(rendered, -1) {
+		// Query tokens are kept as written when the parameter is absent
+		if strings.HasPrefix(token, "{{query.") {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w %s", label, ErrUndefinedToken, token))
+	}
+	if asJSON && strings.TrimSpace(rendered) != "" && !json.Valid([]byte(rendered)) {
+		errs = append(errs, fmt.Errorf("%s: renders invalid JSON for a request without query parameters and an empty JSON body: %s", label, abbreviate(rendered, 80)))
+	}
+	return errs
+}
+
+// expectsJSON reports whether template is served as JSON: the endpoint
+// declares a JSON Content-Type, or sets none and the template looks like a
+// JSON object or array
+func expectsJSON(endpoint models.EndpointConfig, template string) bool {
+	if contentType := endpoint.ConfiguredContentType(); contentType != "" {
+		return strings.Contains(strings.ToLower(contentType), "json")
+	}
+	trimmed := strings.TrimSpace(template)
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+// abbreviate shortens s to at most n bytes for error messages
+func abbreviate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestCheckTemplates_Valid(t *testing.T) {
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/users", Method: "POST", Response: `{"received": {{body}}, "path": "{{path}}", "n": {{counter}}}`},
+		{Path: "/api/users/", Response: `{"name": "{{body.name}}", "id": "{{query.id}}", "at": "{{now}}", "up": "{{upper .Method}}"}`},
+		{Path: "/text", Response: "Hello {{body.name}}", Headers: map[string]string{"Content-Type": "text/plain"}},
+		{Path: "/static", Response: `{"ok": true}`},
+		{Path: "/logo.png", ResponseBase64: "iVBORw0KGgo="},
+	}
+
+	for _, endpoint := range endpoints {
+		if errs := CheckTemplates(endpoint); len(errs) > 0 {
+			t.Errorf("Expected %s to render valid JSON, got %v", endpoint.Path, errs)
+		}
+	}
+}
+
+func TestCheckTemplates_InvalidJSONAfterSubstitution(t *testing.T) {
+	// {{body.id}} renders as nothing when the body has no id, leaving "id":
+	// without a value
+	endpoint := models.EndpointConfig{
+		Path:     "/api/orders",
+		Method:   "POST",
+		Response: `{"id": {{body.id}}, "status": "created"}`,
+		Responses: []models.ResponseVariant{
+			{Response: `{"echo": {{query.q}}}`},
+		},
+	}
+
+	errs := CheckTemplates(endpoint)
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errs)
+	}
+	expected := []string{
+		`endpoint POST /api/orders: response: renders invalid JSON for a request without query parameters and an empty JSON body: {"id": , "status": "created"}`,
+		`endpoint POST /api/orders: responses[0]: renders invalid JSON`,
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("Expected error containing %q, got %q", expected[i], err.Error())
+		}
+	}
+}
+
+func TestCheckTemplates_UndefinedToken(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:            "/api/items",
+		Headers:         map[string]string{"Content-Type": "text/plain"},
+		MethodResponses: map[string]string{"GET": "items for {{usr_id}}", "DELETE": "deleted {{whisper .Path}}"},
+	}
+
+	errs := CheckTemplates(endpoint)
+	expected := []string{
+		"endpoint DELETE /api/items: response: undefined template token {{whisper .Path}}",
+		"endpoint GET /api/items: response: undefined template token {{usr_id}}",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], err.Error())
+		}
+	}
+}
+
+func TestCheckTemplates_EveryTemplatedField(t *testing.T) {
+	endpoint := models.EndpointConfig{
+		Path:                    "/api/jobs",
+		Method:                  "POST",
+		Response:                `{"ok": true}`,
+		Headers:                 map[string]string{"X-Job": "{{jobid}}"},
+		HeaderList:              []models.HeaderConfig{{Name: "Link", Value: "<{{lnk}}>"}},
+		Cookies:                 []models.CookieConfig{{Name: "session", Value: "{{sess}}"}},
+		AnonymousResponse:       `{"user": "{{anon}}"}`,
+		RequiredHeaders:         []models.RequiredHeader{{Name: "X-Api-Key"}},
+		RequiredHeadersResponse: `{"error": "{{missing}}"}`,
+		Quota:                   &models.QuotaConfig{Limit: 1, Response: `{"retry": {{body.retry}}}`},
+		Callback: &models.CallbackConfig{
+			URL:     "{{hook}}",
+			Body:    `{"job": "{{jobid}}"}`,
+			Headers: map[string]string{"X-Sig": "{{sig}}"},
+		},
+		Type:        models.TypeGRPCWeb,
+		GRPCMessage: "{{reason}}",
+	}
+
+	errs := CheckTemplates(endpoint)
+	expected := []string{
+		"anonymous_response: undefined template token {{anon}}",
+		"required_headers_response: undefined template token {{missing}}",
+		"quota.response: renders invalid JSON",
+		"headers.X-Job: undefined template token {{jobid}}",
+		"header[0]: undefined template token {{lnk}}",
+		"cookies[0]: undefined template token {{sess}}",
+		"grpc_message: undefined template token {{reason}}",
+		"callback.url: undefined template token {{hook}}",
+		"callback.body: undefined template token {{jobid}}",
+		"callback.headers.X-Sig: undefined template token {{sig}}",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("Expected error containing %q, got %q", expected[i], err.Error())
+		}
+	}
+
+	proxied := models.EndpointConfig{Path: "/api/orders", Proxy: &models.ProxyConfig{URL: "http://orders.internal", FallbackResponse: `{"orders": {{cached}}}`}}
+	errs = CheckTemplates(proxied)
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "proxy.fallback_response: undefined template token {{cached}}") {
+		t.Errorf("Expected the fallback response to be checked, got %v", errs)
+	}
+}
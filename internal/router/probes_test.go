@@ -0,0 +1,53 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestRouterHandler_Probes(t *testing.T) {
+	readiness := &Readiness{}
+	router := New()
+	router.SetDefaultEndpoint(&models.DefaultEndpointConfig{Status: 200, Response: `{"stub": true}`})
+	router.RegisterLiveness("/livez")
+	router.RegisterReadiness("/readyz", readiness)
+
+	probe := func(path string) (int, string) {
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code, w.Body.String()
+	}
+
+	// Probes are matched ahead of the default endpoint
+	if code, body := probe("/livez"); code != http.StatusOK || body != `{"status":"alive"}` {
+		t.Errorf("Expected live probe, got %d %s", code, body)
+	}
+	if code, body := probe("/readyz"); code != http.StatusOK || body != `{"status":"ready"}` {
+		t.Errorf("Expected ready probe, got %d %s", code, body)
+	}
+
+	// Only readiness follows the reload state
+	readiness.SetReady(false)
+	if code, body := probe("/readyz"); code != http.StatusServiceUnavailable || body != `{"status":"not ready"}` {
+		t.Errorf("Expected 503 while not ready, got %d %s", code, body)
+	}
+	if code, _ := probe("/livez"); code != http.StatusOK {
+		t.Errorf("Expected liveness to stay 200 while not ready, got %d", code)
+	}
+
+	readiness.SetReady(true)
+	if code, _ := probe("/readyz"); code != http.StatusOK {
+		t.Errorf("Expected 200 once ready again, got %d", code)
+	}
+}
+
+func TestReadinessHandler_Nil(t *testing.T) {
+	w := httptest.NewRecorder()
+	ReadinessHandler(nil)(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected a nil readiness to be ready, got %d", w.Code)
+	}
+}
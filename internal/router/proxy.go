@@ -0,0 +1,143 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// proxyHeadersKey is the request context key of the response headers set
+// before the request was proxied: default, CORS and request ID headers and
+// the endpoint's own
+type proxyHeadersKey struct{}
+
+// proxyHandler forwards requests to the endpoint's upstream, appending the
+// request path and query to the upstream URL. When the upstream cannot be
+// reached or sends no response headers within the timeout, the fallback is
+// served instead.
+func proxyHandler(endpoint models.EndpointConfig, accessLog *accessLogger) http.HandlerFunc {
+	cfg := endpoint.Proxy
+	target, err := url.Parse(cfg.URL)
+	if err != nil {
+		// Rejected by config validation; every request gets the fallback
+		log.Printf("Invalid proxy url for %s %s: %v", endpoint.Method, endpoint.Path, err)
+	}
+
+	// The timeout bounds connecting and waiting for headers, not the body,
+	// so slow downloads from a healthy upstream are not cut off
+	timeout := cfg.GetTimeout()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+
+	// Configured headers replace the upstream's like the CORS policy does
+	owned := map[string]bool{RequestIDHeader: true}
+	for key := range endpoint.Headers {
+		owned[http.CanonicalHeaderKey(key)] = true
+	}
+	for _, header := range endpoint.HeaderList {
+		owned[http.CanonicalHeaderKey(header.Name)] = true
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+		Transport: transport,
+		ModifyResponse: func(resp *http.Response) error {
+			if preset, ok := resp.Request.Context().Value(proxyHeadersKey{}).(http.Header); ok {
+				mergeProxyHeaders(preset, resp.Header, owned)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				PayloadTooLargeHandler(maxBytesErr.Limit)(w, r)
+				return
+			}
+			proxyFallback(w, r, endpoint, err)
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessLog.log(r)
+
+		// Set configured headers, templating values but never names. The
+		// body is left unread for the upstream, so body tokens render empty.
+		for key, value := range endpoint.Headers {
+			w.Header().Set(key, proxyHeaderValue(endpoint, value, r))
+		}
+		for _, header := range endpoint.HeaderList {
+			w.Header().Add(header.Name, proxyHeaderValue(endpoint, header.Value, r))
+		}
+
+		if target == nil {
+			proxyFallback(w, r, endpoint, err)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), proxyHeadersKey{}, w.Header()))
+		proxy.ServeHTTP(w, r)
+	}
+}
+
+// proxyHeaderValue renders a configured header value for a proxied request
+func proxyHeaderValue(endpoint models.EndpointConfig, value string, r *http.Request) string {
+	if !endpoint.IsTemplated() {
+		return value
+	}
+	return renderResponse(value, r, nil, false)
+}
+
+// mergeProxyHeaders resolves the headers both the mock and the upstream set,
+// which the reverse proxy would otherwise send twice. The CORS policy, the
+// request ID and the endpoint's configured headers (owned) are the mock's
+// and replace the upstream's; default headers yield to the upstream like
+// they do to any handler. Vary lists are combined.
+func mergeProxyHeaders(preset, upstream http.Header, owned map[string]bool) {
+	for name := range preset {
+		if _, sent := upstream[name]; !sent || name == "Vary" {
+			continue
+		}
+		if owned[name] || strings.HasPrefix(name, "Access-Control-") {
+			upstream.Del(name)
+		} else {
+			preset.Del(name)
+		}
+	}
+}
+
+// proxyFallback answers a request whose upstream failed with the configured
+// fallback response, or the error envelope when there is none
+func proxyFallback(w http.ResponseWriter, r *http.Request, endpoint models.EndpointConfig, err error) {
+	cfg := endpoint.Proxy
+	status := cfg.GetFallbackStatus()
+	log.Printf("[%d] %s %s upstream %s failed: %v", status, r.Method, r.URL.Path, cfg.URL, err)
+
+	if cfg.FallbackResponse != "" {
+		response := cfg.FallbackResponse
+		if endpoint.IsTemplated() {
+			response = processResponse(response, r)
+		}
+		// Configured headers were set before proxying
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", defaultContentType(endpoint))
+		}
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(response)); err != nil {
+			log.Printf("Failed to write %d response: %v", status, err)
+		}
+		return
+	}
+
+	writeError(w, r, status, "upstream unavailable", err.Error(), `{"error":"upstream unavailable"}`)
+}
@@ -0,0 +1,174 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestHandler_ProxyForwards(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "real")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery))
+	}))
+	defer upstream.Close()
+
+	handler := Handler(models.EndpointConfig{Path: "/api/orders", Method: "POST", Proxy: &models.ProxyConfig{URL: upstream.URL + "/v2"}})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/orders?id=7", nil))
+	if w.Code != 201 || w.Body.String() != "/v2/api/orders?id=7" || w.Header().Get("X-Upstream") != "real" {
+		t.Errorf("Expected the upstream response, got %d %s %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestHandler_ProxyDeadUpstream(t *testing.T) {
+	// A closed server leaves an address nothing listens on
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	deadURL := upstream.URL
+	upstream.Close()
+
+	tests := []struct {
+		name     string
+		proxy    models.ProxyConfig
+		status   int
+		expected string
+	}{
+		{"fallback response", models.ProxyConfig{URL: deadURL, FallbackStatus: 503, FallbackResponse: `{"orders": [], "path": "{{path}}"}`}, 503, `{"orders": [], "path": "/api/orders"}`},
+		{"fallback status", models.ProxyConfig{URL: deadURL, FallbackStatus: 200, FallbackResponse: `{"orders": []}`}, 200, `{"orders": []}`},
+		{"default 502", models.ProxyConfig{URL: deadURL}, 502, `{"error":"upstream unavailable"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := tt.proxy
+			handler := Handler(models.EndpointConfig{Path: "/api/orders", Method: "GET", Proxy: &proxy})
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", "/api/orders", nil))
+			if w.Code != tt.status || w.Body.String() != tt.expected {
+				t.Errorf("Expected %d %s, got %d %s", tt.status, tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ProxyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	handler := Handler(models.EndpointConfig{Path: "/api/slow", Method: "GET", Proxy: &models.ProxyConfig{
+		URL:              upstream.URL,
+		TimeoutMS:        50,
+		FallbackStatus:   504,
+		FallbackResponse: `{"error": "upstream too slow"}`,
+	}})
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	handler(w, httptest.NewRequest("GET", "/api/slow", nil))
+	elapsed := time.Since(start)
+
+	if w.Code != 504 || w.Body.String() != `{"error": "upstream too slow"}` {
+		t.Errorf("Expected the fallback after the timeout, got %d %s", w.Code, w.Body.String())
+	}
+	if elapsed > time.Second {
+		t.Errorf("Expected the request to end at the timeout, took %v", elapsed)
+	}
+}
+
+func TestHandler_ProxyHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_, _ = w.Write([]byte("<p>ok</p>"))
+	}))
+	defer upstream.Close()
+
+	rt := New()
+	rt.SetDefaultHeaders(map[string]string{"Content-Type": "application/json", "X-Mock": "bland"})
+	rt.SetCORS(&models.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	if err := rt.RegisterEndpoint(models.EndpointConfig{
+		Path:    "/api/pages",
+		Method:  "GET",
+		Headers: map[string]string{"Cache-Control": "max-age=60", "X-Path": "{{path}}"},
+		Proxy:   &models.ProxyConfig{URL: upstream.URL},
+	}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/pages", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, req)
+
+	header := w.Header()
+	expected := map[string]string{
+		"Content-Type":                "text/html",               // upstream beats default headers
+		"X-Mock":                      "bland",                   // defaults fill in the rest
+		"Cache-Control":               "max-age=60",              // endpoint headers beat the upstream
+		"X-Path":                      "/api/pages",              // and are templated
+		"Access-Control-Allow-Origin": "https://app.example.com", // so does the CORS policy
+	}
+	for name, value := range expected {
+		if got := header.Values(name); len(got) != 1 || got[0] != value {
+			t.Errorf("Expected %s %q once, got %q", name, value, got)
+		}
+	}
+}
+
+func TestHandler_ProxyFallbackHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	deadURL := upstream.URL
+	upstream.Close()
+
+	handler := Handler(models.EndpointConfig{
+		Path:       "/api/status",
+		Method:     "GET",
+		Headers:    map[string]string{"Content-Type": "text/plain"},
+		HeaderList: []models.HeaderConfig{{Name: "X-Fallback", Value: "true"}},
+		Proxy:      &models.ProxyConfig{URL: deadURL, FallbackResponse: "degraded"},
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/status", nil))
+	if w.Code != 502 || w.Body.String() != "degraded" {
+		t.Errorf("Expected the fallback, got %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/plain" || w.Header().Get("X-Fallback") != "true" {
+		t.Errorf("Expected the configured headers on the fallback, got %v", w.Header())
+	}
+}
+
+func TestHandler_ProxyMaxBodyBytes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer upstream.Close()
+
+	rt := New()
+	rt.SetMaxBodyBytes(4)
+	if err := rt.RegisterEndpoint(models.EndpointConfig{Path: "/api/upload", Method: "POST", Proxy: &models.ProxyConfig{URL: upstream.URL}}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	// A chunked body has no Content-Length, so the limit is hit while
+	// forwarding it
+	req := httptest.NewRequest("POST", "/api/upload", io.NopCloser(strings.NewReader("far too long")))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(w, req)
+	if w.Code != 413 {
+		t.Errorf("Expected 413 for an oversized proxied body, got %d %s", w.Code, w.Body.String())
+	}
+}
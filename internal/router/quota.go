@@ -0,0 +1,81 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// quotaCounter counts the requests an endpoint has served against its quota.
+// With a window the count starts over once the window that began with the
+// first counted request has passed. It is safe for concurrent use.
+type quotaCounter struct {
+	limit  int
+	window time.Duration // 0 never resets
+
+	mu    sync.Mutex
+	count int
+	start time.Time // start of the current window
+}
+
+// newQuotaCounter returns nil when cfg is nil
+func newQuotaCounter(cfg *models.QuotaConfig) *quotaCounter {
+	if cfg == nil || cfg.Limit <= 0 {
+		return nil
+	}
+	return &quotaCounter{limit: cfg.Limit, window: time.Duration(cfg.WindowMS) * time.Millisecond}
+}
+
+// take counts a request, reporting whether it is within the quota and, for
+// windowed quotas, how long until the count resets
+func (q *quotaCounter) take(now time.Time) (allowed bool, reset time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.window > 0 && (q.start.IsZero() || now.Sub(q.start) >= q.window) {
+		q.start = now
+		q.count = 0
+	}
+	q.count++
+	if q.window > 0 {
+		reset = q.start.Add(q.window).Sub(now)
+	}
+	return q.count <= q.limit, reset
+}
+
+// rejectOverQuota answers a request beyond the endpoint's quota with its
+// configured status and body, or the error envelope. Windowed quotas send a
+// Retry-After for when the count resets unless the endpoint sets its own.
+func rejectOverQuota(w http.ResponseWriter, r *http.Request, endpoint models.EndpointConfig, reset time.Duration) {
+	quota := endpoint.Quota
+	status := quota.GetStatus()
+	log.Printf("[%d] %s %s exceeds quota of %d requests", status, r.Method, r.URL.Path, quota.Limit)
+
+	if endpoint.RetryAfter != "" {
+		w.Header().Set("Retry-After", string(endpoint.RetryAfter))
+	} else if reset > 0 {
+		seconds := int((reset + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+
+	if quota.Response != "" {
+		response := quota.Response
+		if endpoint.IsTemplated() {
+			response = processResponse(response, r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(response)); err != nil {
+			log.Printf("Failed to write %d response: %v", status, err)
+		}
+		return
+	}
+
+	response := fmt.Sprintf(`{"error":"quota exceeded","limit":%d}`, quota.Limit)
+	writeError(w, r, status, "quota exceeded", fmt.Sprintf("more than %d requests", quota.Limit), response)
+}
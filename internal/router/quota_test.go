@@ -0,0 +1,92 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestHandler_Quota(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:     "/api/work",
+		Method:   "GET",
+		Response: `{"done": true}`,
+		Quota:    &models.QuotaConfig{Limit: 3},
+	})
+
+	// Concurrent requests share one counter
+	statuses := fireConcurrently(handler, 5)
+	if statuses[200] != 3 || statuses[429] != 2 {
+		t.Errorf("Expected 3 requests served and 2 over quota, got %v", statuses)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/work", nil))
+	if w.Code != 429 {
+		t.Errorf("Expected 429 once the quota is used up, got %d", w.Code)
+	}
+	expected := `{"error":"quota exceeded","limit":3}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+	// A quota without a window never resets, so there is no Retry-After
+	if got := w.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Expected no Retry-After, got %q", got)
+	}
+}
+
+func TestHandler_QuotaCustomResponse(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:     "/api/work",
+		Method:   "GET",
+		Response: `{"done": true}`,
+		Quota: &models.QuotaConfig{
+			Limit:    1,
+			Status:   402,
+			Response: `{"error": "upgrade your plan", "path": "{{path}}"}`,
+			WindowMS: 60000,
+		},
+	})
+
+	for i, expected := range []int{200, 402} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/api/work", nil))
+		if w.Code != expected {
+			t.Fatalf("Request %d: expected %d, got %d", i, expected, w.Code)
+		}
+		if expected == 402 {
+			if w.Body.String() != `{"error": "upgrade your plan", "path": "/api/work"}` {
+				t.Errorf("Expected the configured quota response, got %s", w.Body.String())
+			}
+			if got := w.Header().Get("Retry-After"); got != "60" {
+				t.Errorf("Expected Retry-After 60 for the window reset, got %q", got)
+			}
+		}
+	}
+}
+
+func TestQuotaCounter_Window(t *testing.T) {
+	quota := newQuotaCounter(&models.QuotaConfig{Limit: 2, WindowMS: 1000})
+	start := time.Now()
+
+	tests := []struct {
+		offset  time.Duration
+		allowed bool
+		reset   time.Duration
+	}{
+		{0, true, time.Second},
+		{100 * time.Millisecond, true, 900 * time.Millisecond},
+		{200 * time.Millisecond, false, 800 * time.Millisecond},
+		{time.Second, true, time.Second}, // a new window
+		{1500 * time.Millisecond, true, 500 * time.Millisecond},
+		{1600 * time.Millisecond, false, 400 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		allowed, reset := quota.take(start.Add(tt.offset))
+		if allowed != tt.allowed || reset != tt.reset {
+			t.Errorf("At %v: expected allowed=%v reset=%v, got allowed=%v reset=%v", tt.offset, tt.allowed, tt.reset, allowed, reset)
+		}
+	}
+}
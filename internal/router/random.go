@@ -0,0 +1,50 @@
+package router
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// randomSource supplies the random numbers behind weighted responses,
+// sampled latency and injected faults. A seeded source repeats the same
+// sequence on every run; a nil source draws from the global generator. It is
+// safe for concurrent use.
+type randomSource struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newRandomSource creates a source repeating the sequence for seed
+func newRandomSource(seed int64) *randomSource {
+	return &randomSource{rng: rand.New(rand.NewPCG(uint64(seed), uint64(seed)))}
+}
+
+// Float64 returns a number in [0.0, 1.0)
+func (s *randomSource) Float64() float64 {
+	if s == nil {
+		return rand.Float64()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.Float64()
+}
+
+// IntN returns a number in [0, n)
+func (s *randomSource) IntN(n int) int {
+	if s == nil {
+		return rand.IntN(n)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.IntN(n)
+}
+
+// NormFloat64 returns a standard normally distributed number
+func (s *randomSource) NormFloat64() float64 {
+	if s == nil {
+		return rand.NormFloat64()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.NormFloat64()
+}
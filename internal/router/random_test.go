@@ -0,0 +1,74 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// weightedOutcomes serves a weighted endpoint n times through a router seeded
+// with seed and returns the bodies in order
+func weightedOutcomes(t *testing.T, seed *int64, n int) []string {
+	t.Helper()
+
+	router := New()
+	router.SetRandomSeed(seed)
+	if err := router.RegisterEndpoint(models.EndpointConfig{
+		Path:   "/api/flaky",
+		Method: "GET",
+		Responses: []models.ResponseVariant{
+			{Weight: 5, Response: `{"result": "ok"}`},
+			{Weight: 3, Status: 503, Response: `{"result": "degraded"}`},
+			{Weight: 2, Status: 500, Response: `{"result": "error"}`},
+		},
+	}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	outcomes := make([]string, n)
+	for i := range outcomes {
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/flaky", nil))
+		outcomes[i] = w.Body.String()
+	}
+	return outcomes
+}
+
+func TestRouter_SetRandomSeed(t *testing.T) {
+	seed, other := int64(42), int64(7)
+	first := weightedOutcomes(t, &seed, 50)
+	second := weightedOutcomes(t, &seed, 50)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same outcomes for the same seed, request %d got %s and %s", i, first[i], second[i])
+		}
+	}
+
+	different := weightedOutcomes(t, &other, 50)
+	same := true
+	for i := range first {
+		same = same && first[i] == different[i]
+	}
+	if same {
+		t.Error("Expected a different seed to give different outcomes")
+	}
+}
+
+func TestRandomSource_Seeded(t *testing.T) {
+	a, b := newRandomSource(1), newRandomSource(1)
+	for i := 0; i < 10; i++ {
+		if x, y := a.NormFloat64(), b.NormFloat64(); x != y {
+			t.Fatalf("Expected identical latency samples, got %v and %v", x, y)
+		}
+		if x, y := a.Float64(), b.Float64(); x != y {
+			t.Fatalf("Expected identical fault draws, got %v and %v", x, y)
+		}
+	}
+
+	// The unseeded source draws from the global generator
+	var unseeded *randomSource
+	if n := unseeded.IntN(3); n < 0 || n >= 3 {
+		t.Errorf("Expected a number in [0, 3), got %d", n)
+	}
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// missingHeader returns the first required header the request lacks or
+// carries with the wrong value, reporting whether the header was present
+func missingHeader(r *http.Request, required []models.RequiredHeader) (name string, present bool) {
+	for _, header := range required {
+		value := r.Header.Get(header.Name)
+		if value == "" {
+			return header.Name, false
+		}
+		if header.Value != "" && value != header.Value {
+			return header.Name, true
+		}
+	}
+	return "", false
+}
+
+// rejectMissingHeader answers a request that lacks a required header with the
+// endpoint's configured status and body, or the error envelope. The expected
+// value is never echoed.
+func rejectMissingHeader(w http.ResponseWriter, r *http.Request, endpoint models.EndpointConfig, name string, present bool) {
+	status := endpoint.GetRequiredHeadersStatus()
+	log.Printf("[%d] %s %s rejected: required header %s missing or invalid", status, r.Method, r.URL.Path, name)
+
+	if endpoint.RequiredHeadersResponse != "" {
+		response := endpoint.RequiredHeadersResponse
+		if endpoint.IsTemplated() {
+			response = processResponse(response, r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(response)); err != nil {
+			log.Printf("Failed to write %d response: %v", status, err)
+		}
+		return
+	}
+
+	message, detail := "missing required header", fmt.Sprintf("header %s is required", name)
+	if present {
+		message, detail = "invalid header value", fmt.Sprintf("header %s has an unexpected value", name)
+	}
+	response := fmt.Sprintf(`{"error":%q,"header":%q}`, message, name)
+	writeError(w, r, status, message, detail, response)
+}
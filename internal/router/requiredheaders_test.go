@@ -0,0 +1,134 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestHandler_RequiredHeaders(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:     "/api/orders",
+		Method:   "GET",
+		Response: `{"orders": []}`,
+		RequiredHeaders: []models.RequiredHeader{
+			{Name: "X-Api-Key", Value: "secret"},
+			{Name: "X-Tenant"},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		status   int
+		expected string
+	}{
+		{"present", map[string]string{"X-Api-Key": "secret", "X-Tenant": "acme"}, 200, `{"orders": []}`},
+		{"absent", map[string]string{"X-Tenant": "acme"}, 400, `{"error":"missing required header","header":"X-Api-Key"}`},
+		{"value mismatch", map[string]string{"X-Api-Key": "guess", "X-Tenant": "acme"}, 400, `{"error":"invalid header value","header":"X-Api-Key"}`},
+		{"any value accepted", map[string]string{"X-Api-Key": "secret"}, 400, `{"error":"missing required header","header":"X-Tenant"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/orders", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != tt.status || w.Body.String() != tt.expected {
+				t.Errorf("Expected %d %s, got %d %s", tt.status, tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RequiredHeadersCustomResponse(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:                    "/api/orders",
+		Method:                  "GET",
+		Delay:                   5000,
+		Response:                `{"orders": []}`,
+		RequiredHeaders:         []models.RequiredHeader{{Name: "Authorization", Value: "Bearer test-token"}},
+		RequiredHeadersStatus:   401,
+		RequiredHeadersResponse: `{"message": "unauthorized", "path": "{{path}}"}`,
+	})
+
+	// Rejected before the delay
+	req := httptest.NewRequest("GET", "/api/orders", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	expected := `{"message": "unauthorized", "path": "/api/orders"}`
+	if w.Code != 401 || w.Body.String() != expected {
+		t.Errorf("Expected 401 %s, got %d %s", expected, w.Code, w.Body.String())
+	}
+}
+
+func TestRouterHandler_RequiredHeadersErrorEnvelope(t *testing.T) {
+	router := New()
+	router.SetErrorEnvelope(problemEnvelope)
+	if err := router.RegisterEndpoint(models.EndpointConfig{
+		Path:                  "/api/orders",
+		Method:                "GET",
+		RequiredHeaders:       []models.RequiredHeader{{Name: "X-Api-Key"}},
+		RequiredHeadersStatus: 401,
+	}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/orders", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	var problem map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to parse %s: %v", w.Body.String(), err)
+	}
+	if w.Code != 401 || problem["status"] != float64(401) || problem["detail"] != "header X-Api-Key is required" {
+		t.Errorf("Expected a 401 problem for the missing header, got %d %v", w.Code, problem)
+	}
+}
+
+func TestHandler_AnonymousResponse(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:              "/api/profile",
+		Method:            "GET",
+		Response:          `{"name": "Ada", "email": "ada@example.com", "plan": "pro"}`,
+		AnonymousResponse: `{"name": "Ada"}`,
+		RequiredHeaders:   []models.RequiredHeader{{Name: "Authorization", Value: "Bearer test-token"}},
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      string
+	}{
+		{"valid credentials", "Bearer test-token", `{"name": "Ada", "email": "ada@example.com", "plan": "pro"}`},
+		{"wrong credentials", "Bearer guess", `{"name": "Ada"}`},
+		{"anonymous", "", `{"name": "Ada"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/profile", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != 200 || w.Body.String() != tt.expected {
+				t.Errorf("Expected 200 %s, got %d %s", tt.expected, w.Code, w.Body.String())
+			}
+			if vary := w.Header().Get("Vary"); vary != "Authorization" {
+				t.Errorf("Expected Vary: Authorization, got %q", vary)
+			}
+		})
+	}
+}
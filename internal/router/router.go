@@ -1,9 +1,16 @@
 package router
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/jimbo/blandmockapi/internal/models"
@@ -14,9 +21,51 @@ type Router struct {
 	mux       *http.ServeMux
 	endpoints []models.EndpointConfig
 	// Map of path -> method -> endpoint for multi-method support
-	pathMethods  map[string]map[string]models.EndpointConfig
-	graphqlPath  string
-	hasGraphQL   bool
+	pathMethods map[string]map[string]models.EndpointConfig
+	// Map of path -> method -> candidate routes, built once at registration
+	pathRoutes  map[string]map[string][]route
+	graphqlPath string
+	hasGraphQL  bool
+	// GraphQL handler, dispatched directly so path variants reach it
+	graphqlHandler         http.HandlerFunc
+	graphqlCaseInsensitive bool
+	hasOpenAPI             bool
+	hasVersion             bool
+	benchMode              bool
+	maxBodyBytes           int64
+	// Headers added to every response unless a handler sets its own value
+	defaultHeaders map[string]string
+	requestID      bool
+	// Access logger for endpoint requests; nil disables access logging
+	accessLog *accessLogger
+	// Global CORS policy; nil disables CORS handling
+	cors *corsPolicy
+	// Captured endpoint requests; nil disables capture
+	capture *requestCapture
+	// Custom error body templates; empty uses the built-in bodies
+	notFoundResponse         string
+	methodNotAllowedResponse string
+	// Handler for requests no endpoint matches; nil uses notFoundResponse
+	defaultHandler http.HandlerFunc
+	// Uppercase request method -> uppercase method it is served as
+	methodAliases map[string]string
+	// Template for the router's own error responses; nil uses the built-in bodies
+	errorEnvelope *errorEnvelope
+	// Random numbers for endpoints registered afterwards; nil is unseeded
+	random *randomSource
+	// Load-dependent latency applied to every request; nil disables it
+	throttle *throttle
+	// Probe paths; empty when the probe is not registered
+	livenessPath  string
+	readinessPath string
+	// Static file directories, longest prefix first
+	statics []staticRoute
+	// Methods X-HTTP-Method-Override may turn a POST into; empty ignores the header
+	methodOverrides map[string]bool
+	// Tracker for endpoint callbacks; nil tracks them package-wide
+	callbacks *Callbacks
+	// Environment variables templates may read; empty exposes none
+	templateEnv []string
 }
 
 // New creates a new router
@@ -25,9 +74,144 @@ func New() *Router {
 		mux:         http.NewServeMux(),
 		endpoints:   []models.EndpointConfig{},
 		pathMethods: make(map[string]map[string]models.EndpointConfig),
+		pathRoutes:  make(map[string]map[string][]route),
+		accessLog:   newAccessLogger(nil),
 	}
 }
 
+// route pairs an endpoint with its prebuilt handler
+type route struct {
+	endpoint models.EndpointConfig
+	handler  http.HandlerFunc
+}
+
+// SetBenchMode toggles the benchmark fast path for endpoints registered afterwards.
+// In bench mode requests are not logged and static responses are precomputed.
+func (rt *Router) SetBenchMode(enabled bool) {
+	rt.benchMode = enabled
+}
+
+// SetRandomSeed makes weighted responses, sampled latency and injected faults
+// of endpoints registered afterwards draw from one generator seeded with
+// seed, so a run can be reproduced. A nil seed uses unseeded randomness.
+func (rt *Router) SetRandomSeed(seed *int64) {
+	rt.random = nil
+	if seed != nil {
+		rt.random = newRandomSource(*seed)
+	}
+}
+
+// SetMaxBodyBytes limits the size of request bodies; zero disables the limit
+func (rt *Router) SetMaxBodyBytes(limit int64) {
+	rt.maxBodyBytes = limit
+}
+
+// SetThrottle delays every request, health checks and errors included, by a
+// latency that grows with the number of requests in flight. nil disables it.
+func (rt *Router) SetThrottle(cfg *models.ThrottleConfig) {
+	rt.throttle = newThrottle(cfg)
+}
+
+// SetDefaultHeaders sets headers applied to every response, including health
+// checks and errors. Endpoint headers take precedence on conflicts.
+func (rt *Router) SetDefaultHeaders(headers map[string]string) {
+	rt.defaultHeaders = headers
+}
+
+// SetMethodAliases serves requests using an alias method, such as LIST, with
+// the endpoint configured for the method it maps to, such as GET, when the
+// path has no endpoint for the alias itself. Methods match case-insensitively.
+func (rt *Router) SetMethodAliases(aliases map[string]string) {
+	rt.methodAliases = make(map[string]string, len(aliases))
+	for alias, method := range aliases {
+		rt.methodAliases[strings.ToUpper(strings.TrimSpace(alias))] = strings.ToUpper(strings.TrimSpace(method))
+	}
+}
+
+// SetMethodOverride serves POST requests carrying an X-HTTP-Method-Override
+// header as the method it names, for clients that can only send GET and POST.
+// Only the listed methods can be selected; other values are ignored and the
+// request is served as a POST. No methods disables the override.
+func (rt *Router) SetMethodOverride(methods []string) {
+	rt.methodOverrides = make(map[string]bool, len(methods))
+	for _, method := range methods {
+		rt.methodOverrides[strings.ToUpper(strings.TrimSpace(method))] = true
+	}
+}
+
+// SetCallbacks tracks the callbacks of every endpoint in callbacks, so the
+// caller can wait for them on shutdown
+func (rt *Router) SetCallbacks(callbacks *Callbacks) {
+	rt.callbacks = callbacks
+}
+
+// SetCORS applies a CORS policy to every route, answering preflight requests
+// unless an endpoint is registered for OPTIONS on the path. Endpoint headers
+// override the policy's headers. A nil cfg disables CORS handling.
+func (rt *Router) SetCORS(cfg *models.CORSConfig) {
+	rt.cors = newCORSPolicy(cfg)
+}
+
+// SetRequestID enables echoing X-Request-Id on every response, generating an
+// ID when the request does not carry one
+func (rt *Router) SetRequestID(enabled bool) {
+	rt.requestID = enabled
+}
+
+// SetAccessLog enables or disables per-request logging for endpoints
+// registered afterwards. Values of the named query parameters are logged
+// as "***".
+func (rt *Router) SetAccessLog(enabled bool, redactQueryParams []string) {
+	rt.accessLog = nil
+	if enabled {
+		rt.accessLog = newAccessLogger(redactQueryParams)
+	}
+}
+
+// SetErrorResponses sets custom body templates for 404 and 405 responses.
+// Empty templates keep the built-in JSON bodies.
+func (rt *Router) SetErrorResponses(notFound, methodNotAllowed string) {
+	rt.notFoundResponse = notFound
+	rt.methodNotAllowedResponse = methodNotAllowed
+}
+
+// SetErrorEnvelope renders the router's own error responses, such as 404,
+// 405, 413, 503 and 504, from cfg. Bodies set with SetErrorResponses and the
+// default endpoint take precedence. A nil cfg restores the built-in bodies.
+func (rt *Router) SetErrorEnvelope(cfg *models.ErrorsConfig) {
+	rt.errorEnvelope = newErrorEnvelope(cfg)
+}
+
+// SetDefaultEndpoint answers requests that match no endpoint with cfg
+// instead of a 404, taking precedence over SetErrorResponses. Call it after
+// SetAccessLog so unmatched requests are logged like endpoint requests. A nil
+// cfg restores the 404.
+func (rt *Router) SetDefaultEndpoint(cfg *models.DefaultEndpointConfig) {
+	rt.defaultHandler = nil
+	if cfg != nil {
+		rt.defaultHandler = newHandler(models.EndpointConfig{
+			Path:     "/",
+			Status:   cfg.GetStatus(),
+			Response: cfg.Response,
+			Headers:  cfg.Headers,
+		}, rt.accessLog, rt.random)
+	}
+}
+
+// notFound answers a request no endpoint serves with the default endpoint,
+// or a 404 using the configured body template
+func (rt *Router) notFound(w http.ResponseWriter, r *http.Request) {
+	if rt.defaultHandler != nil {
+		rt.defaultHandler(w, r)
+		return
+	}
+	if rt.notFoundResponse != "" {
+		CustomNotFoundHandler(rt.notFoundResponse)(w, r)
+		return
+	}
+	NotFoundHandler()(w, r)
+}
+
 // RegisterEndpoints registers all configured endpoints
 func (rt *Router) RegisterEndpoints(endpoints []models.EndpointConfig) error {
 	for _, endpoint := range endpoints {
@@ -40,68 +224,255 @@ func (rt *Router) RegisterEndpoints(endpoints []models.EndpointConfig) error {
 
 // RegisterEndpoint registers a single endpoint
 func (rt *Router) RegisterEndpoint(endpoint models.EndpointConfig) error {
-	// Validate endpoint
+	// Disabled endpoints are skipped, so requests to them 404 as if unconfigured
+	if !endpoint.IsEnabled() {
+		log.Printf("Skipped disabled endpoint: %s", endpoint.RouteKey())
+		return nil
+	}
+
+	// Validate endpoint. Stray whitespace around the path would stop it
+	// matching any request, so it is dropped.
+	endpoint.Path = strings.TrimSpace(endpoint.Path)
 	if endpoint.Path == "" {
 		return fmt.Errorf("endpoint path cannot be empty")
 	}
+	if strings.Contains(endpoint.Path, "?") {
+		return fmt.Errorf("endpoint path %q contains a query string; paths match the request path only, use {{query.NAME}} to read query parameters", endpoint.Path)
+	}
+	if endpoint.ResponseBase64 != "" {
+		if _, err := base64.StdEncoding.DecodeString(endpoint.ResponseBase64); err != nil {
+			return fmt.Errorf("invalid response_base64 for %s: %w", endpoint.Path, err)
+		}
+	}
+
+	// Response files the config loader has not read, as in a Config built
+	// in Go, are read once here
+	if err := endpoint.LoadResponseFiles(); err != nil {
+		return fmt.Errorf("endpoint %s: %w", endpoint.RouteKey(), err)
+	}
+
+	// Endpoints with per-method responses register once per method
+	if len(endpoint.MethodResponses) > 0 {
+		for _, expanded := range endpoint.ExpandMethods() {
+			if err := rt.RegisterEndpoint(expanded); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	if endpoint.Method == "" {
 		endpoint.Method = "GET"
 	}
 
-	// Normalize method to uppercase
+	// Normalize method to uppercase, and wildcard aliases to "*"
 	endpoint.Method = strings.ToUpper(endpoint.Method)
+	if endpoint.IsAnyMethod() {
+		endpoint.Method = models.AnyMethod
+	}
 
 	// Check if this path is already registered
 	if _, exists := rt.pathMethods[endpoint.Path]; !exists {
 		// First time seeing this path - register it with the mux
 		rt.pathMethods[endpoint.Path] = make(map[string]models.EndpointConfig)
+		rt.pathRoutes[endpoint.Path] = make(map[string][]route)
 		rt.mux.HandleFunc(endpoint.Path, rt.multiMethodHandler(endpoint.Path))
 	}
 
+	// Reject a second registration of the same route rather than silently
+	// shadowing the first
+	for _, existing := range rt.pathRoutes[endpoint.Path][endpoint.Method] {
+		if existing.endpoint.RouteKey() == endpoint.RouteKey() {
+			return fmt.Errorf("duplicate endpoint registration: %s", endpoint.RouteKey())
+		}
+	}
+
 	// Store the endpoint config for this method
 	rt.pathMethods[endpoint.Path][endpoint.Method] = endpoint
+	handler := newHandler(endpoint, rt.accessLog, rt.random)
+	if rt.benchMode {
+		handler = benchHandler(endpoint, rt.random)
+	}
+	rt.pathRoutes[endpoint.Path][endpoint.Method] = append(rt.pathRoutes[endpoint.Path][endpoint.Method], route{
+		endpoint: endpoint,
+		handler:  handler,
+	})
 	rt.endpoints = append(rt.endpoints, endpoint)
 
-	log.Printf("Registered endpoint: %s %s -> %d", endpoint.Method, endpoint.Path, endpoint.Status)
+	log.Printf("Registered endpoint: %s -> %d", endpoint.RouteKey(), endpoint.Status)
+	for _, err := range CheckTemplates(endpoint) {
+		log.Printf("Warning: %v", err)
+	}
 	return nil
 }
 
+// selectRoute picks the route for a request. Routes bound to the Host header,
+// the TLS SNI server name or query parameters take priority over routes
+// without them, and a route matching more conditions wins; among equally
+// specific routes the first registered wins.
+func selectRoute(routes []route, r *http.Request) (route, bool) {
+	serverName := ""
+	if r.TLS != nil {
+		serverName = r.TLS.ServerName
+	}
+	host := requestHost(r)
+	var query url.Values
+
+	best, bestScore := -1, -1
+	for i := range routes {
+		endpoint := routes[i].endpoint
+		score := 0
+		if endpoint.Host != "" {
+			if !strings.EqualFold(endpoint.Host, host) {
+				continue
+			}
+			score++
+		}
+		if endpoint.ServerName != "" {
+			if !strings.EqualFold(endpoint.ServerName, serverName) {
+				continue
+			}
+			score++
+		}
+		if len(endpoint.MatchQuery) > 0 {
+			if query == nil {
+				query = r.URL.Query()
+			}
+			if !matchesQuery(endpoint.MatchQuery, query) {
+				continue
+			}
+			score += len(endpoint.MatchQuery)
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return route{}, false
+	}
+	return routes[best], true
+}
+
+// matchesQuery reports whether query carries every parameter in match, with
+// the given value unless that value is empty
+func matchesQuery(match map[string]string, query url.Values) bool {
+	for name, want := range match {
+		values, present := query[name]
+		if !present {
+			return false
+		}
+		if want != "" && !slices.Contains(values, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHost returns the request's Host header without the port or a
+// trailing dot
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// matchesHost reports whether an endpoint bound to host, or to no host,
+// serves the request
+func matchesHost(host string, r *http.Request) bool {
+	return host == "" || strings.EqualFold(host, requestHost(r))
+}
+
+// MethodOverrideHeader names the method a tunnelled POST request is served as
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
 // multiMethodHandler creates a handler that routes based on HTTP method
 func (rt *Router) multiMethodHandler(path string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		methodMap, exists := rt.pathMethods[path]
-		if !exists {
-			NotFoundHandler()(w, r)
+		if _, exists := rt.pathMethods[path]; !exists {
+			rt.notFound(w, r)
 			return
 		}
 
-		endpoint, methodExists := methodMap[r.Method]
-		if !methodExists {
-			// Method not allowed - list allowed methods
-			allowed := make([]string, 0, len(methodMap))
-			for method := range methodMap {
-				allowed = append(allowed, method)
+		// Methods are matched case-insensitively, so "get" reaches GET
+		if method := strings.ToUpper(r.Method); method != r.Method {
+			r = withMethod(r, method)
+		}
+
+		// Tunnelled methods are served as the method they name
+		if r.Method == http.MethodPost && len(rt.methodOverrides) > 0 {
+			if override := strings.ToUpper(strings.TrimSpace(r.Header.Get(MethodOverrideHeader))); override != "" {
+				if rt.methodOverrides[override] {
+					r = withMethod(r, override)
+				} else {
+					log.Printf("Ignored %s: %s for POST %s: method not allowed", MethodOverrideHeader, override, r.URL.Path)
+				}
 			}
-			w.Header().Set("Content-Type", "application/json")
-			w.Header().Set("Allow", strings.Join(allowed, ", "))
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			if _, err := fmt.Fprintf(w, `{"error":"method not allowed","allowed":%q,"received":"%s"}`, allowed, r.Method); err != nil {
-				log.Printf("Failed to write method not allowed response: %v", err)
+		}
+
+		// Call the handler for this specific endpoint, then for the method an
+		// alias maps to, falling back to a wildcard endpoint for methods not
+		// configured explicitly
+		selected, ok := selectRoute(rt.pathRoutes[path][r.Method], r)
+		if target, aliased := rt.methodAliases[r.Method]; !ok && aliased {
+			if selected, ok = selectRoute(rt.pathRoutes[path][target], r); ok {
+				r = withMethod(r, target)
+			}
+		}
+		if !ok {
+			selected, ok = selectRoute(rt.pathRoutes[path][models.AnyMethod], r)
+		}
+		if ok {
+			if rt.captureRequest(w, r, path) {
+				selected.handler(w, r)
 			}
 			return
 		}
 
-		// Call the handler for this specific endpoint
-		Handler(endpoint)(w, r)
+		// Method not allowed - list the methods served for this host
+		var allowed []string
+		for method, routes := range rt.pathRoutes[path] {
+			if _, ok := selectRoute(routes, r); ok {
+				allowed = append(allowed, method)
+			}
+		}
+		if len(allowed) == 0 {
+			rt.notFound(w, r)
+			return
+		}
+		sort.Strings(allowed)
+		MethodNotAllowedHandler(allowed, rt.methodNotAllowedResponse)(w, r)
 	}
 }
 
+// withMethod returns a shallow copy of r using method, so endpoint handlers
+// see the method they are configured for
+func withMethod(r *http.Request, method string) *http.Request {
+	r = r.WithContext(r.Context())
+	r.Method = method
+	return r
+}
+
 // RegisterHealthCheck registers a health check endpoint
 func (rt *Router) RegisterHealthCheck() {
 	rt.mux.HandleFunc("/health", HealthHandler())
 	log.Printf("Registered health check endpoint: GET /health")
 }
 
+// RegisterVersion registers an endpoint reporting the running build and the
+// config files it loaded
+func (rt *Router) RegisterVersion(info VersionInfo) {
+	rt.hasVersion = true
+	rt.mux.HandleFunc(VersionPath, VersionHandler(info))
+	log.Printf("Registered version endpoint: GET %s", VersionPath)
+}
+
+// SetGraphQLCaseInsensitive makes the GraphQL path match regardless of case,
+// so /GraphQL reaches a handler registered at /graphql
+func (rt *Router) SetGraphQLCaseInsensitive(enabled bool) {
+	rt.graphqlCaseInsensitive = enabled
+}
+
 // RegisterGraphQL registers a GraphQL endpoint handler
 func (rt *Router) RegisterGraphQL(path string, handler http.HandlerFunc) {
 	if path == "" {
@@ -109,24 +480,124 @@ func (rt *Router) RegisterGraphQL(path string, handler http.HandlerFunc) {
 	}
 	rt.graphqlPath = path
 	rt.hasGraphQL = true
-	rt.mux.HandleFunc(path, handler)
-	log.Printf("Registered GraphQL endpoint: POST %s", path)
+	rt.graphqlHandler = handler
+	log.Printf("Registered GraphQL endpoint: GET, POST %s", path)
 }
 
 // Handler returns the underlying HTTP handler
 func (rt *Router) Handler() http.Handler {
 	// Wrap the mux with a custom handler that provides 404 responses
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Apply default headers first so any handler can override them
+		for key, value := range rt.defaultHeaders {
+			w.Header().Set(key, value)
+		}
+
+		// Slow every request down as the number in flight grows
+		if rt.throttle != nil {
+			defer rt.throttle.done()
+			if !rt.throttle.wait(r) {
+				return
+			}
+		}
+
+		// Echo or generate the request ID before dispatching so every
+		// response, including errors, carries it
+		if rt.requestID {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+		}
+
+		// Make the error envelope available to every error response
+		if rt.errorEnvelope != nil {
+			r = r.WithContext(context.WithValue(r.Context(), errorEnvelopeKey{}, rt.errorEnvelope))
+		}
+
+		// Track callbacks sent by the endpoint handlers
+		if rt.callbacks != nil {
+			r = r.WithContext(context.WithValue(r.Context(), callbacksKey{}, rt.callbacks))
+		}
+
+		// Let templates read the allowed environment variables
+		if len(rt.templateEnv) > 0 {
+			r = withTemplateEnv(r, rt.templateEnv)
+		}
+
 		// Check if any pattern matches
 		pattern := rt.findMatchingPattern(r)
+
+		// Apply the CORS policy, answering preflights that no endpoint
+		// handles itself
+		if rt.cors != nil {
+			rt.cors.setHeaders(w, r)
+			if isPreflight(r) {
+				if _, handled := rt.pathMethods[pattern][http.MethodOptions]; !handled {
+					rt.cors.preflight(w, r)
+					return
+				}
+			}
+		}
+
+		// Limit bodies of requests that will be served, including those the
+		// default endpoint answers
+		if rt.maxBodyBytes > 0 && (pattern != "" || rt.defaultHandler != nil) {
+			// Reject declared oversized bodies without reading them; chunked
+			// bodies are capped and rejected by the handler on read
+			if r.ContentLength > rt.maxBodyBytes {
+				PayloadTooLargeHandler(rt.maxBodyBytes)(w, r)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, rt.maxBodyBytes)
+		}
+
 		if pattern != "" {
+			if rt.hasGraphQL && pattern == rt.graphqlPath {
+				rt.graphqlHandler(w, r)
+				return
+			}
+			if _, isEndpoint := rt.pathMethods[pattern]; !isEndpoint {
+				if static := rt.matchStatic(r.URL.Path); static != nil && static.prefix == pattern {
+					static.handler.ServeHTTP(w, r)
+					return
+				}
+			}
 			rt.mux.ServeHTTP(w, r)
 		} else {
-			NotFoundHandler()(w, r)
+			rt.notFound(w, r)
 		}
 	})
 }
 
+// RequestIDHeader is the header used to carry request IDs
+const RequestIDHeader = "X-Request-Id"
+
+// requestIDKey is the context key for the request ID
+type requestIDKey struct{}
+
+// requestID returns the ID assigned by the router, falling back to the
+// incoming header when request IDs are not enabled
+func requestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return r.Header.Get(RequestIDHeader)
+}
+
+// newRequestID generates a random RFC 4122 version 4 UUID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		log.Printf("Failed to generate request ID: %v", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
 // findMatchingPattern checks if a request matches any registered pattern
 func (rt *Router) findMatchingPattern(r *http.Request) string {
 	// Check health endpoint
@@ -134,21 +605,60 @@ func (rt *Router) findMatchingPattern(r *http.Request) string {
 		return "/health"
 	}
 
+	// Check liveness and readiness probes
+	if rt.livenessPath != "" && r.URL.Path == rt.livenessPath {
+		return rt.livenessPath
+	}
+	if rt.readinessPath != "" && r.URL.Path == rt.readinessPath {
+		return rt.readinessPath
+	}
+
 	// Check GraphQL endpoint
-	if rt.hasGraphQL && r.URL.Path == rt.graphqlPath {
+	if rt.hasGraphQL && rt.matchesGraphQLPath(r.URL.Path) {
 		return rt.graphqlPath
 	}
 
+	// Check the generated OpenAPI document
+	if rt.hasOpenAPI && r.URL.Path == OpenAPIPath {
+		return OpenAPIPath
+	}
+
+	// Check the version endpoint
+	if rt.hasVersion && r.URL.Path == VersionPath {
+		return VersionPath
+	}
+
+	// Check the captured requests
+	if rt.capture != nil && r.URL.Path == CapturePath {
+		return CapturePath
+	}
+
 	// Check registered endpoints
 	for _, ep := range rt.endpoints {
-		if matchesPattern(ep.Path, r.URL.Path) {
+		if matchesPattern(ep.Path, r.URL.Path) && matchesHost(ep.Host, r) {
 			return ep.Path
 		}
 	}
 
+	// Check static file directories, which endpoints override
+	if static := rt.matchStatic(r.URL.Path); static != nil {
+		return static.prefix
+	}
+
 	return ""
 }
 
+// matchesGraphQLPath reports whether a request path addresses the GraphQL
+// endpoint, tolerating a trailing slash and, when enabled, any letter case
+func (rt *Router) matchesGraphQLPath(path string) bool {
+	want := strings.TrimSuffix(rt.graphqlPath, "/")
+	got := strings.TrimSuffix(path, "/")
+	if rt.graphqlCaseInsensitive {
+		return strings.EqualFold(want, got)
+	}
+	return want == got
+}
+
 // matchesPattern checks if a URL path matches a pattern
 // Simple implementation - could be enhanced with path parameters
 func matchesPattern(pattern, path string) bool {
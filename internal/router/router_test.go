@@ -1,7 +1,15 @@
 package router
 
 import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/jimbo/blandmockapi/internal/models"
@@ -62,6 +70,39 @@ func TestRegisterEndpoint_EmptyPath(t *testing.T) {
 	}
 }
 
+func TestRegisterEndpoint_QueryStringPath(t *testing.T) {
+	router := New()
+
+	err := router.RegisterEndpoint(models.EndpointConfig{Path: "/api/users?active=true", Response: `{}`})
+	if err == nil {
+		t.Fatal("Expected error for a path with a query string, got nil")
+	}
+	if !strings.Contains(err.Error(), `"/api/users?active=true" contains a query string`) {
+		t.Errorf("Expected error to name the path, got %v", err)
+	}
+	if len(router.GetEndpoints()) != 0 {
+		t.Errorf("Expected no endpoints registered, got %d", len(router.GetEndpoints()))
+	}
+}
+
+func TestRegisterEndpoint_TrimsPathWhitespace(t *testing.T) {
+	router := New()
+
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: " /api/users \t", Response: `{"users": []}`}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+	if path := router.GetEndpoints()[0].Path; path != "/api/users" {
+		t.Errorf("Expected normalized path /api/users, got %q", path)
+	}
+
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+	if w.Code != 200 || w.Body.String() != `{"users": []}` {
+		t.Errorf("Expected the endpoint to match, got %d %s", w.Code, w.Body.String())
+	}
+}
+
 func TestRegisterEndpoint_DefaultMethod(t *testing.T) {
 	router := New()
 
@@ -117,6 +158,35 @@ func TestRegisterMultipleMethodsSamePath(t *testing.T) {
 	}
 }
 
+func TestRegisterEndpoint_Duplicate(t *testing.T) {
+	router := New()
+
+	first := models.EndpointConfig{Path: "/api/users", Method: "GET", Status: 200, Response: `{"first": true}`}
+	second := models.EndpointConfig{Path: "/api/users", Method: "get", Status: 200, Response: `{"second": true}`}
+
+	if err := router.RegisterEndpoint(first); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	err := router.RegisterEndpoint(second)
+	if err == nil {
+		t.Fatal("Expected error for duplicate registration, got nil")
+	}
+
+	if len(router.GetEndpoints()) != 1 {
+		t.Errorf("Expected duplicate not to be appended, got %d endpoints", len(router.GetEndpoints()))
+	}
+
+	// The original endpoint keeps serving
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Body.String() != `{"first": true}` {
+		t.Errorf("Expected original response, got %s", w.Body.String())
+	}
+}
+
 func TestRouterHandler_Success(t *testing.T) {
 	router := New()
 
@@ -198,6 +268,39 @@ func TestRouterHandler_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestRegisterVersion(t *testing.T) {
+	router := New()
+	router.RegisterVersion(VersionInfo{
+		Version:     "1.2.3",
+		Commit:      "abc123",
+		BuildDate:   "2026-01-02",
+		ConfigFiles: []string{"config/base.toml", "config/users.toml"},
+	})
+
+	req := httptest.NewRequest("GET", VersionPath, nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Error("Expected Content-Type to be application/json")
+	}
+
+	expectedBody := `{"version":"1.2.3","commit":"abc123","build_date":"2026-01-02","config_files":["config/base.toml","config/users.toml"]}` + "\n"
+	if w.Body.String() != expectedBody {
+		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
+	}
+
+	// Without the endpoint registered the path is not found
+	w = httptest.NewRecorder()
+	New().Handler().ServeHTTP(w, req)
+	if w.Code != 404 {
+		t.Errorf("Expected status 404 without RegisterVersion, got %d", w.Code)
+	}
+}
+
 func TestRegisterHealthCheck(t *testing.T) {
 	router := New()
 	router.RegisterHealthCheck()
@@ -216,6 +319,43 @@ func TestRegisterHealthCheck(t *testing.T) {
 	}
 }
 
+func TestRouterHandler_GraphQLPathVariants(t *testing.T) {
+	graphqlHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}
+
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		path            string
+		expected        int
+	}{
+		{"exact", false, "/graphql", 200},
+		{"trailing slash", false, "/graphql/", 200},
+		{"different case without flag", false, "/GraphQL", 404},
+		{"different case with flag", true, "/GraphQL", 200},
+		{"different case and trailing slash with flag", true, "/GRAPHQL/", 200},
+		{"other path with flag", true, "/graphqlx", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New()
+			router.SetGraphQLCaseInsensitive(tt.caseInsensitive)
+			router.RegisterGraphQL("/graphql", graphqlHandler)
+
+			req := httptest.NewRequest("POST", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.Handler().ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d for %s, got %d", tt.expected, tt.path, w.Code)
+			}
+		})
+	}
+}
+
 func TestMatchesPattern(t *testing.T) {
 	tests := []struct {
 		pattern string
@@ -259,3 +399,769 @@ func TestGetEndpoints(t *testing.T) {
 		t.Errorf("Expected 2 endpoints, got %d", len(retrieved))
 	}
 }
+
+func TestRegisterEndpoint_Disabled(t *testing.T) {
+	router := New()
+	disabled := false
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/users", Method: "GET", Response: `{"users": []}`},
+		{Path: "/api/users", Method: "DELETE", Response: "{}", Enabled: &disabled},
+		{Path: "/api/beta", Method: "GET", Response: `{"beta": true}`, Enabled: &disabled},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	if got := router.GetEndpoints(); len(got) != 1 || got[0].Method != "GET" {
+		t.Errorf("Expected only the enabled endpoint, got %v", got)
+	}
+
+	// A disabled endpoint answers as if it were never configured
+	tests := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{"GET", "/api/users", http.StatusOK},
+		{"DELETE", "/api/users", http.StatusMethodNotAllowed},
+		{"GET", "/api/beta", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("Expected %d for %s %s, got %d", tt.expected, tt.method, tt.path, w.Code)
+		}
+	}
+}
+
+func TestRouterHandler_BenchMode(t *testing.T) {
+	router := New()
+	router.SetBenchMode(true)
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/static", Method: "GET", Status: 201, Response: `{"static": true}`, Headers: map[string]string{"X-Custom": "value"}},
+		{Path: "/dynamic", Method: "GET", Status: 200, Response: `{"path": "{{path}}"}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/static", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 201 {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+	if w.Header().Get("X-Custom") != "value" {
+		t.Errorf("Expected X-Custom header 'value', got '%s'", w.Header().Get("X-Custom"))
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != `{"static": true}` {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+
+	// Templated endpoints still go through templating in bench mode
+	req = httptest.NewRequest("GET", "/dynamic", nil)
+	w = httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Body.String() != `{"path": "/dynamic"}` {
+		t.Errorf("Expected templated body, got %s", w.Body.String())
+	}
+}
+
+func TestRouterHandler_MaxBodyBytes(t *testing.T) {
+	router := New()
+	router.SetMaxBodyBytes(10)
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/echo", Method: "POST", Status: 200, Response: `{"received": {{body}}}`},
+		{Path: "/ignore", Method: "POST", Status: 200, Response: `{"ok": true}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	large := `{"name":"a long value"}`
+
+	// Declared Content-Length over the limit is rejected up front
+	req := httptest.NewRequest("POST", "/ignore", bytes.NewBufferString(large))
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+	if w.Code != 413 {
+		t.Errorf("Expected status 413 for oversized Content-Length, got %d", w.Code)
+	}
+
+	// Bodies of unknown length are rejected when the template reads them
+	req = httptest.NewRequest("POST", "/echo", io.NopCloser(bytes.NewBufferString(large)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+	if w.Code != 413 {
+		t.Errorf("Expected status 413 for oversized streamed body, got %d", w.Code)
+	}
+
+	// Bodies within the limit pass through
+	req = httptest.NewRequest("POST", "/echo", bytes.NewBufferString(`{"a":1}`))
+	w = httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != `{"received": {"a":1}}` {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestRouterHandler_SNIRouting(t *testing.T) {
+	router := New()
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/whoami", Method: "GET", Status: 200, Response: `{"host": "default"}`},
+		{Path: "/whoami", Method: "GET", Status: 200, ServerName: "api.example.com", Response: `{"host": "api", "sni": "{{tls.servername}}"}`},
+		{Path: "/whoami", Method: "GET", Status: 200, ServerName: "admin.example.com", Response: `{"host": "admin"}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	server := httptest.NewTLSServer(router.Handler())
+	defer server.Close()
+
+	tests := []struct {
+		serverName string
+		expected   string
+	}{
+		{"api.example.com", `{"host": "api", "sni": "api.example.com"}`},
+		{"ADMIN.example.com", `{"host": "admin"}`},
+		{"other.example.com", `{"host": "default"}`},
+	}
+
+	for _, tt := range tests {
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					ServerName:         tt.serverName,
+					InsecureSkipVerify: true, // httptest certificate does not cover these names
+				},
+			},
+		}
+
+		resp, err := client.Get(server.URL + "/whoami")
+		if err != nil {
+			t.Fatalf("Request with SNI %s failed: %v", tt.serverName, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+
+		if string(body) != tt.expected {
+			t.Errorf("For SNI %s, expected body %s, got %s", tt.serverName, tt.expected, string(body))
+		}
+	}
+}
+
+func TestRouterHandler_SNIWithoutFallback(t *testing.T) {
+	router := New()
+
+	endpoint := models.EndpointConfig{Path: "/secure", Method: "GET", ServerName: "api.example.com", Response: "{}"}
+	if err := router.RegisterEndpoint(endpoint); err != nil {
+		t.Fatalf("Failed to register endpoint: %v", err)
+	}
+
+	// Plaintext requests carry no SNI and must not match a bound endpoint
+	req := httptest.NewRequest("GET", "/secure", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 404 {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestRouterHandler_DefaultHeaders(t *testing.T) {
+	router := New()
+	router.SetDefaultHeaders(map[string]string{
+		"X-API-Version": "2024-01",
+		"X-Service":     "mock",
+	})
+	router.RegisterHealthCheck()
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/plain", Method: "GET", Response: "{}"},
+		{Path: "/override", Method: "GET", Response: "{}", Headers: map[string]string{"X-API-Version": "legacy"}},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantVersion string
+	}{
+		{"/plain", "2024-01"},
+		{"/override", "legacy"},
+		{"/health", "2024-01"},
+		{"/missing", "2024-01"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if got := w.Header().Get("X-API-Version"); got != tt.wantVersion {
+			t.Errorf("%s: expected X-API-Version %q, got %q", tt.path, tt.wantVersion, got)
+		}
+		if got := w.Header().Get("X-Service"); got != "mock" {
+			t.Errorf("%s: expected X-Service default header, got %q", tt.path, got)
+		}
+	}
+}
+
+func TestRouterHandler_RequestID(t *testing.T) {
+	router := New()
+	router.SetRequestID(true)
+	router.RegisterHealthCheck()
+
+	endpoint := models.EndpointConfig{Path: "/trace", Method: "GET", Response: `{"id": "{{request_id}}"}`}
+	if err := router.RegisterEndpoint(endpoint); err != nil {
+		t.Fatalf("Failed to register endpoint: %v", err)
+	}
+
+	// An incoming ID is echoed in headers and templating
+	req := httptest.NewRequest("GET", "/trace", nil)
+	req.Header.Set("X-Request-Id", "abc-123")
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Request-Id"); got != "abc-123" {
+		t.Errorf("Expected echoed X-Request-Id abc-123, got %q", got)
+	}
+	if w.Body.String() != `{"id": "abc-123"}` {
+		t.Errorf("Expected templated request ID, got %s", w.Body.String())
+	}
+
+	// A UUID is generated when absent, on success and error responses alike
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, path := range []string{"/trace", "/health", "/missing"} {
+		req = httptest.NewRequest("GET", path, nil)
+		w = httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		id := w.Header().Get("X-Request-Id")
+		if !uuidPattern.MatchString(id) {
+			t.Errorf("%s: expected generated UUID, got %q", path, id)
+		}
+		if path == "/trace" && w.Body.String() != `{"id": "`+id+`"}` {
+			t.Errorf("Expected body to contain generated ID %s, got %s", id, w.Body.String())
+		}
+	}
+}
+
+func TestRouterHandler_CustomErrorResponses(t *testing.T) {
+	router := New()
+	router.SetErrorResponses(
+		`{"code": "NOT_FOUND", "message": "no route for {{method}} {{path}}"}`,
+		`{"code": "METHOD_NOT_ALLOWED", "allowed": "{{allowed}}"}`,
+	)
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/items", Method: "GET", Response: "{}"},
+		{Path: "/items", Method: "POST", Response: "{}"},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 404 {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	expected := `{"code": "NOT_FOUND", "message": "no route for GET /missing"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+
+	req = httptest.NewRequest("DELETE", "/items", nil)
+	w = httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 405 {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+	if w.Header().Get("Allow") != "GET, POST" {
+		t.Errorf("Expected Allow header 'GET, POST', got %q", w.Header().Get("Allow"))
+	}
+	expected = `{"code": "METHOD_NOT_ALLOWED", "allowed": "GET, POST"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestRouterHandler_DefaultEndpoint(t *testing.T) {
+	router := New()
+	router.SetErrorResponses(`{"code": "NOT_FOUND"}`, "")
+	router.SetDefaultEndpoint(&models.DefaultEndpointConfig{
+		Status:   200,
+		Response: `{"stub": true, "path": "{{path}}"}`,
+		Headers:  map[string]string{"X-Mock": "default"},
+	})
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/items", Method: "GET", Response: `{"items": []}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	// Unmatched paths hit the default endpoint instead of the 404
+	req := httptest.NewRequest("GET", "/missing/thing", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected status 200 from the default endpoint, got %d", w.Code)
+	}
+	expected := `{"stub": true, "path": "/missing/thing"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected body %s, got %s", expected, w.Body.String())
+	}
+	if w.Header().Get("X-Mock") != "default" {
+		t.Errorf("Expected X-Mock header from the default endpoint, got %q", w.Header().Get("X-Mock"))
+	}
+
+	// Configured endpoints and 405s are unaffected
+	req = httptest.NewRequest("GET", "/items", nil)
+	w = httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+	if w.Body.String() != `{"items": []}` {
+		t.Errorf("Expected the configured endpoint, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("DELETE", "/items", nil)
+	w = httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+	if w.Code != 405 {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+func TestRouterHandler_DefaultEndpointStatus(t *testing.T) {
+	router := New()
+	router.SetDefaultEndpoint(&models.DefaultEndpointConfig{Response: `{"error": "nothing here"}`})
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 404 || w.Body.String() != `{"error": "nothing here"}` {
+		t.Errorf("Expected the branded 404, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRouterHandler_DefaultErrorResponses(t *testing.T) {
+	router := New()
+
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: "/items", Method: "GET", Response: "{}"}); err != nil {
+		t.Fatalf("Failed to register endpoint: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	expected := `{"error":"endpoint not found","path":"/missing","method":"GET"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected default 404 body %s, got %s", expected, w.Body.String())
+	}
+
+	req = httptest.NewRequest("PUT", "/items", nil)
+	w = httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	expected = `{"error":"method not allowed","allowed":["GET"],"received":"PUT"}`
+	if w.Body.String() != expected {
+		t.Errorf("Expected default 405 body %s, got %s", expected, w.Body.String())
+	}
+}
+
+func TestRegisterEndpoint_MethodResponses(t *testing.T) {
+	router := New()
+
+	endpoint := models.EndpointConfig{
+		Path:   "/api/items",
+		Status: 200,
+		MethodResponses: map[string]string{
+			"GET":  `{"items": []}`,
+			"post": `{"created": "{{method}}"}`,
+		},
+	}
+	if err := router.RegisterEndpoint(endpoint); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		expected string
+	}{
+		{"GET", `{"items": []}`},
+		{"POST", `{"created": "POST"}`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/items", nil)
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if w.Body.String() != tt.expected {
+			t.Errorf("%s: expected body %s, got %s", tt.method, tt.expected, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("DELETE", "/api/items", nil)
+	w := httptest.NewRecorder()
+	router.Handler().ServeHTTP(w, req)
+
+	if w.Code != 405 {
+		t.Errorf("Expected status 405 for unlisted method, got %d", w.Code)
+	}
+	if w.Header().Get("Allow") != "GET, POST" {
+		t.Errorf("Expected Allow header 'GET, POST', got %q", w.Header().Get("Allow"))
+	}
+}
+
+func TestRegisterEndpoint_WildcardMethod(t *testing.T) {
+	router := New()
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/items", Method: "*", Status: 202, Response: `{"any": "{{method}}"}`},
+		{Path: "/api/items", Method: "GET", Status: 200, Response: `{"items": []}`},
+		{Path: "/api/other", Method: "ANY", Response: `{"other": true}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		expected string
+	}{
+		{"GET", "/api/items", 200, `{"items": []}`},
+		{"DELETE", "/api/items", 202, `{"any": "DELETE"}`},
+		{"PATCH", "/api/items", 202, `{"any": "PATCH"}`},
+		{"PUT", "/api/other", 200, `{"other": true}`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.expected {
+			t.Errorf("%s %s: expected %d %s, got %d %s", tt.method, tt.path, tt.status, tt.expected, w.Code, w.Body.String())
+		}
+	}
+
+	// "ANY" and "*" are the same route
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: "/api/items", Method: "any"}); err == nil {
+		t.Error("Expected error for a second wildcard on the same path, got nil")
+	}
+}
+
+func TestRegisterEndpoint_MethodAliases(t *testing.T) {
+	router := New()
+	router.SetMethodAliases(map[string]string{"list": "get", "PURGE": "DELETE"})
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/items", Method: "GET", Response: `{"items": [], "method": "{{method}}"}`},
+		{Path: "/api/items", Method: "POST", Status: 201, Response: `{"created": true}`},
+		{Path: "/api/items", Method: "LIST", Response: `{"own": true}`},
+		{Path: "/api/cache", Method: "DELETE", Status: 204},
+		{Path: "/api/cache", Method: "GET", Response: `{"cached": true}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("RegisterEndpoints failed: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		expected string
+	}{
+		// The endpoint configured for the alias itself wins
+		{"LIST", "/api/items", 200, `{"own": true}`},
+		// The alias resolves to the GET endpoint, which sees GET
+		{"list", "/api/cache", 200, `{"cached": true}`},
+		{"PURGE", "/api/cache", 204, ``},
+		// Lowercase methods reach the uppercase endpoint
+		{"get", "/api/items", 200, `{"items": [], "method": "GET"}`},
+		{"post", "/api/items", 201, `{"created": true}`},
+		// Unaliased custom verbs still get a 405
+		{"PURGE", "/api/items", 405, ``},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, w.Code)
+		}
+		if tt.expected != "" && w.Body.String() != tt.expected {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.expected, w.Body.String())
+		}
+	}
+}
+
+func TestRegisterEndpoint_MethodOverride(t *testing.T) {
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/items", Method: "POST", Status: 201, Response: `{"created": true}`},
+		{Path: "/api/items", Method: "DELETE", Response: `{"deleted": true, "method": "{{method}}"}`},
+		{Path: "/api/items", Method: "PURGE", Response: `{"purged": true}`},
+	}
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		method   string
+		override string
+		status   int
+		expected string
+	}{
+		{"routes to DELETE", true, "POST", "delete", 200, `{"deleted": true, "method": "DELETE"}`},
+		{"method not allowed stays POST", true, "POST", "PURGE", 201, `{"created": true}`},
+		{"only POST is overridden", true, "GET", "DELETE", 405, ``},
+		{"ignored when disabled", false, "POST", "DELETE", 201, `{"created": true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New()
+			if tt.enabled {
+				router.SetMethodOverride([]string{"PUT", "PATCH", "DELETE"})
+			}
+			if err := router.RegisterEndpoints(endpoints); err != nil {
+				t.Fatalf("RegisterEndpoints failed: %v", err)
+			}
+
+			req := httptest.NewRequest(tt.method, "/api/items", nil)
+			req.Header.Set(MethodOverrideHeader, tt.override)
+			w := httptest.NewRecorder()
+			router.Handler().ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.expected != "" && w.Body.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func benchmarkStaticEndpoint(b *testing.B, benchMode bool) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	router := New()
+	router.SetBenchMode(benchMode)
+	if err := router.RegisterEndpoint(models.EndpointConfig{
+		Path:     "/api/static",
+		Method:   "GET",
+		Status:   200,
+		Response: `{"users": [{"id": 1, "name": "Alice"}, {"id": 2, "name": "Bob"}]}`,
+	}); err != nil {
+		b.Fatalf("Failed to register endpoint: %v", err)
+	}
+	handler := router.Handler()
+	req := httptest.NewRequest("GET", "/api/static", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "req/s")
+}
+
+func BenchmarkRouter_StaticEndpoint_Default(b *testing.B) {
+	benchmarkStaticEndpoint(b, false)
+}
+
+func BenchmarkRouter_StaticEndpoint_BenchMode(b *testing.B) {
+	benchmarkStaticEndpoint(b, true)
+}
+
+func TestRouterHandler_HostRouting(t *testing.T) {
+	router := New()
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/api", Method: "GET", Status: 200, Host: "users.local", Response: `{"service": "users"}`},
+		{Path: "/api", Method: "GET", Status: 200, Host: "orders.local", Response: `{"service": "orders"}`},
+		{Path: "/api", Method: "POST", Status: 201, Host: "orders.local", Response: `{"created": true}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+	handler := router.Handler()
+
+	tests := []struct {
+		method   string
+		host     string
+		status   int
+		expected string
+	}{
+		{"GET", "users.local", 200, `{"service": "users"}`},
+		{"GET", "ORDERS.local:8080", 200, `{"service": "orders"}`},
+		{"POST", "orders.local", 201, `{"created": true}`},
+		{"GET", "billing.local", 404, ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api", nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.host, tt.status, w.Code)
+		}
+		if tt.expected != "" && w.Body.String() != tt.expected {
+			t.Errorf("%s %s: expected body %s, got %s", tt.method, tt.host, tt.expected, w.Body.String())
+		}
+	}
+
+	// Only the methods served for the host are allowed
+	req := httptest.NewRequest("POST", "/api", nil)
+	req.Host = "users.local"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != 405 || w.Header().Get("Allow") != "GET" {
+		t.Errorf("Expected 405 allowing GET for users.local, got %d allowing %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestRouterHandler_HostFallback(t *testing.T) {
+	router := New()
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/api", Method: "GET", Status: 200, Response: `{"service": "default"}`},
+		{Path: "/api", Method: "GET", Status: 200, Host: "users.local", Response: `{"service": "users"}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	for host, expected := range map[string]string{
+		"users.local":  `{"service": "users"}`,
+		"orders.local": `{"service": "default"}`,
+		"localhost":    `{"service": "default"}`,
+	} {
+		req := httptest.NewRequest("GET", "/api", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if w.Body.String() != expected {
+			t.Errorf("For host %s, expected body %s, got %s", host, expected, w.Body.String())
+		}
+	}
+}
+
+func TestRouterHandler_QueryRouting(t *testing.T) {
+	router := New()
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/search", Method: "GET", Status: 200, MatchQuery: map[string]string{"type": "user"}, Response: `{"results": "users"}`},
+		{Path: "/search", Method: "GET", Status: 200, MatchQuery: map[string]string{"type": "order"}, Response: `{"results": "orders"}`},
+		{Path: "/search", Method: "GET", Status: 200, MatchQuery: map[string]string{"type": "order", "archived": ""}, Response: `{"results": "archived orders"}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+	handler := router.Handler()
+
+	tests := []struct {
+		target   string
+		status   int
+		expected string
+	}{
+		{"/search?type=user", 200, `{"results": "users"}`},
+		{"/search?type=order&q=shoes", 200, `{"results": "orders"}`},
+		// The route matching more parameters wins; an empty value only needs presence
+		{"/search?type=order&archived", 200, `{"results": "archived orders"}`},
+		{"/search?archived=1&type=order", 200, `{"results": "archived orders"}`},
+		// Repeated parameters match on any value
+		{"/search?type=invoice&type=user", 200, `{"results": "users"}`},
+		{"/search?type=invoice", 404, ""},
+		{"/search", 404, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("GET %s: expected status %d, got %d", tt.target, tt.status, w.Code)
+		}
+		if tt.expected != "" && w.Body.String() != tt.expected {
+			t.Errorf("GET %s: expected body %s, got %s", tt.target, tt.expected, w.Body.String())
+		}
+	}
+}
+
+func TestRouterHandler_QueryFallback(t *testing.T) {
+	router := New()
+
+	endpoints := []models.EndpointConfig{
+		{Path: "/search", Method: "GET", Status: 200, Response: `{"results": "all"}`},
+		{Path: "/search", Method: "GET", Status: 200, MatchQuery: map[string]string{"type": "user"}, Response: `{"results": "users"}`},
+		{Path: "/search", Method: "GET", Status: 200, Host: "orders.local", Response: `{"results": "orders host"}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+
+	tests := []struct {
+		target   string
+		host     string
+		expected string
+	}{
+		{"/search?type=user", "localhost", `{"results": "users"}`},
+		{"/search?type=order", "localhost", `{"results": "all"}`},
+		{"/search", "localhost", `{"results": "all"}`},
+		{"/search?type=order", "orders.local", `{"results": "orders host"}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if w.Body.String() != tt.expected {
+			t.Errorf("GET %s on %s: expected body %s, got %s", tt.target, tt.host, tt.expected, w.Body.String())
+		}
+	}
+
+	// Only routes with the same query conditions are duplicates
+	duplicate := models.EndpointConfig{Path: "/search", Method: "GET", MatchQuery: map[string]string{"type": "user"}}
+	if err := router.RegisterEndpoint(duplicate); err == nil {
+		t.Error("Expected an error registering the same match_query twice")
+	}
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// staticRoute serves the files of a local directory under a URL prefix
+type staticRoute struct {
+	prefix  string // always ends in /
+	handler http.Handler
+}
+
+// RegisterStatic serves the files in dir under the URL prefix, e.g.
+// /assets/logo.png from dir/logo.png. Endpoints take precedence over static
+// files, so an endpoint can override a single file or the whole prefix.
+func (rt *Router) RegisterStatic(prefix, dir string) {
+	prefix = staticPrefix(prefix)
+	rt.statics = append(rt.statics, staticRoute{
+		prefix:  prefix,
+		handler: http.StripPrefix(prefix, http.FileServer(http.Dir(dir))),
+	})
+	// Longest prefix first, so nested prefixes win over their parents
+	sort.SliceStable(rt.statics, func(i, j int) bool {
+		return len(rt.statics[i].prefix) > len(rt.statics[j].prefix)
+	})
+	log.Printf("Registered static files: GET %s -> %s", prefix, dir)
+}
+
+// staticPrefix normalizes a static URL prefix to end in a slash
+func staticPrefix(prefix string) string {
+	prefix = strings.TrimSpace(prefix)
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// matchStatic returns the static route serving path, or nil. A prefix also
+// matches its path without the trailing slash, which the file server
+// redirects to the directory.
+func (rt *Router) matchStatic(path string) *staticRoute {
+	for i := range rt.statics {
+		static := &rt.statics[i]
+		if strings.HasPrefix(path, static.prefix) || path == strings.TrimSuffix(static.prefix, "/") {
+			return static
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestRouterHandler_Static(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log('hi')"), 0644); err != nil {
+		t.Fatalf("Failed to create static file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"file": true}`), 0644); err != nil {
+		t.Fatalf("Failed to create static file: %v", err)
+	}
+
+	router := New()
+	router.RegisterHealthCheck()
+	router.RegisterStatic("/assets", dir)
+	router.RegisterStatic("/", dir)
+	if err := router.RegisterEndpoint(models.EndpointConfig{Path: "/assets/config.json", Method: "GET", Response: `{"endpoint": true}`}); err != nil {
+		t.Fatalf("Failed to register endpoint: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected int
+		body     string
+	}{
+		{"/assets/app.js", http.StatusOK, "console.log('hi')"},
+		{"/app.js", http.StatusOK, "console.log('hi')"},
+		{"/assets/missing.js", http.StatusNotFound, ""},
+		// Endpoints and built-in routes take precedence over files
+		{"/assets/config.json", http.StatusOK, `{"endpoint": true}`},
+		{"/health", http.StatusOK, `{"status":"healthy","service":"blandmockapi"}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		router.Handler().ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("Expected %d for %s, got %d", tt.expected, tt.path, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("Expected body %s for %s, got %s", tt.body, tt.path, w.Body.String())
+		}
+	}
+}
+
+func TestStaticPrefix(t *testing.T) {
+	tests := map[string]string{
+		"/assets":  "/assets/",
+		"/assets/": "/assets/",
+		" /files ": "/files/",
+		"/":        "/",
+	}
+	for prefix, expected := range tests {
+		if got := staticPrefix(prefix); got != expected {
+			t.Errorf("staticPrefix(%q): expected %q, got %q", prefix, expected, got)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// defaultStreamInterval separates chunks when stream_interval is unset
+const defaultStreamInterval = 100 * time.Millisecond
+
+// writeChunks writes body in n roughly equal chunks, flushing after each and
+// pausing interval between them. No Content-Length is set, so HTTP/1.1
+// clients receive a chunked response. Writing stops early when the client
+// goes away.
+func writeChunks(w http.ResponseWriter, r *http.Request, body []byte, n int, interval time.Duration) {
+	controller := http.NewResponseController(w)
+	if n > len(body) {
+		n = len(body)
+	}
+
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(interval):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		chunk := body[i*len(body)/n : (i+1)*len(body)/n]
+		if _, err := w.Write(chunk); err != nil {
+			log.Printf("Failed to write response chunk: %v", err)
+			return
+		}
+		if err := controller.Flush(); err != nil {
+			log.Printf("Cannot flush response chunk, writing unflushed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// flushRecorder records the body written before each flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []string
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushed = append(f.flushed, f.Body.String())
+	f.ResponseRecorder.Flush()
+}
+
+func TestHandler_StreamChunks(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:           "/api/stream",
+		Method:         "GET",
+		Response:       `{"events": [1, 2, 3, 4]}`,
+		StreamChunks:   4,
+		StreamInterval: 10,
+	})
+
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler(w, httptest.NewRequest("GET", "/api/stream", nil))
+
+	if w.Body.String() != `{"events": [1, 2, 3, 4]}` {
+		t.Errorf("Expected the whole response, got %s", w.Body.String())
+	}
+	if len(w.flushed) != 4 {
+		t.Fatalf("Expected 4 flushes, got %d: %q", len(w.flushed), w.flushed)
+	}
+	for i := 1; i < len(w.flushed); i++ {
+		if len(w.flushed[i]) <= len(w.flushed[i-1]) {
+			t.Errorf("Expected flush %d to add to the body, got %q", i, w.flushed)
+		}
+	}
+	if w.Header().Get("Content-Length") != "" {
+		t.Errorf("Expected no Content-Length, got %s", w.Header().Get("Content-Length"))
+	}
+}
+
+func TestHandler_StreamChunksOverHTTP(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:           "/api/stream",
+		Method:         "GET",
+		Response:       "line one\nline two\nline three\n",
+		Headers:        map[string]string{"Content-Type": "text/plain"},
+		StreamChunks:   3,
+		StreamInterval: 50,
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Expected a chunked response, got %v", resp.TransferEncoding)
+	}
+
+	// Each line arrives separately, spaced by the interval
+	reader := bufio.NewReader(resp.Body)
+	var arrivals []time.Time
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			arrivals = append(arrivals, time.Now())
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(arrivals) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(arrivals))
+	}
+	if gap := arrivals[2].Sub(arrivals[0]); gap < 80*time.Millisecond {
+		t.Errorf("Expected lines to arrive over time, got them within %v", gap)
+	}
+}
+
+func TestHandler_StreamChunksCancelled(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:           "/api/stream",
+		Method:         "GET",
+		Response:       strings.Repeat("x", 10),
+		StreamChunks:   10,
+		StreamInterval: 1000,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/api/stream", nil).WithContext(ctx)
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	handler(w, req)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected streaming to stop when the request was cancelled, took %v", elapsed)
+	}
+	if w.Body.String() != "x" {
+		t.Errorf("Expected only the first chunk, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// throttle delays requests by a latency that grows with the number of
+// requests in flight: base, plus increment for every other request being
+// served, capped at max
+type throttle struct {
+	base      time.Duration
+	increment time.Duration
+	max       time.Duration // 0 is uncapped
+	inFlight  atomic.Int64
+}
+
+// newThrottle returns nil when cfg is nil
+func newThrottle(cfg *models.ThrottleConfig) *throttle {
+	if cfg == nil {
+		return nil
+	}
+	return &throttle{
+		base:      time.Duration(cfg.BaseMS) * time.Millisecond,
+		increment: time.Duration(cfg.IncrementMS) * time.Millisecond,
+		max:       time.Duration(cfg.MaxMS) * time.Millisecond,
+	}
+}
+
+// delay returns the latency for a request arriving while inFlight requests,
+// itself included, are being served
+func (t *throttle) delay(inFlight int64) time.Duration {
+	d := t.base + time.Duration(inFlight-1)*t.increment
+	if t.max > 0 && d > t.max {
+		d = t.max
+	}
+	return d
+}
+
+// wait counts r as in flight and delays it by the current latency. It
+// reports false when the client went away while waiting. Callers must call
+// done once the request is served, whatever wait returned.
+func (t *throttle) wait(r *http.Request) bool {
+	d := t.delay(t.inFlight.Add(1))
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// done stops counting a request as in flight
+func (t *throttle) done() {
+	t.inFlight.Add(-1)
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestThrottle_Delay(t *testing.T) {
+	throttle := newThrottle(&models.ThrottleConfig{BaseMS: 10, IncrementMS: 20, MaxMS: 45})
+
+	tests := []struct {
+		inFlight int64
+		expected time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 30 * time.Millisecond},
+		{3, 45 * time.Millisecond}, // capped
+		{10, 45 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := throttle.delay(tt.inFlight); got != tt.expected {
+			t.Errorf("Expected %v with %d in flight, got %v", tt.expected, tt.inFlight, got)
+		}
+	}
+}
+
+func TestRouterHandler_Throttle(t *testing.T) {
+	router := New()
+	router.SetThrottle(&models.ThrottleConfig{IncrementMS: 100})
+	endpoints := []models.EndpointConfig{
+		{Path: "/api/slow", Method: "GET", Response: `{}`, Delay: 300},
+		{Path: "/api/fast", Method: "GET", Response: `{}`},
+	}
+	if err := router.RegisterEndpoints(endpoints); err != nil {
+		t.Fatalf("Failed to register endpoints: %v", err)
+	}
+	handler := router.Handler()
+
+	serve := func(path string) time.Duration {
+		start := time.Now()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected 200 from %s, got %d", path, w.Code)
+		}
+		return time.Since(start)
+	}
+
+	// Alone, a request is not delayed
+	if elapsed := serve("/api/fast"); elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected an unloaded request to be fast, took %v", elapsed)
+	}
+
+	// With the slow request still in flight, the next one waits an increment
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve("/api/slow")
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if elapsed := serve("/api/fast"); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected a request under load to wait at least 100ms, took %v", elapsed)
+	}
+	<-done
+
+	// The in-flight count drops back once requests finish
+	if elapsed := serve("/api/fast"); elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected the load to clear, took %v", elapsed)
+	}
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// sleepContext waits for d, reporting false when ctx ends first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// timedOut reports whether r was abandoned because its endpoint's timeout
+// passed, rather than because the client went away
+func timedOut(r *http.Request) bool {
+	return errors.Is(r.Context().Err(), context.DeadlineExceeded)
+}
+
+// GatewayTimeoutHandler returns a 504 handler for requests that exceeded
+// their endpoint's timeout
+func GatewayTimeoutHandler(timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("[504] %s %s exceeded its %v timeout", r.Method, r.URL.Path, timeout)
+		response := fmt.Sprintf(`{"error":"endpoint timed out","timeout_ms":%d}`, timeout.Milliseconds())
+		writeError(w, r, http.StatusGatewayTimeout, "endpoint timed out",
+			fmt.Sprintf("no response within %d ms", timeout.Milliseconds()), response)
+	}
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestHandler_TimeoutNotExceeded(t *testing.T) {
+	handler := Handler(models.EndpointConfig{Path: "/api/slow", Method: "GET", Response: `{"ok": true}`, Delay: 10, TimeoutMS: 500})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/slow", nil))
+	if w.Code != 200 || w.Body.String() != `{"ok": true}` {
+		t.Errorf("Expected the response within the timeout, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_TimeoutExceeded(t *testing.T) {
+	handler := Handler(models.EndpointConfig{Path: "/api/slow", Method: "GET", Response: `{"ok": true}`, Delay: 2000, TimeoutMS: 50})
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	handler(w, httptest.NewRequest("GET", "/api/slow", nil))
+	elapsed := time.Since(start)
+
+	if w.Code != 504 {
+		t.Fatalf("Expected status 504 for a delay over the timeout, got %d", w.Code)
+	}
+	if w.Body.String() != `{"error":"endpoint timed out","timeout_ms":50}` {
+		t.Errorf("Unexpected 504 body: %s", w.Body.String())
+	}
+	if elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected the request to end at the timeout, took %v", elapsed)
+	}
+}
+
+func TestHandler_TimeoutWhileQueued(t *testing.T) {
+	handler := Handler(models.EndpointConfig{
+		Path:        "/api/slow",
+		Method:      "GET",
+		Response:    `{"ok": true}`,
+		Delay:       300,
+		TimeoutMS:   400,
+		Concurrency: &models.ConcurrencyConfig{Max: 1, Overflow: models.OverflowQueue},
+	})
+
+	// The first request holds the only slot for 300ms of its 400ms timeout
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/api/slow", nil))
+		done <- w.Code
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// The second waits for the slot, then runs out of time during its delay
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/slow", nil))
+	if w.Code != 504 {
+		t.Errorf("Expected status 504 for the queued request, got %d", w.Code)
+	}
+	if code := <-done; code != 200 {
+		t.Errorf("Expected status 200 for the first request, got %d", code)
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import "github.com/jimbo/blandmockapi/internal/models"
+
+// Aliases for the configuration types, so code outside this module can build
+// a Config in Go rather than loading TOML. Their fields match the TOML keys
+// documented in the README.
+type (
+	// ServerConfig is the [server] table
+	ServerConfig = models.ServerConfig
+	// EndpointConfig is one [[endpoints]] entry
+	EndpointConfig = models.EndpointConfig
+	// HeaderConfig is one [[endpoints.header]] entry
+	HeaderConfig = models.HeaderConfig
+	// RequiredHeader is one [[endpoints.required_headers]] entry
+	RequiredHeader = models.RequiredHeader
+	// ResponseVariant is one [[endpoints.responses]] entry
+	ResponseVariant = models.ResponseVariant
+	// LocalizedResponse is one [[endpoints.localized]] entry
+	LocalizedResponse = models.LocalizedResponse
+	// CookieConfig is one [[endpoints.cookies]] entry
+	CookieConfig = models.CookieConfig
+	// RetryAfter is an endpoint's retry_after value
+	RetryAfter = models.RetryAfter
+	// LatencyConfig is an [endpoints.latency] table
+	LatencyConfig = models.LatencyConfig
+	// DelayRule is one [[endpoints.delay_when]] entry
+	DelayRule = models.DelayRule
+	// FaultConfig is an [endpoints.fault] table
+	FaultConfig = models.FaultConfig
+	// ConcurrencyConfig is an [endpoints.concurrency] table
+	ConcurrencyConfig = models.ConcurrencyConfig
+	// QuotaConfig is an [endpoints.quota] table
+	QuotaConfig = models.QuotaConfig
+	// CallbackConfig is an [endpoints.callback] table
+	CallbackConfig = models.CallbackConfig
+	// ProxyConfig is an [endpoints.proxy] table
+	ProxyConfig = models.ProxyConfig
+	// NamedResponse is one [[responses]] entry
+	NamedResponse = models.NamedResponse
+	// DefaultEndpointConfig is the [default_endpoint] table
+	DefaultEndpointConfig = models.DefaultEndpointConfig
+	// ErrorsConfig is the [errors] table
+	ErrorsConfig = models.ErrorsConfig
+	// ThrottleConfig is the [throttle] table
+	ThrottleConfig = models.ThrottleConfig
+	// HealthConfig is the [health] table
+	HealthConfig = models.HealthConfig
+	// StaticConfig is one [[static]] entry
+	StaticConfig = models.StaticConfig
+	// CORSConfig is the [cors] table
+	CORSConfig = models.CORSConfig
+	// GraphQLConfig is the [graphql] table
+	GraphQLConfig = models.GraphQLConfig
+	// GraphQLType is one [[graphql.types]] entry
+	GraphQLType = models.GraphQLType
+	// GraphQLField is one field of a GraphQL type
+	GraphQLField = models.GraphQLField
+	// GraphQLScalar is one [[graphql.scalars]] entry
+	GraphQLScalar = models.GraphQLScalar
+	// GraphQLQuery is one [[graphql.queries]] entry
+	GraphQLQuery = models.GraphQLQuery
+	// GraphQLCase is one [[graphql.queries.cases]] entry
+	GraphQLCase = models.GraphQLCase
+	// GraphQLMutation is one [[graphql.mutations]] entry
+	GraphQLMutation = models.GraphQLMutation
+	// GraphQLPersistedQuery is one [[graphql.persisted]] entry
+	GraphQLPersistedQuery = models.GraphQLPersistedQuery
+)
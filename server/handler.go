@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/jimbo/blandmockapi/internal/graphql"
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+// swappableHandler serves requests through a handler that can be replaced
+// while the server is running. In-flight requests finish on the handler they
+// started with.
+type swappableHandler struct {
+	current atomic.Pointer[http.Handler]
+}
+
+// newSwappableHandler creates a swappableHandler serving h
+func newSwappableHandler(h http.Handler) *swappableHandler {
+	s := &swappableHandler{}
+	s.Swap(h)
+	return s
+}
+
+// Swap atomically replaces the handler used for new requests
+func (s *swappableHandler) Swap(h http.Handler) {
+	s.current.Store(&h)
+}
+
+// ServeHTTP dispatches to the current handler
+func (s *swappableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*s.current.Load()).ServeHTTP(w, r)
+}
+
+// NewHandler creates a router for cfg with the health check, version and
+// OpenAPI documents, REST endpoints and optional request capture and GraphQL
+// endpoint registered. Only the BenchMode and Build options apply. Its
+// readiness probe, when configured, always reports ready.
+func NewHandler(cfg Config, opts Options) (http.Handler, error) {
+	return newHandler(cfg, opts, nil, nil)
+}
+
+// newHandler creates the NewHandler router with its readiness probe answering
+// from readiness and endpoint callbacks tracked by callbacks
+func newHandler(cfg Config, opts Options, readiness *router.Readiness, callbacks *router.Callbacks) (http.Handler, error) {
+	rt := router.New()
+	rt.SetBenchMode(opts.BenchMode)
+	rt.SetRandomSeed(cfg.Server.RandomSeed)
+	rt.SetMaxBodyBytes(cfg.Server.MaxBodyBytes)
+	rt.SetThrottle(cfg.Throttle)
+	rt.SetDefaultHeaders(cfg.DefaultHeaders)
+	rt.SetRequestID(cfg.Server.RequestID)
+	rt.SetAccessLog(cfg.Server.AccessLogEnabled(), cfg.Server.RedactQueryParams)
+	rt.SetErrorResponses(cfg.Server.NotFoundResponse, cfg.Server.MethodNotAllowedResponse)
+	rt.SetErrorEnvelope(cfg.Errors)
+	rt.SetDefaultEndpoint(cfg.DefaultEndpoint)
+	rt.SetMethodAliases(cfg.MethodAliases)
+	if cfg.Server.MethodOverride {
+		rt.SetMethodOverride(cfg.Server.GetMethodOverrideMethods())
+	}
+	rt.SetCORS(cfg.CORS)
+	rt.SetCallbacks(callbacks)
+	rt.SetTemplateEnv(cfg.Server.TemplateEnv)
+
+	// Register health check and probes, version, OpenAPI document and
+	// request capture
+	rt.RegisterHealthCheck()
+	if cfg.Health != nil {
+		rt.RegisterLiveness(cfg.Health.GetLivenessPath())
+		rt.RegisterReadiness(cfg.Health.GetReadinessPath(), readiness)
+	}
+	rt.RegisterVersion(router.VersionInfo{
+		Version:     opts.Build.Version,
+		Commit:      opts.Build.Commit,
+		BuildDate:   opts.Build.Date,
+		ConfigFiles: cfg.Files,
+	})
+	rt.RegisterOpenAPI()
+	if cfg.Server.CaptureRequests {
+		rt.RegisterCapture(cfg.Server.GetCaptureLimit())
+	}
+
+	// Register static file directories; endpoints take precedence
+	for _, static := range cfg.Static {
+		rt.RegisterStatic(static.Prefix, static.Dir)
+	}
+
+	// Register REST endpoints
+	if err := rt.RegisterEndpoints(cfg.Endpoints); err != nil {
+		return nil, fmt.Errorf("failed to register endpoints: %w", err)
+	}
+
+	// Register GraphQL endpoint if enabled
+	if cfg.GraphQL != nil && cfg.GraphQL.Enabled {
+		gqlHandler, err := graphql.New(cfg.GraphQL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GraphQL handler: %w", err)
+		}
+
+		path := cfg.GraphQL.Path
+		if path == "" {
+			path = "/graphql"
+		}
+		rt.SetGraphQLCaseInsensitive(cfg.GraphQL.CaseInsensitivePath)
+		rt.RegisterGraphQL(path, gqlHandler.ServeHTTP)
+		log.Printf("GraphQL endpoint enabled with %d types, %d queries, %d mutations",
+			len(cfg.GraphQL.Types), len(cfg.GraphQL.Queries), len(cfg.GraphQL.Mutations))
+	}
+
+	return rt.Handler(), nil
+}
+
+// RegisterTemplateFunc makes fn callable from response templates as
+// {{name arg...}}, e.g. {{name .Query.id}}. fn must return one value, or a
+// value and an error. Register functions before creating a Server.
+func RegisterTemplateFunc(name string, fn interface{}) error {
+	return router.RegisterTemplateFunc(name, fn)
+}
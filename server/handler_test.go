@@ -0,0 +1,132 @@
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/server"
+)
+
+func TestNewHandler_PublicConfig(t *testing.T) {
+	cfg := server.Config{
+		Endpoints: []server.EndpointConfig{
+			{
+				Path:       "/api/users",
+				Method:     "GET",
+				Response:   `{"id": "{{query.id}}"}`,
+				HeaderList: []server.HeaderConfig{{Name: "X-Mock", Value: "embedded"}},
+			},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	handler, err := server.NewHandler(cfg, server.Options{})
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/users?id=42")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || string(body) != `{"id": "42"}` {
+		t.Errorf("Expected 200 {\"id\": \"42\"}, got %d %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("X-Mock"); got != "embedded" {
+		t.Errorf("Expected X-Mock: embedded, got %q", got)
+	}
+}
+
+func TestNewHandler_ResponseFiles(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "user.json")
+	if err := os.WriteFile(fixture, []byte(`{"user": "alice"}`), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	// Files are read when the handler is built, without the config loader
+	cfg := server.Config{
+		Endpoints: []server.EndpointConfig{
+			{Path: "/api/user", Method: "GET", ResponseFiles: []string{fixture}},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	handler, err := server.NewHandler(cfg, server.Options{})
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/user", nil))
+	if w.Code != 200 || w.Body.String() != `{"user": "alice"}` {
+		t.Errorf("Expected 200 with the file body, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A missing file fails the handler instead of serving an empty body
+	cfg.Endpoints[0].ResponseFiles = []string{filepath.Join(t.TempDir(), "missing.json")}
+	if _, err := server.NewHandler(cfg, server.Options{}); err == nil {
+		t.Error("Expected an error for a missing response file, got nil")
+	}
+}
+
+func TestNewHandler_GlobalHeaders(t *testing.T) {
+	cfg := server.Config{
+		Server:         server.ServerConfig{RequestID: true},
+		DefaultHeaders: map[string]string{"X-Service": "mock"},
+		CORS:           &server.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}},
+		Endpoints: []server.EndpointConfig{
+			{Path: "/api/users", Method: "GET", Response: `{"users": []}`},
+		},
+		GraphQL: &server.GraphQLConfig{
+			Enabled: true,
+			Queries: []server.GraphQLQuery{{Name: "ping", ReturnType: "String", Response: `"pong"`}},
+		},
+	}
+
+	handler, err := server.NewHandler(cfg, server.Options{})
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	for _, tt := range []struct{ method, path, body string }{
+		{"GET", "/api/users", ""},
+		{"GET", "/health", ""},
+		{"POST", "/graphql", `{"query": "{ ping }"}`},
+	} {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Origin", "https://app.example.com")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tt.method, tt.path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Errorf("%s %s: expected status 200, got %d", tt.method, tt.path, resp.StatusCode)
+		}
+		if got := resp.Header.Get("X-Service"); got != "mock" {
+			t.Errorf("%s %s: expected default header X-Service, got %q", tt.method, tt.path, got)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("%s %s: expected CORS origin, got %q", tt.method, tt.path, got)
+		}
+		if resp.Header.Get("X-Request-Id") == "" {
+			t.Errorf("%s %s: expected X-Request-Id", tt.method, tt.path)
+		}
+	}
+}
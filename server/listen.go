@@ -0,0 +1,141 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+// newHTTPServer creates the HTTP server for the configured address, timeouts
+// and protocols
+func newHTTPServer(cfg models.ServerConfig, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.GetHost(), cfg.GetPort()),
+		Handler:           handler,
+		ReadTimeout:       cfg.GetReadTimeout(),
+		WriteTimeout:      cfg.GetWriteTimeout(),
+		IdleTimeout:       cfg.GetIdleTimeout(),
+		ReadHeaderTimeout: cfg.GetReadHeaderTimeout(),
+	}
+
+	// Serve HTTP/2 without TLS alongside HTTP/1 for h2c clients
+	if cfg.H2C {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	// Close every connection after one response, so each request opens a
+	// new connection
+	if cfg.DisableKeepAlive {
+		srv.SetKeepAlivesEnabled(false)
+	}
+	return srv
+}
+
+// listen opens the Unix socket at cfg.SocketPath when set, otherwise a TCP
+// listener on addr. Closing a Unix listener removes its socket file; a stale
+// socket left by a crashed server is removed before listening.
+func listen(cfg models.ServerConfig, addr string) (net.Listener, error) {
+	if cfg.SocketPath == "" {
+		if cfg.AutoPort {
+			return listenAutoPort(addr, cfg.GetAutoPortAttempts())
+		}
+		return net.Listen("tcp", addr)
+	}
+
+	if info, err := os.Stat(cfg.SocketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(cfg.SocketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", cfg.SocketPath, err)
+		}
+	}
+	return net.Listen("unix", cfg.SocketPath)
+}
+
+// listenAutoPort listens on addr, moving on to the next port while the
+// current one is already in use, up to attempts ports in total
+func listenAutoPort(addr string, attempts int) (net.Listener, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in %s: %w", addr, err)
+	}
+
+	for i := 0; i < attempts && port+i <= 65535; i++ {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port+i)))
+		if err == nil {
+			if i > 0 {
+				log.Printf("Port %d in use, bound %s instead", port, listener.Addr())
+			}
+			return listener, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no free port in %d attempts starting at %d", attempts, port)
+}
+
+// limitListener accepts a connection only while fewer than cap(slots)
+// connections are open across every listener sharing slots. Further
+// connections wait in the kernel's accept queue until a slot frees.
+type limitListener struct {
+	net.Listener
+	slots     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newLimitListener wraps l so that it shares the connection slots with
+// other listeners
+func newLimitListener(l net.Listener, slots chan struct{}) *limitListener {
+	return &limitListener{Listener: l, slots: slots, done: make(chan struct{})}
+}
+
+// Accept waits for a free slot, then for a connection
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.slots
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: func() { <-l.slots }}, nil
+}
+
+// Close closes the listener, ending an Accept waiting for a slot
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitConn frees its listener slot when closed
+type limitConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+// Close closes the connection and frees its slot
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
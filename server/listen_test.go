@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+func TestNewHTTPServer_H2C(t *testing.T) {
+	rt := router.New()
+	if err := rt.RegisterEndpoint(models.EndpointConfig{Path: "/api/ping", Method: "GET", Response: `{"pong": true}`}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	srv := newHTTPServer(models.ServerConfig{H2C: true}, rt.Handler())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	// A client that only speaks HTTP/2 over cleartext (prior knowledge)
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	resp, err := client.Get(fmt.Sprintf("http://%s/api/ping", listener.Addr()))
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+	if string(body) != `{"pong": true}` {
+		t.Errorf("Expected body {\"pong\": true}, got %s", body)
+	}
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	rt := router.New()
+	if err := rt.RegisterEndpoint(models.EndpointConfig{Path: "/api/ping", Method: "GET", Response: `{"pong": true}`}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	// Keep the path short; Unix socket paths are limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "bma")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "mock.sock")
+
+	cfg := models.ServerConfig{SocketPath: socketPath}
+	srv := newHTTPServer(cfg, rt.Handler())
+	listener, err := listen(cfg, srv.Addr)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	go srv.Serve(listener)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	resp, err := client.Get("http://unix/api/ping")
+	if err != nil {
+		t.Fatalf("Request over socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != `{"pong": true}` {
+		t.Errorf("Expected body {\"pong\": true}, got %s", body)
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("Expected socket file to be removed on shutdown, stat returned %v", err)
+	}
+}
+
+func TestNewHTTPServer_DefaultsToHTTP1(t *testing.T) {
+	srv := newHTTPServer(models.ServerConfig{Port: 9000}, http.NotFoundHandler())
+
+	if srv.Addr != "0.0.0.0:9000" {
+		t.Errorf("Expected address 0.0.0.0:9000, got %s", srv.Addr)
+	}
+	if srv.Protocols != nil {
+		t.Errorf("Expected default protocols without h2c, got %v", srv.Protocols)
+	}
+}
+
+func TestListen_AutoPort(t *testing.T) {
+	// Occupy a port, then ask for it with auto_port enabled
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer busy.Close()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+
+	cfg := models.ServerConfig{Host: "127.0.0.1", Port: busyPort}
+	if _, err := listen(cfg, newHTTPServer(cfg, nil).Addr); err == nil {
+		t.Fatal("Expected busy port to fail without auto_port")
+	}
+
+	cfg.AutoPort = true
+	listener, err := listen(cfg, newHTTPServer(cfg, nil).Addr)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	if port <= busyPort || port >= busyPort+cfg.GetAutoPortAttempts() {
+		t.Errorf("Expected a port after %d, got %d", busyPort, port)
+	}
+}
+
+func TestNewHTTPServer_DisableKeepAlive(t *testing.T) {
+	rt := router.New()
+	if err := rt.RegisterEndpoint(models.EndpointConfig{Path: "/api/ping", Method: "GET", Response: `{"pong": true}`}); err != nil {
+		t.Fatalf("RegisterEndpoint failed: %v", err)
+	}
+
+	for _, disabled := range []bool{false, true} {
+		srv := newHTTPServer(models.ServerConfig{DisableKeepAlive: disabled}, rt.Handler())
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		go srv.Serve(listener)
+
+		resp, err := http.Get(fmt.Sprintf("http://%s/api/ping", listener.Addr()))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		srv.Close()
+
+		// The server announces it will close the connection
+		if resp.Close != disabled {
+			t.Errorf("With disable_keepalive=%v expected Connection: close to be %v, got %v", disabled, disabled, resp.Close)
+		}
+	}
+}
+
+func TestServer_MaxConnections(t *testing.T) {
+	cfg := Config{
+		Server: models.ServerConfig{MaxConnections: 1},
+		Endpoints: []models.EndpointConfig{
+			{Path: "/api/ping", Method: "GET", Response: `{"pong": true}`},
+		},
+	}
+	srv, err := New(cfg, Options{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Shutdown(context.Background())
+
+	// An idle connection takes the only slot
+	held, err := net.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer held.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		client := &http.Client{Transport: &http.Transport{}}
+		resp, err := client.Get("http://" + srv.Addr() + "/api/ping")
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	// The request queues while the slot is held...
+	select {
+	case err := <-done:
+		t.Fatalf("Expected the request to wait for a free connection slot, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// ...and is served once it frees
+	held.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Request failed after the slot freed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Request still waiting after the slot freed")
+	}
+}
@@ -0,0 +1,245 @@
+// Package server runs the mock API. It backs cmd/server and can be embedded
+// in Go tests to serve a mock without starting a separate process:
+//
+//	cfg, err := server.LoadConfig("./testdata/mocks")
+//	srv, err := server.New(cfg, server.Options{Addr: "127.0.0.1:0"})
+//	err = srv.Start()
+//	defer srv.Shutdown(context.Background())
+//	resp, err := http.Get("http://" + srv.Addr() + "/health")
+//
+// A Config can also be built in Go from the aliased configuration types and
+// served by any http.Server through NewHandler.
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/jimbo/blandmockapi/internal/config"
+	"github.com/jimbo/blandmockapi/internal/models"
+	"github.com/jimbo/blandmockapi/internal/router"
+)
+
+// Config is the complete mock API configuration
+type Config = models.Config
+
+// Options adjusts how a Server runs its configuration
+type Options struct {
+	// Addr overrides the configured host and port, e.g. "127.0.0.1:0" to
+	// listen on a free port (optional)
+	Addr string
+	// BenchMode disables request logging and serves static responses from
+	// precomputed bytes
+	BenchMode bool
+	// Build identifies the running binary at /__version (optional)
+	Build BuildInfo
+}
+
+// BuildInfo identifies a build of the server, usually injected with -ldflags
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// Server serves a mock API configuration over HTTP on one or more addresses
+type Server struct {
+	cfg       Config
+	opts      Options
+	handler   *swappableHandler
+	readiness *router.Readiness // shared by the handlers of every reload
+	callbacks *router.Callbacks // pending endpoint callbacks of every reload
+	srvs      []*http.Server    // one per listen address, sharing handler
+	listeners []net.Listener
+	done      chan error
+
+	waitOnce sync.Once
+	waitErr  error
+}
+
+// LoadConfig loads and validates the configuration file or directory at path
+func LoadConfig(path string) (Config, error) {
+	loader := config.New()
+	loader.SetTemplateCheck(router.UndefinedTokens)
+	if err := loader.LoadFromPath(path); err != nil {
+		return Config{}, err
+	}
+	return loader.GetConfig(), nil
+}
+
+// New creates a server for cfg. It does not listen until Start is called.
+func New(cfg Config, opts Options) (*Server, error) {
+	readiness := &router.Readiness{}
+	callbacks := router.NewCallbacks()
+	h, err := newHandler(cfg, opts, readiness, callbacks)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{cfg: cfg, opts: opts, handler: newSwappableHandler(h), readiness: readiness, callbacks: callbacks}
+	for _, addr := range s.addresses() {
+		srv := newHTTPServer(cfg.Server, s.handler)
+		if addr != "" {
+			srv.Addr = addr
+		}
+		s.srvs = append(s.srvs, srv)
+	}
+	return s, nil
+}
+
+// addresses lists the addresses to listen on: Options.Addr, else the
+// configured listen addresses, else "" for the configured host and port
+func (s *Server) addresses() []string {
+	if s.opts.Addr != "" {
+		return []string{s.opts.Addr}
+	}
+	if len(s.cfg.Server.Listen) > 0 && s.cfg.Server.SocketPath == "" {
+		return s.cfg.Server.Listen
+	}
+	return []string{""}
+}
+
+// Start opens the listeners and serves requests in the background. Once it
+// returns, Addr and Addrs report the bound addresses. If any address cannot
+// be bound, none are served.
+func (s *Server) Start() error {
+	if s.listeners != nil {
+		return errors.New("server already started")
+	}
+
+	cfg := s.cfg.Server
+	if s.opts.Addr != "" {
+		// An explicit address replaces the configured Unix socket too
+		cfg.SocketPath = ""
+	}
+
+	// max_connections is shared by every listen address
+	var slots chan struct{}
+	if cfg.MaxConnections > 0 {
+		slots = make(chan struct{}, cfg.MaxConnections)
+		log.Printf("Connections limited to %d", cfg.MaxConnections)
+	}
+	if cfg.DisableKeepAlive {
+		log.Println("Keep-alive disabled: one request per connection")
+	}
+
+	listeners := make([]net.Listener, 0, len(s.srvs))
+	for _, srv := range s.srvs {
+		listener, err := listen(cfg, srv.Addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return err
+		}
+		if slots != nil {
+			listener = newLimitListener(listener, slots)
+		}
+		listeners = append(listeners, listener)
+		// auto_port and port 0 may bind a different port than configured
+		srv.Addr = listener.Addr().String()
+	}
+	s.listeners = listeners
+
+	if cfg.H2C {
+		log.Println("HTTP/2 cleartext (h2c) enabled")
+	}
+
+	s.done = make(chan error, len(s.srvs))
+	for i, srv := range s.srvs {
+		go func(srv *http.Server, listener net.Listener) {
+			var err error
+			if cfg.TLSEnabled() {
+				log.Printf("Server listening on %s (TLS)", listener.Addr())
+				err = srv.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+			} else {
+				log.Printf("Server listening on %s", listener.Addr())
+				err = srv.Serve(listener)
+			}
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			s.done <- err
+		}(srv, listeners[i])
+	}
+	return nil
+}
+
+// Addr returns the first address the server is listening on, or will listen
+// on before Start
+func (s *Server) Addr() string {
+	return s.Addrs()[0]
+}
+
+// Addrs returns every address the server is listening on, or will listen on
+// before Start, in configuration order
+func (s *Server) Addrs() []string {
+	addrs := make([]string, len(s.srvs))
+	for i, srv := range s.srvs {
+		addrs[i] = srv.Addr
+		if s.listeners != nil {
+			addrs[i] = s.listeners[i].Addr().String()
+		}
+	}
+	return addrs
+}
+
+// Wait blocks until every address stops serving, or until one fails. It
+// returns nil after Shutdown and the first serve error otherwise.
+func (s *Server) Wait() error {
+	if s.done == nil {
+		return errors.New("server not started")
+	}
+	s.waitOnce.Do(func() {
+		for range s.srvs {
+			if err := <-s.done; err != nil {
+				s.waitErr = err
+				return
+			}
+		}
+	})
+	return s.waitErr
+}
+
+// Shutdown gracefully stops every address, waiting for in-flight requests
+// and then pending endpoint callbacks until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	errs := make([]error, len(s.srvs))
+	var wg sync.WaitGroup
+	for i, srv := range s.srvs {
+		wg.Add(1)
+		go func(i int, srv *http.Server) {
+			defer wg.Done()
+			errs[i] = srv.Shutdown(ctx)
+		}(i, srv)
+	}
+	wg.Wait()
+	errs = append(errs, s.callbacks.Wait(ctx))
+	return errors.Join(errs...)
+}
+
+// Reload replaces the served endpoints with those of cfg. In-flight requests
+// finish on the previous configuration. Server settings such as the listen
+// address and timeouts only take effect on a new Server. The readiness probe
+// answers 503 until the reload finishes, successfully or not.
+func (s *Server) Reload(cfg Config) error {
+	s.readiness.SetReady(false)
+	defer s.readiness.SetReady(true)
+
+	h, err := newHandler(cfg, s.opts, s.readiness, s.callbacks)
+	if err != nil {
+		return err
+	}
+	s.handler.Swap(h)
+	return nil
+}
+
+// SetReady marks the server ready or not ready for the readiness probe, e.g.
+// while loading a configuration to pass to Reload
+func (s *Server) SetReady(ready bool) {
+	s.readiness.SetReady(ready)
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jimbo/blandmockapi/internal/models"
+)
+
+func TestServer_Embedded(t *testing.T) {
+	cfg := Config{
+		Server: models.ServerConfig{Port: 9000},
+		Endpoints: []models.EndpointConfig{
+			{Path: "/api/ping", Method: "GET", Response: `{"pong": true}`},
+		},
+	}
+
+	srv, err := New(cfg, Options{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if strings.HasSuffix(srv.Addr(), ":0") || strings.HasSuffix(srv.Addr(), ":9000") {
+		t.Fatalf("Expected an assigned port, got %s", srv.Addr())
+	}
+
+	resp, err := http.Get("http://" + srv.Addr() + "/health")
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected status 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := srv.Wait(); err != nil {
+		t.Errorf("Expected nil from Wait after Shutdown, got %v", err)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	cfg := Config{Endpoints: []models.EndpointConfig{
+		{Path: "/api/version", Method: "GET", Response: `{"version": 1}`},
+	}}
+	srv, err := New(cfg, Options{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Shutdown(context.Background())
+
+	cfg.Endpoints[0].Response = `{"version": 2}`
+	if err := srv.Reload(cfg); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	resp, err := http.Get("http://" + srv.Addr() + "/api/version")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"version": 2}` {
+		t.Errorf("Expected reloaded response, got %s", body)
+	}
+}
+
+func TestServer_ReadinessDuringReload(t *testing.T) {
+	cfg := Config{
+		Health:    &models.HealthConfig{},
+		Endpoints: []models.EndpointConfig{{Path: "/api/ping", Method: "GET", Response: `{}`}},
+	}
+	srv, err := New(cfg, Options{Addr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Shutdown(context.Background())
+
+	status := func(path string) int {
+		t.Helper()
+		resp, err := http.Get("http://" + srv.Addr() + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := status("/readyz"); got != http.StatusOK {
+		t.Errorf("Expected ready after start, got %d", got)
+	}
+
+	// Simulate a reload in progress, as while the config files are read
+	srv.SetReady(false)
+	if got := status("/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 during reload, got %d", got)
+	}
+	if got := status("/livez"); got != http.StatusOK {
+		t.Errorf("Expected liveness 200 during reload, got %d", got)
+	}
+
+	// Reload finishes ready, and the new handler shares the readiness state
+	if err := srv.Reload(cfg); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := status("/readyz"); got != http.StatusOK {
+		t.Errorf("Expected ready after reload, got %d", got)
+	}
+	srv.SetReady(false)
+	if got := status("/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("Expected the reloaded handler to follow readiness, got %d", got)
+	}
+
+	// A failed reload keeps the old configuration and is ready again
+	bad := cfg
+	bad.Endpoints = []models.EndpointConfig{{Path: "/api/ping?x=1", Response: `{}`}}
+	if err := srv.Reload(bad); err == nil {
+		t.Fatal("Expected reload of an invalid config to fail")
+	}
+	if got := status("/readyz"); got != http.StatusOK {
+		t.Errorf("Expected ready after a failed reload, got %d", got)
+	}
+}
+
+func TestServer_MultipleAddresses(t *testing.T) {
+	cfg := Config{Server: models.ServerConfig{Listen: []string{"127.0.0.1:0", "127.0.0.1:0"}}}
+
+	srv, err := New(cfg, Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	addrs := srv.Addrs()
+	if len(addrs) != 2 || addrs[0] == addrs[1] {
+		t.Fatalf("Expected two distinct addresses, got %v", addrs)
+	}
+	for _, addr := range addrs {
+		resp, err := http.Get("http://" + addr + "/health")
+		if err != nil {
+			t.Fatalf("Health check on %s failed: %v", addr, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Errorf("Expected status 200 on %s, got %d", addr, resp.StatusCode)
+		}
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := srv.Wait(); err != nil {
+		t.Errorf("Expected nil from Wait after Shutdown, got %v", err)
+	}
+	for _, addr := range addrs {
+		if _, err := http.Get("http://" + addr + "/health"); err == nil {
+			t.Errorf("Expected %s to stop serving after Shutdown", addr)
+		}
+	}
+}